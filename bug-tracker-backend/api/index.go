@@ -9,7 +9,10 @@ import (
 
 	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/conf"
 	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/middlewares"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/notifications"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/realtime"
 	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/routes"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/webhooks"
 )
 
 // Create a single shared Gin router instance
@@ -19,13 +22,16 @@ func init() {
 	// Load env vars & DB connection (runs once on cold start)
 	conf.LoadEnvVars()
 	conf.ConnectToDatabase()
+	notifications.Register()
+	webhooks.Register()
+	realtime.Register()
 
 	router = gin.New()
 
 	// CORS
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"}, // TODO: update with your frontend domain
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
 		ExposeHeaders:    []string{"Content-Length"},
 		AllowCredentials: true,
@@ -70,10 +76,20 @@ func init() {
 	apiV1 := router.Group("/api/v1")
 	{
 		routes.AuthRoutes(apiV1)
-		routes.UserRoutes(apiV1)
-		routes.ProjectRoutes(apiV1)
-		routes.TeamRoutes(apiV1)
-		routes.BugRoutes(apiV1)
+		routes.RealtimeRoutes(apiV1)
+		routes.CronRoutes(apiV1)
+
+		// Every other route needs an authenticated user
+		authenticated := apiV1.Group("", middlewares.RequireAuth)
+		routes.UserRoutes(authenticated)
+		routes.ProjectRoutes(authenticated)
+		routes.TeamRoutes(authenticated)
+		routes.BugRoutes(authenticated)
+		routes.CommentRoutes(authenticated)
+		routes.AttachmentRoutes(authenticated)
+		routes.MilestoneRoutes(authenticated)
+		routes.WatchRoutes(authenticated)
+		routes.WebhookRoutes(authenticated)
 	}
 }
 
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"log"
+
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/conf"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/models"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/utils"
+)
+
+// backfillBugKeys gives a key to the projects and a number to the bugs created before bug keys existed.
+// It runs before AutoMigrate, which could not add the not null and unique constraints to the existing rows.
+func backfillBugKeys() error {
+	migrator := conf.DB.Migrator()
+	if !migrator.HasTable(&models.Project{}) || !migrator.HasTable(&models.Bug{}) {
+		return nil // Fresh database, AutoMigrate creates the tables with the columns
+	}
+
+	statements := []string{
+		"ALTER TABLE projects ADD COLUMN IF NOT EXISTS key varchar(10)",
+		"ALTER TABLE projects ADD COLUMN IF NOT EXISTS bug_sequence bigint NOT NULL DEFAULT 0",
+		"ALTER TABLE bugs ADD COLUMN IF NOT EXISTS number bigint",
+	}
+	for _, statement := range statements {
+		if err := conf.DB.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+
+	var projects []models.Project
+	if err := conf.DB.Unscoped().Where("key IS NULL OR key = ''").Order("id").Find(&projects).Error; err != nil {
+		return err
+	}
+
+	for _, project := range projects {
+		key, err := utils.GenerateProjectKey(conf.DB, project.Title)
+		if err != nil {
+			return err
+		}
+		if err := conf.DB.Unscoped().Model(&project).UpdateColumn("key", key).Error; err != nil {
+			return err
+		}
+	}
+
+	// Bugs are numbered in the order they were created, after any bug of the project that already has a number
+	numbered := conf.DB.Exec(`
+		UPDATE bugs SET number = numbered.number
+		FROM (
+			SELECT b.id, COALESCE((SELECT MAX(n.number) FROM bugs n WHERE n.project_id = b.project_id), 0)
+				+ ROW_NUMBER() OVER (PARTITION BY b.project_id ORDER BY b.id) AS number
+			FROM bugs b
+			WHERE b.number IS NULL
+		) numbered
+		WHERE bugs.id = numbered.id`)
+	if numbered.Error != nil {
+		return numbered.Error
+	}
+
+	if err := conf.DB.Exec(`
+		UPDATE projects SET bug_sequence = GREATEST(bug_sequence, COALESCE((SELECT MAX(number) FROM bugs WHERE bugs.project_id = projects.id), 0))`).Error; err != nil {
+		return err
+	}
+
+	if len(projects) > 0 || numbered.RowsAffected > 0 {
+		log.Printf("Backfilled keys of %d projects and numbers of %d bugs", len(projects), numbered.RowsAffected)
+	}
+	return nil
+}
@@ -15,11 +15,38 @@ func init() {
 func main() {
 	log.Println("Starting migration...")
 
+	if err := backfillBugKeys(); err != nil {
+		log.Fatal("Error while backfilling bug keys: ", err)
+	}
+
 	conf.DB.AutoMigrate(
 		&models.User{},
+		&models.RefreshToken{},
+		&models.RevokedToken{},
+		&models.UserToken{},
+		&models.PersonalAccessToken{},
+		&models.RecoveryCode{},
+		&models.LoginAttempt{},
 		&models.Project{},
 		&models.Team{},
+		&models.Milestone{},
 		&models.Bug{},
+		&models.BugHistory{},
+		&models.Invitation{},
+		&models.Comment{},
+		&models.CommentRevision{},
+		&models.AttachmentBlob{},
+		&models.Attachment{},
+		&models.WorkflowState{},
+		&models.WorkflowTransition{},
+		&models.BugLink{},
+		&models.CustomField{},
+		&models.Watch{},
+		&models.Notification{},
+		&models.NotificationPreference{},
+		&models.EmailNotificationSetting{},
+		&models.Webhook{},
+		&models.WebhookDelivery{},
 	)
 
 	log.Println("Migration completed successfully.")
@@ -7,13 +7,20 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/conf"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/jobs"
 	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/middlewares"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/notifications"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/realtime"
 	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/routes"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/webhooks"
 )
 
 func init() {
 	conf.LoadEnvVars()
 	conf.ConnectToDatabase()
+	notifications.Register()
+	webhooks.Register()
+	realtime.Register()
 }
 
 func main() {
@@ -21,7 +28,7 @@ func main() {
 
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:5000"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
 		ExposeHeaders:    []string{"Content-Length"},
 		AllowCredentials: true,
@@ -36,11 +43,24 @@ func main() {
 	apiV1 := router.Group("/api/v1")
 	{
 		routes.AuthRoutes(apiV1)
-		routes.UserRoutes(apiV1)
-		routes.ProjectRoutes(apiV1)
-		routes.TeamRoutes(apiV1)
-		routes.BugRoutes(apiV1)
+		routes.RealtimeRoutes(apiV1)
+		routes.CronRoutes(apiV1)
+
+		// Every other route needs an authenticated user
+		authenticated := apiV1.Group("", middlewares.RequireAuth)
+		routes.UserRoutes(authenticated)
+		routes.ProjectRoutes(authenticated)
+		routes.TeamRoutes(authenticated)
+		routes.BugRoutes(authenticated)
+		routes.CommentRoutes(authenticated)
+		routes.AttachmentRoutes(authenticated)
+		routes.MilestoneRoutes(authenticated)
+		routes.WatchRoutes(authenticated)
+		routes.WebhookRoutes(authenticated)
 	}
 
+	// Background jobs
+	jobs.Start()
+
 	router.Run(":8080")
 }
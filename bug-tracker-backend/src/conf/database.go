@@ -11,6 +11,10 @@ import (
 
 var DB *gorm.DB
 
+// DSN is the connection string of the database, for the clients that need a connection of their own,
+// such as the listener of the realtime updates.
+var DSN string
+
 func ConnectToDatabase() {
 	var err error
 
@@ -35,8 +39,8 @@ func ConnectToDatabase() {
 		ssl_mode = "disable"
 	}
 
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s TimeZone=UTC", db_host, db_user, db_password, db_name, db_port, ssl_mode)
-	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	DSN = fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s TimeZone=UTC", db_host, db_user, db_password, db_name, db_port, ssl_mode)
+	DB, err = gorm.Open(postgres.Open(DSN), &gorm.Config{})
 
 	if err != nil {
 		log.Printf("Warning: Failed to connect to database: %v", err)
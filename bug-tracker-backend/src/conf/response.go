@@ -20,14 +20,32 @@ type CustomResponseWriter struct {
 	gin.ResponseWriter
 	Body       *bytes.Buffer
 	StatusCode int
+	Streaming  bool // Writes go straight to the client instead of being captured, see EnhancedContext.StartStream
 }
 
 func (r *CustomResponseWriter) Write(b []byte) (int, error) {
+	if r.Streaming {
+		return r.ResponseWriter.Write(b)
+	}
 	return r.Body.Write(b)
 }
 
+func (r *CustomResponseWriter) WriteString(str string) (int, error) {
+	return r.Write([]byte(str))
+}
+
 func (r *CustomResponseWriter) WriteHeader(statusCode int) {
 	r.StatusCode = statusCode
+	if r.Streaming {
+		r.ResponseWriter.WriteHeader(statusCode)
+	}
+}
+
+// Flush only reaches the client when streaming, otherwise it would send the headers before the response is wrapped.
+func (r *CustomResponseWriter) Flush() {
+	if r.Streaming {
+		r.ResponseWriter.Flush()
+	}
 }
 
 func (r *CustomResponseWriter) Status() int {
@@ -84,6 +102,18 @@ func (ec *EnhancedContext) BadRequestWithNoMessageAndNoData() {
 	ec.StandardJSON(http.StatusBadRequest, "Request Failed", nil)
 }
 
+func (ec *EnhancedContext) Forbidden(data any) {
+	ec.StandardJSON(http.StatusForbidden, "Request Forbidden", data)
+}
+
+func (ec *EnhancedContext) ForbiddenWithMessage(message string, data any) {
+	ec.StandardJSON(http.StatusForbidden, message, data)
+}
+
+func (ec *EnhancedContext) ForbiddenWithMessageAndNoData(message string) {
+	ec.StandardJSON(http.StatusForbidden, message, nil)
+}
+
 type validationError struct {
 	Key   string `json:"key"`
 	Error string `json:"error"`
@@ -107,6 +137,14 @@ func parseValidationErrors(input string) []validationError {
 	return result
 }
 
+// StartStream makes the rest of the response go straight to the client as it is written, such as for Server-Sent
+// Events or file downloads, instead of being captured and wrapped in the standard format once the handler returns.
+func (ec *EnhancedContext) StartStream() {
+	if w, ok := ec.Writer.(*CustomResponseWriter); ok {
+		w.Streaming = true
+	}
+}
+
 func (ec *EnhancedContext) ValidationError(errorDetails string) {
 	errors := parseValidationErrors(errorDetails)
 	if len(errors) != 0 {
@@ -0,0 +1,260 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/conf"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/mailer"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/models"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/throttle"
+	types "github.com/WNBARookie/BugTracker/bug-tracker-backend/src/types"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/utils"
+)
+
+const (
+	defaultEmailVerificationExpiresIn = 24 // in hours
+	defaultPasswordResetExpiresIn     = 60 // in minutes
+)
+
+func emailVerificationExpiresIn() time.Duration {
+	hours, err := strconv.Atoi(os.Getenv("EMAIL_VERIFICATION_EXPIRES_IN"))
+	if err != nil || hours <= 0 {
+		hours = defaultEmailVerificationExpiresIn
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+func passwordResetExpiresIn() time.Duration {
+	minutes, err := strconv.Atoi(os.Getenv("PASSWORD_RESET_EXPIRES_IN"))
+	if err != nil || minutes <= 0 {
+		minutes = defaultPasswordResetExpiresIn
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// sendTokenEmail emails the user a link to a page of the frontend that carries the token.
+func sendTokenEmail(user models.User, subject, intro, path, token string, expiresIn time.Duration) error {
+	link := utils.AppURL(path + "?token=" + url.QueryEscape(token))
+
+	return mailer.Send(mailer.Message{
+		To:      []string{user.Email},
+		Subject: subject,
+		Text: fmt.Sprintf("Hi %s,\n\n%s\n\n%s\n\nThe link expires in %s. If you did not request this, you can ignore this email.\n",
+			user.Name, intro, link, expiresIn),
+		HTML: fmt.Sprintf(`<p>Hi %s,</p><p>%s</p><p><a href="%s">%s</a></p><p>The link expires in %s. If you did not request this, you can ignore this email.</p>`,
+			html.EscapeString(user.Name), html.EscapeString(intro), html.EscapeString(link), html.EscapeString(link), expiresIn),
+	})
+}
+
+func sendVerificationEmail(user models.User) error {
+	token, err := utils.CreateUserToken(user.ID, utils.UserTokenEmailVerification, emailVerificationExpiresIn())
+	if err != nil {
+		return err
+	}
+
+	return sendTokenEmail(user, "Verify your email address", "Please verify your email address by opening the link below.",
+		"/verify-email", token, emailVerificationExpiresIn())
+}
+
+func RequestEmailVerification(c *gin.Context) {
+	ec := conf.EnhancedContext{Context: c}
+	user := utils.ExtractUserFromContext(c)
+
+	if user.EmailVerifiedAt != nil {
+		ec.BadRequestWithMessageAndNoData("Email is already verified")
+		return
+	}
+
+	if err := sendVerificationEmail(user); err != nil {
+		log.Println("Error while sending verification email:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to send verification email")
+		return
+	}
+
+	ec.SuccessWithMessageAndNoData("Verification email sent")
+}
+
+func VerifyEmail(c *gin.Context) {
+	var body types.VerifyEmail
+	ec := conf.EnhancedContext{Context: c}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		ec.ValidationError(err.Error())
+		return
+	}
+
+	err := conf.DB.Transaction(func(tx *gorm.DB) error {
+		userToken, err := utils.ConsumeUserToken(tx, body.Token, utils.UserTokenEmailVerification)
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&models.User{}).Where("id = ?", userToken.UserID).Update("email_verified_at", time.Now()).Error
+	})
+	if err != nil {
+		if errors.Is(err, utils.ErrInvalidUserToken) {
+			ec.BadRequestWithMessageAndNoData("Verification link is invalid, expired or has already been used")
+		} else {
+			log.Println("Error while verifying email:", err)
+			ec.BadRequestWithMessageAndNoData("Failed to verify email")
+		}
+		return
+	}
+
+	ec.SuccessWithMessageAndNoData("Email verified successfully")
+}
+
+func ForgotPassword(c *gin.Context) {
+	var body types.ForgotPassword
+	ec := conf.EnhancedContext{Context: c}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		ec.ValidationError(err.Error())
+		return
+	}
+
+	// The response is the same whether the user exists or not, so that registered emails are not leaked
+	if user, _ := utils.LookupUserUsingEmail(body.Email); user != nil {
+		token, err := utils.CreateUserToken(user.ID, utils.UserTokenPasswordReset, passwordResetExpiresIn())
+		if err == nil {
+			err = sendTokenEmail(*user, "Reset your password", "A password reset was requested for your account. Open the link below to choose a new password.",
+				"/reset-password", token, passwordResetExpiresIn())
+		}
+		if err != nil {
+			log.Println("Error while sending password reset email:", err)
+		}
+	}
+
+	ec.SuccessWithMessageAndNoData("If an account with that email exists, a password reset link has been sent")
+}
+
+func ResetPassword(c *gin.Context) {
+	var body types.ResetPassword
+	ec := conf.EnhancedContext{Context: c}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		ec.ValidationError(err.Error())
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(body.Password), bcrypt.DefaultCost)
+	if err != nil {
+		ec.BadRequestWithMessageAndNoData("Failed to hash password")
+		return
+	}
+
+	var userID uint
+	err = conf.DB.Transaction(func(tx *gorm.DB) error {
+		userToken, err := utils.ConsumeUserToken(tx, body.Token, utils.UserTokenPasswordReset)
+		if err != nil {
+			return err
+		}
+		userID = userToken.UserID
+
+		// Receiving the reset link also proves that the user owns the email address. Sessions opened with the old
+		// password are logged out, their access tokens are rejected from the time of the reset
+		now := time.Now()
+		if err := tx.Model(&models.User{}).Where("id = ?", userToken.UserID).Updates(map[string]any{
+			"password":          string(hashedPassword),
+			"email_verified_at": gorm.Expr("COALESCE(email_verified_at, ?)", now),
+			"password_reset_at": now,
+		}).Error; err != nil {
+			return err
+		}
+
+		return utils.RevokeRefreshTokens(tx, "user_id = ?", userToken.UserID)
+	})
+	if err != nil {
+		if errors.Is(err, utils.ErrInvalidUserToken) {
+			ec.BadRequestWithMessageAndNoData("Reset link is invalid, expired or has already been used")
+		} else {
+			log.Println("Error while resetting password:", err)
+			ec.BadRequestWithMessageAndNoData("Failed to reset password")
+		}
+		return
+	}
+
+	// A locked account is unlocked once its password has been reset
+	if user, _ := utils.LookupUserUsingID(userID); user != nil {
+		if err := throttle.Default().Reset(throttle.AccountKey(user.Email)); err != nil {
+			log.Println("Error while resetting login attempts:", err)
+		}
+	}
+
+	ec.SuccessWithMessageAndNoData("Password reset successfully")
+}
+
+func RequestAccountUnlock(c *gin.Context) {
+	var body types.RequestAccountUnlock
+	ec := conf.EnhancedContext{Context: c}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		ec.ValidationError(err.Error())
+		return
+	}
+
+	// The response is the same whether the user exists or not, so that registered emails are not leaked
+	if user, _ := utils.LookupUserUsingEmail(body.Email); user != nil {
+		token, err := utils.CreateUserToken(user.ID, utils.UserTokenAccountUnlock, passwordResetExpiresIn())
+		if err == nil {
+			err = sendTokenEmail(*user, "Unlock your account", "Your account was temporarily locked after too many failed login attempts. Open the link below to unlock it.",
+				"/unlock-account", token, passwordResetExpiresIn())
+		}
+		if err != nil {
+			log.Println("Error while sending account unlock email:", err)
+		}
+	}
+
+	ec.SuccessWithMessageAndNoData("If an account with that email exists, an unlock link has been sent")
+}
+
+func UnlockAccount(c *gin.Context) {
+	var body types.UnlockAccount
+	ec := conf.EnhancedContext{Context: c}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		ec.ValidationError(err.Error())
+		return
+	}
+
+	var userToken *models.UserToken
+	err := conf.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		userToken, err = utils.ConsumeUserToken(tx, body.Token, utils.UserTokenAccountUnlock)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, utils.ErrInvalidUserToken) {
+			ec.BadRequestWithMessageAndNoData("Unlock link is invalid, expired or has already been used")
+		} else {
+			log.Println("Error while unlocking account:", err)
+			ec.BadRequestWithMessageAndNoData("Failed to unlock account")
+		}
+		return
+	}
+
+	user, _ := utils.LookupUserUsingID(userToken.UserID)
+	if user == nil {
+		ec.BadRequestWithMessageAndNoData("Failed to unlock account")
+		return
+	}
+
+	if err := throttle.Default().Reset(throttle.AccountKey(user.Email)); err != nil {
+		log.Println("Error while resetting login attempts:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to unlock account")
+		return
+	}
+
+	ec.SuccessWithMessageAndNoData("Account unlocked successfully")
+}
@@ -0,0 +1,137 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/conf"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/middlewares"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/models"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/utils"
+)
+
+func TestVerificationLinkCanOnlyBeUsedOnce(t *testing.T) {
+	mail := setupTestDB(t, &models.User{}, &models.UserToken{})
+	user := createTestUser(t, "a@example.com", "password123")
+
+	router := gin.New()
+	router.POST("/verify-email/request", asUser(user), RequestEmailVerification)
+	router.POST("/verify-email", VerifyEmail)
+
+	if w := performJSON(router, http.MethodPost, "/verify-email/request", nil); w.Code != http.StatusOK {
+		t.Fatalf("request verification: status %d, %s", w.Code, w.Body)
+	}
+	if to := mail.Messages()[0].To; len(to) != 1 || to[0] != user.Email {
+		t.Fatalf("verification email sent to %v", to)
+	}
+	token := emailedToken(t, mail)
+
+	if w := performJSON(router, http.MethodPost, "/verify-email", gin.H{"token": token}); w.Code != http.StatusOK {
+		t.Fatalf("verify: status %d, %s", w.Code, w.Body)
+	}
+
+	var verified models.User
+	conf.DB.First(&verified, user.ID)
+	if verified.EmailVerifiedAt == nil {
+		t.Error("email is not verified")
+	}
+
+	if w := performJSON(router, http.MethodPost, "/verify-email", gin.H{"token": token}); w.Code != http.StatusBadRequest {
+		t.Errorf("second use of the link: status %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestNewVerificationLinkInvalidatesThePreviousOne(t *testing.T) {
+	mail := setupTestDB(t, &models.User{}, &models.UserToken{})
+	user := createTestUser(t, "a@example.com", "password123")
+
+	router := gin.New()
+	router.POST("/verify-email/request", asUser(user), RequestEmailVerification)
+	router.POST("/verify-email", VerifyEmail)
+
+	performJSON(router, http.MethodPost, "/verify-email/request", nil)
+	first := emailedToken(t, mail)
+	performJSON(router, http.MethodPost, "/verify-email/request", nil)
+	second := emailedToken(t, mail)
+
+	if w := performJSON(router, http.MethodPost, "/verify-email", gin.H{"token": first}); w.Code != http.StatusBadRequest {
+		t.Errorf("previous link: status %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	if w := performJSON(router, http.MethodPost, "/verify-email", gin.H{"token": second}); w.Code != http.StatusOK {
+		t.Errorf("latest link: status %d, %s", w.Code, w.Body)
+	}
+}
+
+func TestResetLinkCanOnlyBeUsedOnce(t *testing.T) {
+	mail := setupTestDB(t, &models.User{}, &models.UserToken{}, &models.RefreshToken{}, &models.RevokedToken{})
+	user := createTestUser(t, "a@example.com", "password123")
+
+	refreshToken, _, err := utils.CreateRefreshToken(conf.DB, user.ID, "family")
+	if err != nil {
+		t.Fatal(err)
+	}
+	accessToken, _, err := utils.GenerateAccessToken(user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := gin.New()
+	router.POST("/forgot-password", ForgotPassword)
+	router.POST("/reset-password", ResetPassword)
+	router.GET("/profile", middlewares.RequireAuth, func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	if w := performJSON(router, http.MethodPost, "/forgot-password", gin.H{"email": user.Email}); w.Code != http.StatusOK {
+		t.Fatalf("forgot password: status %d, %s", w.Code, w.Body)
+	}
+	token := emailedToken(t, mail)
+
+	if w := performJSON(router, http.MethodPost, "/reset-password", gin.H{"token": token, "password": "new-password"}); w.Code != http.StatusOK {
+		t.Fatalf("reset: status %d, %s", w.Code, w.Body)
+	}
+
+	var reset models.User
+	conf.DB.First(&reset, user.ID)
+	if bcrypt.CompareHashAndPassword([]byte(reset.Password), []byte("new-password")) != nil {
+		t.Error("password was not changed")
+	}
+	if reset.EmailVerifiedAt == nil {
+		t.Error("email is not verified by the reset link")
+	}
+
+	var stored models.RefreshToken
+	conf.DB.Where("token_hash = ?", utils.HashToken(refreshToken)).First(&stored)
+	if stored.RevokedAt == nil {
+		t.Error("refresh token of the old password was not revoked")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/profile", nil)
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized || !strings.Contains(w.Body.String(), "revoked") {
+		t.Errorf("access token issued before the reset: status %d, %s", w.Code, w.Body)
+	}
+
+	if w := performJSON(router, http.MethodPost, "/reset-password", gin.H{"token": token, "password": "other-password"}); w.Code != http.StatusBadRequest {
+		t.Errorf("second use of the link: status %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestForgotPasswordDoesNotRevealUnknownEmails(t *testing.T) {
+	mail := setupTestDB(t, &models.User{}, &models.UserToken{})
+
+	router := gin.New()
+	router.POST("/forgot-password", ForgotPassword)
+
+	if w := performJSON(router, http.MethodPost, "/forgot-password", gin.H{"email": "nobody@example.com"}); w.Code != http.StatusOK {
+		t.Errorf("status %d, want %d", w.Code, http.StatusOK)
+	}
+	if len(mail.Messages()) != 0 {
+		t.Error("an email was sent for an unknown address")
+	}
+}
@@ -0,0 +1,302 @@
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/conf"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/models"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/storage"
+	types "github.com/WNBARookie/BugTracker/bug-tracker-backend/src/types"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/utils"
+)
+
+// multipartOverhead leaves room for the boundaries and headers of the form on top of the file itself
+const multipartOverhead = 1 << 20
+
+func UploadAttachment(c *gin.Context) {
+	ec := conf.EnhancedContext{Context: c}
+	bug := utils.ExtractBugFromContext(c)
+	user := utils.ExtractUserFromContext(c)
+	maxSize := utils.AttachmentMaxSize()
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+multipartOverhead)
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			respondFileTooLarge(c, maxSize)
+			return
+		}
+		ec.BadRequestWithMessageAndNoData("A file must be uploaded in the file field")
+		return
+	}
+
+	if fileHeader.Size > maxSize {
+		respondFileTooLarge(c, maxSize)
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		log.Println("Error while opening uploaded file:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to upload attachment")
+		return
+	}
+	defer file.Close()
+
+	// The type is detected from the content, the one sent by the client is not trusted
+	contentType, checksum, err := inspectUpload(file)
+	if err != nil {
+		log.Println("Error while reading uploaded file:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to upload attachment")
+		return
+	}
+
+	if !utils.IsAttachmentTypeAllowed(contentType) {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{
+			"message": "Files of type " + contentType + " cannot be attached",
+			"data":    nil,
+		})
+		return
+	}
+
+	// Uploading the same file to a bug twice returns the existing attachment
+	var existing models.Attachment
+	err = conf.DB.Joins("Blob").Preload("Uploader", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Where("attachments.bug_id = ? AND \"Blob\".checksum = ?", bug.ID, checksum).
+		First(&existing).Error
+	if err == nil {
+		ec.SuccessWithMessage("File is already attached to this bug", buildAttachmentResponse(existing))
+		return
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Println("Error while looking up attachment:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to upload attachment")
+		return
+	}
+
+	blob, err := storeAttachmentBlob(c, file, fileHeader.Size, contentType, checksum)
+	if err != nil {
+		log.Println("Error while storing attachment:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to upload attachment")
+		return
+	}
+
+	attachment := models.Attachment{
+		BugID:      bug.ID,
+		UploadedBy: user.ID,
+		BlobID:     blob.ID,
+		Blob:       blob,
+		Uploader:   user,
+		Filename:   sanitizeFilename(fileHeader.Filename),
+	}
+
+	if err := conf.DB.Omit("Blob", "Uploader").Create(&attachment).Error; err != nil {
+		log.Println("Error while creating attachment:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to upload attachment")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Attachment uploaded successfully",
+		"data":    buildAttachmentResponse(attachment),
+	})
+}
+
+func GetAttachments(c *gin.Context) {
+	var params types.PaginationQueryParams
+	ec := conf.EnhancedContext{Context: c}
+	bug := utils.ExtractBugFromContext(c)
+
+	if err := c.ShouldBindQuery(&params); err != nil {
+		ec.ValidationError(err.Error())
+		return
+	}
+
+	query := conf.DB.Model(&models.Attachment{}).Where("bug_id = ?", bug.ID)
+
+	var totalCount int64
+	if err := query.Count(&totalCount).Error; err != nil {
+		log.Println("Error while counting attachments:", err)
+		ec.BadRequestWithNoMessageAndNoData()
+		return
+	}
+
+	offset := (params.Page - 1) * params.Limit
+	query = query.Joins("Blob").Preload("Uploader", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Order("attachments.created_at DESC").Limit(params.Limit).Offset(offset)
+
+	var attachments []models.Attachment
+	if err := query.Find(&attachments).Error; err != nil {
+		log.Println("Error while retrieving attachments:", err)
+		ec.BadRequestWithNoMessageAndNoData()
+		return
+	}
+
+	data := make([]types.AttachmentResponse, 0)
+	for _, attachment := range attachments {
+		data = append(data, buildAttachmentResponse(attachment))
+	}
+
+	paginatedResponse := utils.Paginate(data, params.Page, params.Limit, int(totalCount))
+	c.JSON(http.StatusOK, paginatedResponse)
+}
+
+// DownloadAttachment streams the file. Access is granted by ProjectCheckMiddleware, which re-checks
+// that the user is still a member of the project on every download.
+func DownloadAttachment(c *gin.Context) {
+	ec := conf.EnhancedContext{Context: c}
+	attachment := utils.ExtractAttachmentFromContext(c)
+
+	content, err := storage.Default().Open(c.Request.Context(), attachment.Blob.StorageKey)
+	if err != nil {
+		log.Println("Error while opening attachment:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to download attachment")
+		return
+	}
+	defer content.Close()
+
+	// Files can be large, so they are not held in memory by the standard response format
+	ec.StartStream()
+	c.DataFromReader(http.StatusOK, attachment.Blob.Size, attachment.Blob.ContentType, content, map[string]string{
+		"Content-Disposition":    mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}),
+		"X-Content-Type-Options": "nosniff",
+		"Cache-Control":          "private, no-store",
+	})
+}
+
+func DeleteAttachment(c *gin.Context) {
+	ec := conf.EnhancedContext{Context: c}
+	attachment := utils.ExtractAttachmentFromContext(c)
+	user := utils.ExtractUserFromContext(c)
+
+	if attachment.UploadedBy != user.ID && !checkPermission(c, types.PermissionAttachmentDelete) {
+		return
+	}
+
+	// The stored file is removed by the orphaned blob job once no attachment references it
+	if err := conf.DB.Unscoped().Delete(&attachment).Error; err != nil {
+		log.Println("Error while deleting attachment:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to delete attachment")
+		return
+	}
+
+	ec.SuccessWithMessageAndNoData("Attachment deleted successfully")
+}
+
+func respondFileTooLarge(c *gin.Context, maxSize int64) {
+	c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+		"message": "File is too large",
+		"data":    gin.H{"max_size": maxSize},
+	})
+}
+
+// inspectUpload detects the MIME type of the file and computes its checksum, leaving the file rewound.
+func inspectUpload(file multipart.File) (string, string, error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", "", err
+	}
+
+	contentType, _, err := mime.ParseMediaType(http.DetectContentType(head[:n]))
+	if err != nil {
+		contentType = "application/octet-stream"
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", "", err
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", "", err
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", "", err
+	}
+
+	return contentType, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// storeAttachmentBlob returns the blob with the checksum, writing the file to the storage only if it is not stored yet.
+func storeAttachmentBlob(c *gin.Context, file io.Reader, size int64, contentType, checksum string) (models.AttachmentBlob, error) {
+	var blob models.AttachmentBlob
+	err := conf.DB.Where("checksum = ?", checksum).First(&blob).Error
+	if err == nil {
+		// Keep the blob from being purged as orphaned while it gets a new attachment
+		return blob, conf.DB.Model(&blob).Update("updated_at", time.Now()).Error
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return blob, err
+	}
+
+	blob = models.AttachmentBlob{
+		Checksum:    checksum,
+		Size:        size,
+		ContentType: contentType,
+		StorageKey:  utils.AttachmentStorageKey(checksum),
+	}
+
+	if err := storage.Default().Put(c.Request.Context(), blob.StorageKey, file, size, contentType); err != nil {
+		return blob, err
+	}
+
+	// Another upload of the same file may have created the blob in the meantime
+	if err := conf.DB.Where("checksum = ?", checksum).FirstOrCreate(&blob).Error; err != nil {
+		return blob, err
+	}
+
+	return blob, nil
+}
+
+// sanitizeFilename keeps the base name of the uploaded file, without path separators or control characters.
+func sanitizeFilename(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || r == '"' {
+			return -1
+		}
+		return r
+	}, name)
+
+	if name == "" || name == "." || name == "/" {
+		name = "attachment"
+	}
+
+	for len(name) > 255 {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	return name
+}
+
+func buildAttachmentResponse(attachment models.Attachment) types.AttachmentResponse {
+	return types.AttachmentResponse{
+		ID:          attachment.ID,
+		BugID:       attachment.BugID,
+		Filename:    attachment.Filename,
+		ContentType: attachment.Blob.ContentType,
+		Size:        attachment.Blob.Size,
+		Checksum:    attachment.Blob.Checksum,
+		UploadedBy: types.AssignedTo{
+			ID:    attachment.Uploader.ID,
+			Name:  attachment.Uploader.Name,
+			Email: attachment.Uploader.Email,
+		},
+		CreatedAt: attachment.CreatedAt,
+	}
+}
@@ -1,21 +1,27 @@
 package controllers
 
 import (
+	"errors"
 	"log"
+	"math"
 	"math/rand"
 	"net/http"
-	"os"
 	"strconv"
 	s "strings"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/conf"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/events"
 	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/models"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/throttle"
 	types "github.com/WNBARookie/BugTracker/bug-tracker-backend/src/types"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/utils"
 )
 
 func generateRandomString() string {
@@ -43,6 +49,34 @@ func createNewUser(newUser models.User) (*models.User, error) {
 	return &newUser, nil
 }
 
+const invalidCredentialsMessage = "Invalid email or password"
+
+var dummyPasswordHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("dummy-password"), bcrypt.DefaultCost)
+	return hash
+})
+
+func recordFailedLogin(accountKey, ipKey string) {
+	limiter := throttle.Default()
+	if err := limiter.RecordFailure(accountKey, throttle.AccountPolicy); err != nil {
+		log.Println("Error while recording failed login:", err)
+	}
+	if ipKey != "" {
+		if err := limiter.RecordFailure(ipKey, throttle.IPPolicy); err != nil {
+			log.Println("Error while recording failed login:", err)
+		}
+	}
+}
+
+func respondTooManyAttempts(c *gin.Context, retryAfter time.Duration) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"message": "Too many failed login attempts, please try again later",
+		"data":    gin.H{"retry_after": seconds},
+	})
+}
+
 func SignUp(c *gin.Context) {
 	var user types.SignUpUser
 
@@ -81,15 +115,39 @@ func SignUp(c *gin.Context) {
 		return
 	}
 
+	if err := sendVerificationEmail(*createdUser); err != nil {
+		log.Println("Error while sending verification email:", err)
+	}
+
+	message := "User created successfully"
+	if user.InvitationToken != nil {
+		var member *models.Team
+		err := conf.DB.Transaction(func(tx *gorm.DB) error {
+			var err error
+			_, member, err = respondToInvitation(tx, *user.InvitationToken, *createdUser, true)
+			return err
+		})
+		if err != nil {
+			log.Println("Error while accepting invitation on sign up:", err)
+			message = "User created successfully, but the invitation could not be accepted"
+		} else {
+			message = "User created successfully and added to the project"
+			if member != nil {
+				publishTeamEvent(events.TeamMemberAdded, *member, *createdUser, createdUser.ID)
+			}
+		}
+	}
+
 	c.JSON(http.StatusCreated, gin.H{
-		"message": "User created successfully",
+		"message": message,
 		"data": types.UserResponse{
-			ID:        createdUser.ID,
-			Name:      createdUser.Name,
-			Username:  createdUser.Username,
-			Email:     createdUser.Email,
-			CreatedAt: createdUser.CreatedAt,
-			UpdatedAt: createdUser.UpdatedAt,
+			ID:              createdUser.ID,
+			Name:            createdUser.Name,
+			Username:        createdUser.Username,
+			Email:           createdUser.Email,
+			EmailVerifiedAt: createdUser.EmailVerifiedAt,
+			CreatedAt:       createdUser.CreatedAt,
+			UpdatedAt:       createdUser.UpdatedAt,
 		},
 	})
 
@@ -103,35 +161,208 @@ func Login(c *gin.Context) {
 		return
 	}
 
-	var existingUser *models.User
-	conf.DB.First(&existingUser, "email = ?", user.Email)
-	if existingUser == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "User not found"})
+	limiter := throttle.Default()
+	accountKey, ipKey := throttle.AccountKey(user.Email), throttle.IPKey(c.ClientIP())
+
+	retryAfter, err := limiter.RetryAfter(accountKey, ipKey)
+	if err != nil {
+		log.Println("Error while checking login attempts:", err)
+	} else if retryAfter > 0 {
+		respondTooManyAttempts(c, retryAfter)
 		return
 	}
 
-	err := bcrypt.CompareHashAndPassword([]byte(existingUser.Password), []byte(user.Password))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid password"})
+	existingUser, _ := utils.LookupUserUsingEmail(user.Email)
+
+	// The password is compared even if the user does not exist, so that the response time does not leak registered emails
+	passwordHash := dummyPasswordHash()
+	if existingUser != nil {
+		passwordHash = []byte(existingUser.Password)
+	}
+
+	if err := bcrypt.CompareHashAndPassword(passwordHash, []byte(user.Password)); err != nil || existingUser == nil {
+		recordFailedLogin(accountKey, ipKey)
+		c.JSON(http.StatusBadRequest, gin.H{"message": invalidCredentialsMessage})
 		return
 	}
 
-	expiresInStr := os.Getenv("JWT_EXPIRES_IN")
-	expiresIn, _ := strconv.Atoi(expiresInStr)
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub": existingUser.ID,
-		"exp": time.Now().Add(time.Duration(expiresIn) * time.Minute).Unix(),
-	})
+	// Users with two-factor authentication get a challenge to complete instead of the tokens. Their failed attempts
+	// are only forgotten once the challenge is completed, so that wrong codes keep counting after the password
+	if existingUser.TOTPEnabledAt != nil {
+		challengeToken, expiresAt, err := utils.GenerateChallengeToken(existingUser.ID)
+		if err != nil {
+			log.Println("Failed to sign challenge token:", err)
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to generate token"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Two-factor authentication required",
+			"data": types.LoginChallengeResponse{
+				Type:           utils.TokenTypeTwoFactorChallenge,
+				ChallengeToken: challengeToken,
+				ExpiresAt:      expiresAt,
+			},
+		})
+		return
+	}
+
+	if err := limiter.Reset(accountKey); err != nil {
+		log.Println("Error while resetting login attempts:", err)
+	}
 
-	tokenString, err := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	tokens, err := issueTokenPair(existingUser.ID)
 	if err != nil {
-		log.Println("Failed to sign token:", err)
+		log.Println("Failed to issue tokens:", err)
 		c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to generate token"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"type":  "Bearer",
-		"token": tokenString,
+	c.JSON(http.StatusOK, tokens)
+}
+
+// issueTokenPair creates an access token and the first refresh token of a new token family for the user.
+func issueTokenPair(userID uint) (*types.TokenResponse, error) {
+	familyID, err := utils.RandomID()
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, storedToken, err := utils.CreateRefreshToken(conf.DB, userID, familyID)
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, expiresAt, err := utils.GenerateAccessToken(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.TokenResponse{
+		Type:             "Bearer",
+		Token:            accessToken,
+		ExpiresAt:        expiresAt,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: storedToken.ExpiresAt,
+	}, nil
+}
+
+func RefreshToken(c *gin.Context) {
+	var body types.RefreshTokenRequest
+	ec := conf.EnhancedContext{Context: c}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		ec.ValidationError(err.Error())
+		return
+	}
+
+	tokenHash, ok := utils.VerifySignedToken(body.RefreshToken)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid refresh token"})
+		return
+	}
+
+	var tokens *types.TokenResponse
+	reused := false
+
+	err := conf.DB.Transaction(func(tx *gorm.DB) error {
+		var storedToken models.RefreshToken
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("token_hash = ?", tokenHash).First(&storedToken).Error; err != nil {
+			return err
+		}
+
+		// A revoked token being used again means that it has been stolen, so the whole family is revoked
+		if storedToken.RevokedAt != nil {
+			reused = true
+			return utils.RevokeRefreshTokens(tx, "family_id = ?", storedToken.FamilyID)
+		}
+
+		if time.Now().After(storedToken.ExpiresAt) {
+			return gorm.ErrRecordNotFound
+		}
+
+		refreshToken, newToken, err := utils.CreateRefreshToken(tx, storedToken.UserID, storedToken.FamilyID)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Model(&storedToken).Updates(map[string]any{
+			"revoked_at":     time.Now(),
+			"replaced_by_id": newToken.ID,
+		}).Error; err != nil {
+			return err
+		}
+
+		accessToken, expiresAt, err := utils.GenerateAccessToken(storedToken.UserID)
+		if err != nil {
+			return err
+		}
+
+		tokens = &types.TokenResponse{
+			Type:             "Bearer",
+			Token:            accessToken,
+			ExpiresAt:        expiresAt,
+			RefreshToken:     refreshToken,
+			RefreshExpiresAt: newToken.ExpiresAt,
+		}
+		return nil
 	})
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid refresh token"})
+		} else {
+			log.Println("Error while refreshing token:", err)
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to refresh token"})
+		}
+		return
+	}
+
+	if reused {
+		log.Println("Refresh token reuse detected, token family revoked")
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Refresh token has already been used, please log in again"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+func Logout(c *gin.Context) {
+	var body types.LogoutUser
+	ec := conf.EnhancedContext{Context: c}
+	user := utils.ExtractUserFromContext(c)
+
+	// The body is optional, the access token is revoked either way
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			ec.ValidationError(err.Error())
+			return
+		}
+	}
+
+	jti, expiresAt := utils.ExtractAccessTokenFromContext(c)
+	if jti != "" {
+		if err := utils.RevokeAccessToken(jti, expiresAt); err != nil {
+			log.Println("Error while revoking access token:", err)
+			ec.BadRequestWithMessageAndNoData("Failed to log out")
+			return
+		}
+	}
+
+	var err error
+	if body.AllSessions {
+		err = utils.RevokeRefreshTokens(conf.DB, "user_id = ?", user.ID)
+	} else if body.RefreshToken != nil {
+		if tokenHash, ok := utils.VerifySignedToken(*body.RefreshToken); ok {
+			err = utils.RevokeRefreshTokens(conf.DB, "user_id = ? AND family_id IN (?)", user.ID,
+				conf.DB.Model(&models.RefreshToken{}).Select("family_id").Where("token_hash = ?", tokenHash))
+		}
+	}
+	if err != nil {
+		log.Println("Error while revoking refresh tokens:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to log out")
+		return
+	}
+
+	ec.SuccessWithMessageAndNoData("Logged out successfully")
 }
@@ -0,0 +1,95 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/conf"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/models"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/throttle"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/utils"
+)
+
+func TestLoginResetsFailedAttempts(t *testing.T) {
+	setupTestDB(t, &models.User{}, &models.RefreshToken{})
+	user := createTestUser(t, "a@example.com", "password123")
+
+	router := gin.New()
+	router.POST("/login", Login)
+
+	for i := 0; i < throttle.AccountPolicy.FreeAttempts; i++ {
+		if w := performJSON(router, http.MethodPost, "/login", gin.H{"email": user.Email, "password": "wrong-password"}); w.Code != http.StatusBadRequest {
+			t.Fatalf("wrong password: status %d, want %d", w.Code, http.StatusBadRequest)
+		}
+	}
+
+	if w := performJSON(router, http.MethodPost, "/login", gin.H{"email": user.Email, "password": "password123"}); w.Code != http.StatusOK {
+		t.Fatalf("right password: status %d, %s", w.Code, w.Body)
+	}
+
+	if attempt, _ := throttle.Default().Store.Get(throttle.AccountKey(user.Email)); attempt.Failures != 0 {
+		t.Errorf("failures after a login = %d, want 0", attempt.Failures)
+	}
+}
+
+func TestPasswordDoesNotResetFailedTwoFactorCodes(t *testing.T) {
+	setupTestDB(t, &models.User{}, &models.RefreshToken{}, &models.RevokedToken{}, &models.RecoveryCode{})
+	user := createTestUser(t, "a@example.com", "password123")
+
+	secret, _ := utils.GenerateTOTPSecret()
+	now := time.Now()
+	conf.DB.Model(&user).Updates(map[string]any{"totp_secret": secret, "totp_enabled_at": now})
+
+	wrongCode := "000000"
+	if _, ok := utils.ValidateTOTP(secret, wrongCode, now); ok {
+		wrongCode = "111111"
+	}
+
+	router := gin.New()
+	router.POST("/login", Login)
+	router.POST("/login/2fa", LoginTwoFactor)
+
+	login := func() (int, string) {
+		w := performJSON(router, http.MethodPost, "/login", gin.H{"email": user.Email, "password": "password123"})
+
+		var response struct {
+			Data struct {
+				ChallengeToken string `json:"challenge_token"`
+			} `json:"data"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w.Code, response.Data.ChallengeToken
+	}
+
+	status, challenge := login()
+	if status != http.StatusOK || challenge == "" {
+		t.Fatalf("password: status %d, challenge %q", status, challenge)
+	}
+
+	for i := 0; i < throttle.AccountPolicy.FreeAttempts; i++ {
+		if w := performJSON(router, http.MethodPost, "/login/2fa", gin.H{"challenge_token": challenge, "code": wrongCode}); w.Code != http.StatusUnauthorized {
+			t.Fatalf("wrong code: status %d, %s", w.Code, w.Body)
+		}
+	}
+
+	// The password is still accepted, but does not give a fresh set of guesses
+	status, challenge = login()
+	if status != http.StatusOK {
+		t.Fatalf("password after the wrong codes: status %d", status)
+	}
+
+	if w := performJSON(router, http.MethodPost, "/login/2fa", gin.H{"challenge_token": challenge, "code": wrongCode}); w.Code != http.StatusUnauthorized {
+		t.Fatalf("wrong code: status %d, %s", w.Code, w.Body)
+	}
+
+	if status, _ := login(); status != http.StatusTooManyRequests {
+		t.Errorf("password after the lockout: status %d, want %d", status, http.StatusTooManyRequests)
+	}
+	if w := performJSON(router, http.MethodPost, "/login/2fa", gin.H{"challenge_token": challenge, "code": wrongCode}); w.Code != http.StatusTooManyRequests {
+		t.Errorf("code after the lockout: status %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+}
@@ -3,13 +3,17 @@ package controllers
 import (
 	"log"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/lib/pq"
+	"gorm.io/gorm"
 
 	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/conf"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/events"
 	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/models"
 	types "github.com/WNBARookie/BugTracker/bug-tracker-backend/src/types"
 	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/utils"
@@ -30,11 +34,76 @@ func CreateBug(c *gin.Context) {
 		return
 	}
 
+	project := utils.ExtractProjectFromContext(c)
+	role, err := utils.CheckIfUserIsProjectMember(assignedTo.ID, project.ID)
+	if err != nil {
+		log.Println("Error while checking project membership:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to create bug")
+		return
+	} else if role == "" {
+		ec.BadRequestWithMessageAndNoData("Assigned user is not a member of this project")
+		return
+	}
+
+	if assignedTo.ID != utils.ExtractUserFromContext(c).ID && !checkPermission(c, types.PermissionBugAssign) {
+		return
+	}
+
+	workflow, err := utils.GetProjectWorkflow(conf.DB, project.ID)
+	if err != nil {
+		log.Println("Error while retrieving project workflow:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to create bug")
+		return
+	}
+
+	// Bugs start in the initial state, or in a state the member could move them to from there
+	bug.SetDefaults()
+	initialState := workflow.InitialState().Key
+	if bug.Status == "" {
+		bug.Status = types.BugStatus(initialState)
+	} else if bug.Status.Value() != initialState && !checkStatusTransition(c, workflow, initialState, bug.Status.Value()) {
+		return
+	}
+
 	if time.Now().After(bug.Deadline) {
 		ec.BadRequestWithMessageAndNoData("Deadline cannot be in the past")
 		return
 	}
 
+	var parentID *uint
+	if bug.Parent != "" {
+		parent, ok := resolveParentBug(c, project, bug.Parent)
+		if !ok {
+			return
+		}
+		parentID = &parent.ID
+	}
+
+	if bug.MilestoneID != nil && *bug.MilestoneID == 0 {
+		bug.MilestoneID = nil
+	} else if bug.MilestoneID != nil {
+		if _, ok := resolveMilestone(c, project, *bug.MilestoneID); !ok {
+			return
+		}
+	}
+
+	fields, err := utils.GetProjectCustomFields(conf.DB, project.ID)
+	if err != nil {
+		log.Println("Error while retrieving custom fields:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to create bug")
+		return
+	}
+
+	customFields, err := utils.ApplyCustomFieldValues(fields, project.ID, nil, bug.CustomFields)
+	if fieldErr, ok := utils.IsCustomFieldError(err); ok {
+		ec.BadRequestWithMessageAndNoData(fieldErr.Message)
+		return
+	} else if err != nil {
+		log.Println("Error while validating custom fields:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to create bug")
+		return
+	}
+
 	newBug := models.Bug{
 		Title:       bug.Title,
 		Description: bug.Description,
@@ -43,35 +112,80 @@ func CreateBug(c *gin.Context) {
 		Status:      bug.Status.Value(),
 		Priority:    bug.Priority.Value(),
 		AssignedTo:  bug.AssignedTo,
-		ProjectID:   utils.ExtractProjectFromContext(c).ID,
+		ProjectID:   project.ID,
+		ParentID:    parentID,
+		MilestoneID: bug.MilestoneID,
+
+		CustomFields: customFields,
 	}
 
-	result := conf.DB.Create(&newBug)
-	if result.Error != nil {
-		log.Println("Error while creating bug:", result.Error)
+	err = conf.DB.Transaction(func(tx *gorm.DB) error {
+		number, err := utils.NextBugNumber(tx, project.ID)
+		if err != nil {
+			return err
+		}
+
+		newBug.Number = number
+		if err := tx.Create(&newBug).Error; err != nil {
+			return err
+		}
+
+		// The reporter and the assignee follow the bug from the start
+		if err := utils.WatchBug(tx, utils.ExtractUserFromContext(c).ID, newBug, utils.WatchReasonCreated); err != nil {
+			return err
+		}
+		return utils.WatchBug(tx, newBug.AssignedTo, newBug, utils.WatchReasonAssigned)
+	})
+
+	if err != nil {
+		log.Println("Error while creating bug:", err)
 		ec.BadRequestWithMessageAndNoData("Failed to create bug")
 		return
 	}
 
+	response := types.BugResponse{
+		ID:          newBug.ID,
+		Key:         utils.BugKey(project.Key, newBug.Number),
+		Number:      newBug.Number,
+		Title:       newBug.Title,
+		Description: newBug.Description,
+		Tags:        newBug.Tags,
+		Deadline:    newBug.Deadline,
+		Status:      types.BugStatus(newBug.Status),
+		Priority:    types.Priority(newBug.Priority),
+		AssignedTo: types.AssignedTo{
+			ID:    assignedTo.ID,
+			Name:  assignedTo.Name,
+			Email: assignedTo.Email,
+		},
+		ProjectID:   newBug.ProjectID,
+		ParentID:    newBug.ParentID,
+		MilestoneID: newBug.MilestoneID,
+		CreatedAt:   newBug.CreatedAt,
+		UpdatedAt:   newBug.UpdatedAt,
+
+		CustomFields: newBug.CustomFields,
+	}
+
+	actorID := utils.ExtractUserFromContext(c).ID
+	events.Publish(events.Event{
+		Type:      events.BugCreated,
+		ProjectID: newBug.ProjectID,
+		BugID:     newBug.ID,
+		ActorID:   actorID,
+		Data:      map[string]any{"bug": response},
+	})
+	events.Publish(events.Event{
+		Type:      events.BugAssigned,
+		ProjectID: newBug.ProjectID,
+		BugID:     newBug.ID,
+		ActorID:   actorID,
+		UserIDs:   []uint{newBug.AssignedTo},
+	})
+
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "Bug created successfully",
-		"data": types.BugResponse{
-			ID:          newBug.ID,
-			Title:       newBug.Title,
-			Description: newBug.Description,
-			Tags:        newBug.Tags,
-			Deadline:    newBug.Deadline,
-			Status:      types.BugStatus(newBug.Status),
-			Priority:    types.Priority(newBug.Priority),
-			AssignedTo: types.AssignedTo{
-				ID:    assignedTo.ID,
-				Name:  assignedTo.Name,
-				Email: assignedTo.Email,
-			},
-			ProjectID: newBug.ProjectID,
-			CreatedAt: newBug.CreatedAt,
-			UpdatedAt: newBug.UpdatedAt,
-		},
+		"data":    response,
 	})
 }
 
@@ -87,6 +201,13 @@ func GetAllBugs(c *gin.Context) {
 	project := utils.ExtractProjectFromContext(c)
 	query := conf.DB.Model(&models.Bug{}).Where("project_id = ?", project.ID)
 
+	workflow, err := utils.GetProjectWorkflow(conf.DB, project.ID)
+	if err != nil {
+		log.Println("Error while retrieving project workflow:", err)
+		ec.BadRequestWithNoMessageAndNoData()
+		return
+	}
+
 	if params.Search != nil {
 		searchTerm := "%" + *params.Search + "%"
 		query = query.Where("title ILIKE ? OR description ILIKE ?", searchTerm, searchTerm)
@@ -123,6 +244,10 @@ func GetAllBugs(c *gin.Context) {
 		query = query.Where("status = ?", *params.Status)
 	}
 
+	if params.Category != nil {
+		query = query.Where("status IN ?", workflow.StatesInCategory(types.WorkflowCategory(*params.Category)))
+	}
+
 	if params.Priority != nil {
 		query = query.Where("priority = ?", *params.Priority)
 	}
@@ -131,6 +256,66 @@ func GetAllBugs(c *gin.Context) {
 		query = query.Where("assigned_to = ?", *params.AssignedTo)
 	}
 
+	if params.Parent != nil {
+		parent, ok := resolveParentBug(c, project, *params.Parent)
+		if !ok {
+			return
+		}
+		query = query.Where("parent_id = ?", parent.ID)
+	}
+
+	if params.Milestone != nil {
+		if *params.Milestone == 0 {
+			query = query.Where("milestone_id IS NULL")
+		} else {
+			query = query.Where("milestone_id = ?", *params.Milestone)
+		}
+	}
+
+	var fields []models.CustomField
+	for name, values := range c.Request.URL.Query() {
+		key, ok := strings.CutPrefix(name, utils.CustomFieldFilterPrefix)
+		if !ok {
+			continue
+		}
+
+		if fields == nil {
+			var err error
+			if fields, err = utils.GetProjectCustomFields(conf.DB, project.ID); err != nil {
+				log.Println("Error while retrieving custom fields:", err)
+				ec.BadRequestWithNoMessageAndNoData()
+				return
+			}
+		}
+
+		i := slices.IndexFunc(fields, func(field models.CustomField) bool { return field.Key == key })
+		if i < 0 {
+			ec.ValidationError("Unknown custom field: " + key)
+			return
+		}
+
+		for _, value := range values {
+			filtered, err := utils.WhereCustomField(query, fields[i], value)
+			if fieldErr, ok := utils.IsCustomFieldError(err); ok {
+				ec.ValidationError(fieldErr.Message)
+				return
+			} else if err != nil {
+				log.Println("Error while filtering on custom field:", err)
+				ec.BadRequestWithNoMessageAndNoData()
+				return
+			}
+			query = filtered
+		}
+	}
+
+	if params.TopLevel != nil {
+		if *params.TopLevel {
+			query = query.Where("parent_id IS NULL")
+		} else {
+			query = query.Where("parent_id IS NOT NULL")
+		}
+	}
+
 	var totalCount int64
 	if err := query.Count(&totalCount).Error; err != nil {
 		log.Println("Error while counting bugs:", err)
@@ -151,30 +336,13 @@ func GetAllBugs(c *gin.Context) {
 	// Convert models.Bug to types.BugResponse
 	data := make([]types.BugResponse, 0)
 	for _, bug := range bugs {
-		assignedTo, _ := utils.LookupUserUsingID(bug.AssignedTo)
-		assignedToResponse := types.AssignedTo{
-			ID:    bug.AssignedTo,
-			Name:  "",
-			Email: "",
-		}
-		if assignedTo != nil {
-			assignedToResponse.Name = assignedTo.Name
-			assignedToResponse.Email = assignedTo.Email
-		}
-
-		data = append(data, types.BugResponse{
-			ID:          bug.ID,
-			Title:       bug.Title,
-			Description: bug.Description,
-			Tags:        bug.Tags,
-			Deadline:    bug.Deadline,
-			Status:      types.BugStatus(bug.Status),
-			Priority:    types.Priority(bug.Priority),
-			AssignedTo:  assignedToResponse,
-			ProjectID:   bug.ProjectID,
-			CreatedAt:   bug.CreatedAt,
-			UpdatedAt:   bug.UpdatedAt,
-		})
+		data = append(data, buildBugResponse(bug, project.Key))
+	}
+
+	if err := addBugProgress(conf.DB, workflow, data); err != nil {
+		log.Println("Error while counting sub-tasks:", err)
+		ec.BadRequestWithNoMessageAndNoData()
+		return
 	}
 
 	paginatedResponse := utils.Paginate(data, params.Page, params.Limit, int(totalCount))
@@ -184,31 +352,479 @@ func GetAllBugs(c *gin.Context) {
 func GetBugByID(c *gin.Context) {
 	ec := conf.EnhancedContext{Context: c}
 	bug := utils.ExtractBugFromContext(c)
+	project := utils.ExtractProjectFromContext(c)
 
-	ec.SuccessWithMessage(
-		"Bug retrieved successfully",
-		types.BugResponse{
-			ID:          bug.ID,
-			Title:       bug.Title,
-			Description: bug.Description,
-			Tags:        bug.Tags,
-			Deadline:    bug.Deadline,
-			Status:      types.BugStatus(bug.Status),
-			Priority:    types.Priority(bug.Priority),
-			AssignedTo: types.AssignedTo{
-				ID:    bug.AssignedTo,
-				Name:  "", // To be filled if needed
-				Email: "", // To be filled if needed
-			},
-			ProjectID: bug.ProjectID,
-			CreatedAt: bug.CreatedAt,
-			UpdatedAt: bug.UpdatedAt,
-		},
-	)
+	response := buildBugResponse(bug, project.Key)
+
+	links, err := loadBugLinks(bug, project.Key)
+	if err != nil {
+		log.Println("Error while retrieving bug links:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to retrieve bug")
+		return
+	}
+	response.Links = links
+
+	workflow, err := utils.GetProjectWorkflow(conf.DB, project.ID)
+	if err != nil {
+		log.Println("Error while retrieving project workflow:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to retrieve bug")
+		return
+	}
+
+	responses := []types.BugResponse{response}
+	if err := addBugProgress(conf.DB, workflow, responses); err != nil {
+		log.Println("Error while counting sub-tasks:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to retrieve bug")
+		return
+	}
+
+	ec.SuccessWithMessage("Bug retrieved successfully", responses[0])
 }
 
 func UpdateBug(c *gin.Context) {
+	var updatedBug types.UpdateBug
+	ec := conf.EnhancedContext{Context: c}
+	bug := utils.ExtractBugFromContext(c)
+	project := utils.ExtractProjectFromContext(c)
+	user := utils.ExtractUserFromContext(c)
+
+	if err := c.ShouldBindJSON(&updatedBug); err != nil {
+		ec.ValidationError(err.Error())
+		return
+	}
+
+	updateData := make(map[string]any)
+	var history []models.BugHistory
+
+	// Records the change of a field only if its value is actually different
+	trackChange := func(column, field, oldValue, newValue string, value any) {
+		if oldValue == newValue {
+			return
+		}
+		updateData[column] = value
+		history = append(history, models.BugHistory{
+			BugID:     bug.ID,
+			ChangedBy: user.ID,
+			Field:     field,
+			OldValue:  oldValue,
+			NewValue:  newValue,
+		})
+	}
+
+	if updatedBug.Title != nil {
+		if strings.TrimSpace(*updatedBug.Title) == "" {
+			ec.BadRequestWithMessageAndNoData("Title cannot be empty")
+			return
+		}
+		trackChange("title", "title", bug.Title, *updatedBug.Title, *updatedBug.Title)
+	}
+
+	if updatedBug.Description != nil {
+		trackChange("description", "description", bug.Description, *updatedBug.Description, *updatedBug.Description)
+	}
+
+	if updatedBug.Tags != nil {
+		tags := pq.StringArray(*updatedBug.Tags)
+		trackChange("tags", "tags", strings.Join(bug.Tags, ","), strings.Join(tags, ","), tags)
+	}
+
+	if updatedBug.Deadline != nil {
+		if !updatedBug.Deadline.Equal(bug.Deadline) && time.Now().After(*updatedBug.Deadline) {
+			ec.BadRequestWithMessageAndNoData("Deadline cannot be in the past")
+			return
+		}
+		if !updatedBug.Deadline.Equal(bug.Deadline) {
+			trackChange("deadline", "deadline", bug.Deadline.UTC().Format(time.RFC3339), updatedBug.Deadline.UTC().Format(time.RFC3339), *updatedBug.Deadline)
+			updateData["deadline_notified_at"] = nil // The new deadline gets its own reminder
+		}
+	}
+
+	if updatedBug.Status != nil && updatedBug.Status.Value() != bug.Status {
+		workflow, err := utils.GetProjectWorkflow(conf.DB, project.ID)
+		if err != nil {
+			log.Println("Error while retrieving project workflow:", err)
+			ec.BadRequestWithMessageAndNoData("Failed to update bug")
+			return
+		}
+
+		if !checkStatusTransition(c, workflow, bug.Status, updatedBug.Status.Value()) {
+			return
+		}
+
+		if workflow.IsClosed(updatedBug.Status.Value()) && !workflow.IsClosed(bug.Status) {
+			allowed, err := canCloseWithChildren(conf.DB, project, workflow, bug.ID)
+			if err != nil {
+				log.Println("Error while counting open sub-tasks:", err)
+				ec.BadRequestWithMessageAndNoData("Failed to update bug")
+				return
+			} else if !allowed {
+				c.JSON(http.StatusConflict, gin.H{"message": "Bug has open sub-tasks, close them first", "data": nil})
+				return
+			}
+		}
+		trackChange("status", "status", bug.Status, updatedBug.Status.Value(), updatedBug.Status.Value())
+	}
+
+	if updatedBug.Priority != nil {
+		trackChange("priority", "priority", strconv.FormatUint(uint64(bug.Priority), 10), strconv.FormatUint(uint64(updatedBug.Priority.Value()), 10), updatedBug.Priority.Value())
+	}
+
+	if updatedBug.AssignedTo != nil && *updatedBug.AssignedTo != bug.AssignedTo {
+		if *updatedBug.AssignedTo != user.ID && !checkPermission(c, types.PermissionBugAssign) {
+			return
+		}
+
+		role, err := utils.CheckIfUserIsProjectMember(*updatedBug.AssignedTo, bug.ProjectID)
+		if err != nil {
+			log.Println("Error while checking project membership:", err)
+			ec.BadRequestWithMessageAndNoData("Failed to update bug")
+			return
+		} else if role == "" {
+			ec.BadRequestWithMessageAndNoData("Assigned user is not a member of this project")
+			return
+		}
+		trackChange("assigned_to", "assigned_to", strconv.FormatUint(uint64(bug.AssignedTo), 10), strconv.FormatUint(uint64(*updatedBug.AssignedTo), 10), *updatedBug.AssignedTo)
+	}
+
+	if updatedBug.Parent != nil {
+		oldParent, err := parentKey(conf.DB, project.Key, bug.ParentID)
+		if err != nil {
+			log.Println("Error while retrieving parent bug:", err)
+			ec.BadRequestWithMessageAndNoData("Failed to update bug")
+			return
+		}
+
+		if *updatedBug.Parent == "" {
+			trackChange("parent_id", "parent", oldParent, "", nil)
+		} else {
+			parent, ok := resolveParentBug(c, project, *updatedBug.Parent)
+			if !ok || !checkParentChange(c, bug, parent) {
+				return
+			}
+			trackChange("parent_id", "parent", oldParent, utils.BugKey(project.Key, parent.Number), parent.ID)
+		}
+	}
+
+	if updatedBug.MilestoneID != nil {
+		oldMilestone, err := milestoneTitle(conf.DB, bug.MilestoneID)
+		if err != nil {
+			log.Println("Error while retrieving milestone:", err)
+			ec.BadRequestWithMessageAndNoData("Failed to update bug")
+			return
+		}
+
+		if *updatedBug.MilestoneID == 0 {
+			if bug.MilestoneID != nil {
+				trackChange("milestone_id", "milestone", oldMilestone, "", nil)
+			}
+		} else if bug.MilestoneID == nil || *bug.MilestoneID != *updatedBug.MilestoneID {
+			milestone, ok := resolveMilestone(c, project, *updatedBug.MilestoneID)
+			if !ok {
+				return
+			}
+			trackChange("milestone_id", "milestone", oldMilestone, milestone.Title, milestone.ID)
+		}
+	}
+
+	if updatedBug.CustomFields != nil {
+		fields, err := utils.GetProjectCustomFields(conf.DB, project.ID)
+		if err != nil {
+			log.Println("Error while retrieving custom fields:", err)
+			ec.BadRequestWithMessageAndNoData("Failed to update bug")
+			return
+		}
+
+		customFields, err := utils.ApplyCustomFieldValues(fields, project.ID, bug.CustomFields, updatedBug.CustomFields)
+		if fieldErr, ok := utils.IsCustomFieldError(err); ok {
+			ec.BadRequestWithMessageAndNoData(fieldErr.Message)
+			return
+		} else if err != nil {
+			log.Println("Error while validating custom fields:", err)
+			ec.BadRequestWithMessageAndNoData("Failed to update bug")
+			return
+		}
+
+		keys := make([]string, 0, len(updatedBug.CustomFields))
+		for key := range updatedBug.CustomFields {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+
+		for _, key := range keys {
+			trackChange("custom_fields", utils.CustomFieldFilterPrefix+key, customFieldHistoryValue(bug.CustomFields[key]), customFieldHistoryValue(customFields[key]), customFields)
+		}
+	}
+
+	if len(updateData) == 0 {
+		ec.SuccessWithMessage("No changes to update", buildBugResponse(bug, project.Key))
+		return
+	}
+
+	// Start transaction
+	tx := conf.DB.Begin()
+	if tx.Error != nil {
+		log.Println("Error while starting bug update transaction:", tx.Error)
+		ec.BadRequestWithMessageAndNoData("Failed to update bug")
+		return
+	}
+
+	if err := tx.Model(&bug).Updates(updateData).Error; err != nil {
+		tx.Rollback()
+		log.Println("Error while updating bug:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to update bug")
+		return
+	}
+
+	if err := tx.Create(&history).Error; err != nil {
+		tx.Rollback()
+		log.Println("Error while recording bug history:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to update bug")
+		return
+	}
+
+	if assignee, reassigned := updateData["assigned_to"].(uint); reassigned {
+		if err := utils.WatchBug(tx, assignee, bug, utils.WatchReasonAssigned); err != nil {
+			tx.Rollback()
+			log.Println("Error while subscribing the assignee:", err)
+			ec.BadRequestWithMessageAndNoData("Failed to update bug")
+			return
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		log.Println("Error while committing transaction:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to update bug")
+		return
+	}
+
+	publishBugChanges(bug, project.Key, user.ID, history)
+
+	ec.SuccessWithMessage("Bug updated successfully", buildBugResponse(bug, project.Key))
+}
+
+func GetBugHistory(c *gin.Context) {
+	var params types.PaginationQueryParams
+	ec := conf.EnhancedContext{Context: c}
+	bug := utils.ExtractBugFromContext(c)
+
+	if err := c.ShouldBindQuery(&params); err != nil {
+		ec.ValidationError(err.Error())
+		return
+	}
+
+	query := conf.DB.Model(&models.BugHistory{}).Where("bug_id = ?", bug.ID)
+
+	var totalCount int64
+	if err := query.Count(&totalCount).Error; err != nil {
+		log.Println("Error while counting bug history:", err)
+		ec.BadRequestWithNoMessageAndNoData()
+		return
+	}
+
+	offset := (params.Page - 1) * params.Limit
+	query = query.Preload("User", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).Order("created_at DESC").Limit(params.Limit).Offset(offset)
+
+	var history []models.BugHistory
+	if err := query.Find(&history).Error; err != nil {
+		log.Println("Error while retrieving bug history:", err)
+		ec.BadRequestWithNoMessageAndNoData()
+		return
+	}
+
+	data := make([]types.BugHistoryResponse, 0)
+	for _, entry := range history {
+		data = append(data, types.BugHistoryResponse{
+			ID:       entry.ID,
+			Field:    entry.Field,
+			OldValue: entry.OldValue,
+			NewValue: entry.NewValue,
+			ChangedBy: types.AssignedTo{
+				ID:    entry.User.ID,
+				Name:  entry.User.Name,
+				Email: entry.User.Email,
+			},
+			CreatedAt: entry.CreatedAt,
+		})
+	}
+
+	paginatedResponse := utils.Paginate(data, params.Page, params.Limit, int(totalCount))
+	c.JSON(http.StatusOK, paginatedResponse)
 }
 
 func DeleteBug(c *gin.Context) {
+	ec := conf.EnhancedContext{Context: c}
+	bug := utils.ExtractBugFromContext(c)
+	user := utils.ExtractUserFromContext(c)
+
+	// Start transaction
+	tx := conf.DB.Begin()
+	if tx.Error != nil {
+		log.Println("Error while starting bug deletion transaction:", tx.Error)
+		ec.BadRequestWithMessageAndNoData("Failed to delete bug")
+		return
+	}
+
+	// Soft delete, the bug is moved to the project's trash
+	if err := tx.Delete(&bug).Error; err != nil {
+		tx.Rollback()
+		log.Println("Error while deleting bug:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to delete bug")
+		return
+	}
+
+	if err := tx.Create(&models.BugHistory{BugID: bug.ID, ChangedBy: user.ID, Field: "trash", NewValue: "deleted"}).Error; err != nil {
+		tx.Rollback()
+		log.Println("Error while recording bug history:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to delete bug")
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		log.Println("Error while committing transaction:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to delete bug")
+		return
+	}
+
+	publishBugEvent(events.BugDeleted, bug, utils.ExtractProjectFromContext(c).Key, user.ID)
+
+	ec.SuccessWithMessageAndNoData("Bug moved to trash")
+}
+
+func GetTrashedBugs(c *gin.Context) {
+	var params types.PaginationQueryParams
+	ec := conf.EnhancedContext{Context: c}
+	project := utils.ExtractProjectFromContext(c)
+
+	if err := c.ShouldBindQuery(&params); err != nil {
+		ec.ValidationError(err.Error())
+		return
+	}
+
+	// Bugs past the retention period are purged by the jobs, they are left out until then
+	query := conf.DB.Unscoped().Model(&models.Bug{}).
+		Where("project_id = ? AND deleted_at IS NOT NULL AND deleted_at >= ?", project.ID, time.Now().Add(-utils.BugTrashRetention()))
+
+	var totalCount int64
+	if err := query.Count(&totalCount).Error; err != nil {
+		log.Println("Error while counting trashed bugs:", err)
+		ec.BadRequestWithNoMessageAndNoData()
+		return
+	}
+
+	offset := (params.Page - 1) * params.Limit
+	query = query.Order("deleted_at DESC").Limit(params.Limit).Offset(offset)
+
+	var bugs []models.Bug
+	if err := query.Find(&bugs).Error; err != nil {
+		log.Println("Error while retrieving trashed bugs:", err)
+		ec.BadRequestWithNoMessageAndNoData()
+		return
+	}
+
+	retention := utils.BugTrashRetention()
+	data := make([]types.TrashedBugResponse, 0)
+	for _, bug := range bugs {
+		data = append(data, types.TrashedBugResponse{
+			BugResponse: buildBugResponse(bug, project.Key),
+			DeletedAt:   bug.DeletedAt.Time,
+			PurgeAt:     bug.DeletedAt.Time.Add(retention),
+		})
+	}
+
+	paginatedResponse := utils.Paginate(data, params.Page, params.Limit, int(totalCount))
+	c.JSON(http.StatusOK, paginatedResponse)
+}
+
+func RestoreBug(c *gin.Context) {
+	ec := conf.EnhancedContext{Context: c}
+	bug := utils.ExtractBugFromContext(c)
+	project := utils.ExtractProjectFromContext(c)
+	user := utils.ExtractUserFromContext(c)
+
+	// Start transaction
+	tx := conf.DB.Begin()
+	if tx.Error != nil {
+		log.Println("Error while starting bug restoration transaction:", tx.Error)
+		ec.BadRequestWithMessageAndNoData("Failed to restore bug")
+		return
+	}
+
+	if err := tx.Unscoped().Model(&bug).Update("deleted_at", nil).Error; err != nil {
+		tx.Rollback()
+		log.Println("Error while restoring bug:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to restore bug")
+		return
+	}
+
+	if err := tx.Create(&models.BugHistory{BugID: bug.ID, ChangedBy: user.ID, Field: "trash", OldValue: "deleted", NewValue: "restored"}).Error; err != nil {
+		tx.Rollback()
+		log.Println("Error while recording bug history:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to restore bug")
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		log.Println("Error while committing transaction:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to restore bug")
+		return
+	}
+
+	bug.DeletedAt = gorm.DeletedAt{}
+	publishBugEvent(events.BugRestored, bug, project.Key, user.ID)
+
+	ec.SuccessWithMessage("Bug restored successfully", buildBugResponse(bug, project.Key))
+}
+
+func PurgeBug(c *gin.Context) {
+	ec := conf.EnhancedContext{Context: c}
+	bug := utils.ExtractBugFromContext(c)
+
+	if err := conf.DB.Unscoped().Delete(&bug).Error; err != nil {
+		log.Println("Error while purging bug:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to permanently delete bug")
+		return
+	}
+
+	ec.SuccessWithMessageAndNoData("Bug permanently deleted")
+}
+
+// buildBugResponse converts a models.Bug to a types.BugResponse, looking up the assigned user.
+func buildBugResponse(bug models.Bug, projectKey string) types.BugResponse {
+	assignedToResponse := types.AssignedTo{
+		ID: bug.AssignedTo,
+	}
+
+	assignedTo, _ := utils.LookupUserUsingID(bug.AssignedTo)
+	if assignedTo != nil {
+		assignedToResponse.Name = assignedTo.Name
+		assignedToResponse.Email = assignedTo.Email
+	}
+
+	customFields := map[string]any(bug.CustomFields)
+	if customFields == nil {
+		customFields = map[string]any{}
+	}
+
+	return types.BugResponse{
+		ID:          bug.ID,
+		Key:         utils.BugKey(projectKey, bug.Number),
+		Number:      bug.Number,
+		Title:       bug.Title,
+		Description: bug.Description,
+		Tags:        bug.Tags,
+		Deadline:    bug.Deadline,
+		Status:      types.BugStatus(bug.Status),
+		Priority:    types.Priority(bug.Priority),
+		AssignedTo:  assignedToResponse,
+		ProjectID:   bug.ProjectID,
+		ParentID:    bug.ParentID,
+		MilestoneID: bug.MilestoneID,
+		CreatedAt:   bug.CreatedAt,
+		UpdatedAt:   bug.UpdatedAt,
+
+		CustomFields: customFields,
+	}
 }
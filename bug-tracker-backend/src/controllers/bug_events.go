@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/events"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/models"
+	types "github.com/WNBARookie/BugTracker/bug-tracker-backend/src/types"
+)
+
+// publishBugEvent publishes an event carrying the bug as it stands, once the change is committed.
+func publishBugEvent(eventType events.Type, bug models.Bug, projectKey string, actorID uint) {
+	events.Publish(events.Event{
+		Type:      eventType,
+		ProjectID: bug.ProjectID,
+		BugID:     bug.ID,
+		ActorID:   actorID,
+		Data:      map[string]any{"bug": buildBugResponse(bug, projectKey)},
+	})
+}
+
+// publishBugChanges publishes the events of the changes recorded in the history of the bug, once they are committed.
+func publishBugChanges(bug models.Bug, projectKey string, actorID uint, history []models.BugHistory) {
+	changes := make([]types.BugFieldChange, 0, len(history))
+	for _, entry := range history {
+		changes = append(changes, types.BugFieldChange{
+			Field:    entry.Field,
+			OldValue: entry.OldValue,
+			NewValue: entry.NewValue,
+		})
+	}
+
+	events.Publish(events.Event{
+		Type:      events.BugUpdated,
+		ProjectID: bug.ProjectID,
+		BugID:     bug.ID,
+		ActorID:   actorID,
+		Data:      map[string]any{"bug": buildBugResponse(bug, projectKey), "changes": changes},
+	})
+
+	for _, entry := range history {
+		switch entry.Field {
+		case "status":
+			events.Publish(events.Event{
+				Type:      events.BugStatusChanged,
+				ProjectID: bug.ProjectID,
+				BugID:     bug.ID,
+				ActorID:   actorID,
+				Data:      map[string]any{"old_status": entry.OldValue, "new_status": entry.NewValue},
+			})
+		case "assigned_to":
+			events.Publish(events.Event{
+				Type:      events.BugAssigned,
+				ProjectID: bug.ProjectID,
+				BugID:     bug.ID,
+				ActorID:   actorID,
+				UserIDs:   []uint{bug.AssignedTo},
+			})
+		}
+	}
+}
@@ -0,0 +1,356 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/conf"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/models"
+	types "github.com/WNBARookie/BugTracker/bug-tracker-backend/src/types"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/utils"
+)
+
+var (
+	errBugLinkExists    = errors.New("bugs are already linked")
+	errAlreadyDuplicate = errors.New("bug is already a duplicate")
+	errDuplicateCycle   = errors.New("linked bug is a duplicate of the bug")
+	errBlockingCycle    = errors.New("link would create a blocking cycle")
+)
+
+// duplicateStateKey is the closed state duplicates are moved to, if the workflow of the project has it
+const duplicateStateKey = "duplicate"
+
+func CreateBugLink(c *gin.Context) {
+	var body types.CreateBugLink
+	ec := conf.EnhancedContext{Context: c}
+	bug := utils.ExtractBugFromContext(c)
+	project := utils.ExtractProjectFromContext(c)
+	user := utils.ExtractUserFromContext(c)
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		ec.ValidationError(err.Error())
+		return
+	}
+
+	query, ok := utils.WhereBugReference(conf.DB, project, body.Target)
+	if !ok {
+		ec.BadRequestWithMessageAndNoData("Invalid linked bug")
+		return
+	}
+
+	var target models.Bug
+	if err := query.First(&target).Error; err != nil {
+		ec.BadRequestWithMessageAndNoData("Linked bug not found")
+		return
+	}
+
+	if target.ID == bug.ID {
+		ec.BadRequestWithMessageAndNoData("A bug cannot be linked to itself")
+		return
+	}
+
+	link := models.BugLink{
+		SourceID:  bug.ID,
+		TargetID:  target.ID,
+		Type:      body.Type.Value(),
+		CreatedBy: user.ID,
+	}
+
+	closedAs := ""
+	err := conf.DB.Transaction(func(tx *gorm.DB) error {
+		// Links of a project are created one at a time, so that concurrent links cannot form a cycle
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&models.Project{}, project.ID).Error; err != nil {
+			return err
+		}
+
+		if err := checkBugLink(tx, link); err != nil {
+			return err
+		}
+
+		if err := tx.Create(&link).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Create(&models.BugHistory{
+			BugID:     bug.ID,
+			ChangedBy: user.ID,
+			Field:     "link",
+			NewValue:  body.Type.Value() + " " + utils.BugKey(project.Key, target.Number),
+		}).Error; err != nil {
+			return err
+		}
+
+		if body.Type == types.BugLinkTypeDuplicates {
+			var err error
+			closedAs, err = closeDuplicate(c, tx, bug)
+			return err
+		}
+		return nil
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, errBugLinkExists):
+			ec.BadRequestWithMessageAndNoData("Bugs are already linked")
+		case errors.Is(err, errAlreadyDuplicate):
+			ec.BadRequestWithMessageAndNoData("Bug is already marked as a duplicate of another bug")
+		case errors.Is(err, errDuplicateCycle):
+			ec.BadRequestWithMessageAndNoData("Linked bug is a duplicate of this bug")
+		case errors.Is(err, errBlockingCycle):
+			c.JSON(http.StatusConflict, gin.H{"message": "Link would create a cycle of blocking bugs", "data": nil})
+		default:
+			log.Println("Error while linking bugs:", err)
+			ec.BadRequestWithMessageAndNoData("Failed to link bugs")
+		}
+		return
+	}
+
+	message := "Bugs linked successfully"
+	if closedAs != "" {
+		message = "Bugs linked successfully, the duplicate was moved to " + closedAs
+		closed := bug
+		closed.Status = closedAs
+		publishBugChanges(closed, project.Key, user.ID, []models.BugHistory{{Field: "status", OldValue: bug.Status, NewValue: closedAs}})
+	}
+
+	link.Target = target
+	c.JSON(http.StatusCreated, gin.H{
+		"message": message,
+		"data":    buildBugLinkResponse(link, bug.ID, project.Key),
+	})
+}
+
+func DeleteBugLink(c *gin.Context) {
+	var linkURI types.BugLinkURI
+	ec := conf.EnhancedContext{Context: c}
+	bug := utils.ExtractBugFromContext(c)
+	project := utils.ExtractProjectFromContext(c)
+	user := utils.ExtractUserFromContext(c)
+
+	if err := c.ShouldBindUri(&linkURI); err != nil {
+		ec.ValidationError(err.Error())
+		return
+	}
+
+	var link models.BugLink
+	if err := conf.DB.Preload("Target", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Where("source_id = ? OR target_id = ?", bug.ID, bug.ID).
+		First(&link, linkURI.LinkID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Link not found"})
+		return
+	}
+
+	err := conf.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Delete(&link).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&models.BugHistory{
+			BugID:     link.SourceID,
+			ChangedBy: user.ID,
+			Field:     "link",
+			OldValue:  link.Type + " " + utils.BugKey(project.Key, link.Target.Number),
+		}).Error
+	})
+
+	if err != nil {
+		log.Println("Error while removing bug link:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to remove link")
+		return
+	}
+
+	ec.SuccessWithMessageAndNoData("Link removed successfully")
+}
+
+// checkBugLink returns an error if the link would repeat an existing one or make the relations inconsistent.
+func checkBugLink(tx *gorm.DB, link models.BugLink) error {
+	// Relates to reads the same both ways, so the reverse link counts as the same one
+	existing := tx.Model(&models.BugLink{}).Where("source_id = ? AND target_id = ? AND type = ?", link.SourceID, link.TargetID, link.Type)
+	if link.Type == types.BugLinkTypeRelatesTo.Value() {
+		existing = existing.Or("source_id = ? AND target_id = ? AND type = ?", link.TargetID, link.SourceID, link.Type)
+	}
+
+	var count int64
+	if err := existing.Count(&count).Error; err != nil {
+		return err
+	} else if count > 0 {
+		return errBugLinkExists
+	}
+
+	switch types.BugLinkType(link.Type) {
+	case types.BugLinkTypeDuplicates:
+		// A bug is the duplicate of a single original, which must not be its own duplicate
+		if err := tx.Model(&models.BugLink{}).Where("source_id = ? AND type = ?", link.SourceID, link.Type).Count(&count).Error; err != nil {
+			return err
+		} else if count > 0 {
+			return errAlreadyDuplicate
+		}
+
+		cycle, err := isDuplicateOf(tx, link.TargetID, link.SourceID)
+		if err != nil {
+			return err
+		} else if cycle {
+			return errDuplicateCycle
+		}
+	case types.BugLinkTypeBlocks:
+		// The source cannot block the target if the target already blocks the source, directly or not
+		cycle, err := isBlockedBy(tx, link.SourceID, link.TargetID)
+		if err != nil {
+			return err
+		} else if cycle {
+			return errBlockingCycle
+		}
+	}
+
+	return nil
+}
+
+// isDuplicateOf reports whether the bug is a duplicate of the original through a chain of duplicates links.
+// Every bug has a single original, so the chain is followed one bug at a time.
+func isDuplicateOf(tx *gorm.DB, bugID, originalID uint) (bool, error) {
+	visited := map[uint]bool{bugID: true}
+
+	for {
+		var originals []uint
+		if err := tx.Model(&models.BugLink{}).
+			Where("type = ? AND source_id = ?", types.BugLinkTypeDuplicates.Value(), bugID).
+			Limit(1).
+			Pluck("target_id", &originals).Error; err != nil {
+			return false, err
+		}
+
+		if len(originals) == 0 || visited[originals[0]] {
+			return false, nil
+		}
+		if originals[0] == originalID {
+			return true, nil
+		}
+
+		bugID = originals[0]
+		visited[bugID] = true
+	}
+}
+
+// isBlockedBy reports whether the bug is blocked by the blocker through a chain of blocks links.
+func isBlockedBy(tx *gorm.DB, bugID, blockerID uint) (bool, error) {
+	visited := map[uint]bool{blockerID: true}
+	frontier := []uint{blockerID}
+
+	for len(frontier) > 0 {
+		var blocked []uint
+		if err := tx.Model(&models.BugLink{}).
+			Where("type = ? AND source_id IN ?", types.BugLinkTypeBlocks.Value(), frontier).
+			Pluck("target_id", &blocked).Error; err != nil {
+			return false, err
+		}
+
+		frontier = frontier[:0]
+		for _, id := range blocked {
+			if id == bugID {
+				return true, nil
+			}
+			if !visited[id] {
+				visited[id] = true
+				frontier = append(frontier, id)
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// closeDuplicate moves the duplicate to a closed state of the workflow that the member can move it to,
+// preferring a state named duplicate. It returns the state the bug was moved to, or an empty string
+// if the bug is already closed, the member cannot close it or its open sub-tasks prevent it.
+func closeDuplicate(c *gin.Context, tx *gorm.DB, bug models.Bug) (string, error) {
+	role := types.TeamRole(utils.ExtractUserRoleFromContext(c))
+	user := utils.ExtractUserFromContext(c)
+
+	workflow, err := utils.GetProjectWorkflow(tx, bug.ProjectID)
+	if err != nil {
+		return "", err
+	}
+
+	if workflow.IsClosed(bug.Status) || !role.HasPermission(types.PermissionBugClose) {
+		return "", nil
+	}
+
+	if allowed, err := canCloseWithChildren(tx, utils.ExtractProjectFromContext(c), workflow, bug.ID); err != nil || !allowed {
+		return "", err
+	}
+
+	closedStates := workflow.StatesInCategory(types.WorkflowCategoryClosed)
+	if i := slices.Index(closedStates, duplicateStateKey); i > 0 {
+		closedStates = append([]string{duplicateStateKey}, slices.Delete(closedStates, i, i+1)...)
+	}
+
+	for _, state := range closedStates {
+		if !workflow.CanTransition(bug.Status, state, role.Value()) {
+			continue
+		}
+
+		if err := tx.Model(&bug).Update("status", state).Error; err != nil {
+			return "", err
+		}
+
+		return state, tx.Create(&models.BugHistory{
+			BugID:     bug.ID,
+			ChangedBy: user.ID,
+			Field:     "status",
+			OldValue:  bug.Status,
+			NewValue:  state,
+		}).Error
+	}
+
+	return "", nil
+}
+
+// loadBugLinks returns the links of the bug to bugs that are not in the trash.
+func loadBugLinks(bug models.Bug, projectKey string) ([]types.BugLinkResponse, error) {
+	var links []models.BugLink
+	if err := conf.DB.Preload("Source").Preload("Target").
+		Where("source_id = ? OR target_id = ?", bug.ID, bug.ID).
+		Order("created_at ASC").
+		Find(&links).Error; err != nil {
+		return nil, err
+	}
+
+	response := make([]types.BugLinkResponse, 0, len(links))
+	for _, link := range links {
+		if link.Source.ID == 0 || link.Target.ID == 0 {
+			continue
+		}
+		response = append(response, buildBugLinkResponse(link, bug.ID, projectKey))
+	}
+
+	return response, nil
+}
+
+// buildBugLinkResponse describes the link as seen from the bug, the other bug of the link being the loaded one.
+func buildBugLinkResponse(link models.BugLink, bugID uint, projectKey string) types.BugLinkResponse {
+	direction := types.BugLinkDirectionOutward
+	other := link.Target
+	if link.SourceID != bugID {
+		direction = types.BugLinkDirectionInward
+		other = link.Source
+	}
+
+	return types.BugLinkResponse{
+		ID:        link.ID,
+		Type:      types.BugLinkType(link.Type),
+		Direction: direction,
+		Bug: types.LinkedBug{
+			ID:     other.ID,
+			Key:    utils.BugKey(projectKey, other.Number),
+			Title:  other.Title,
+			Status: types.BugStatus(other.Status),
+		},
+		CreatedAt: link.CreatedAt,
+	}
+}
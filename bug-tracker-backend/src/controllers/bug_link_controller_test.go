@@ -0,0 +1,34 @@
+package controllers
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/conf"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/models"
+	types "github.com/WNBARookie/BugTracker/bug-tracker-backend/src/types"
+)
+
+func TestCheckBugLinkRejectsDuplicateCycles(t *testing.T) {
+	setupTestDB(t, &models.BugLink{})
+
+	duplicates := func(source, target uint) models.BugLink {
+		return models.BugLink{SourceID: source, TargetID: target, Type: types.BugLinkTypeDuplicates.Value(), CreatedBy: 1}
+	}
+	for _, link := range []models.BugLink{duplicates(1, 2), duplicates(2, 3)} {
+		if err := checkBugLink(conf.DB, link); err != nil {
+			t.Fatalf("%d duplicates %d: %v", link.SourceID, link.TargetID, err)
+		}
+		conf.DB.Create(&link)
+	}
+
+	for _, link := range []models.BugLink{duplicates(3, 1), duplicates(3, 2)} {
+		if err := checkBugLink(conf.DB, link); !errors.Is(err, errDuplicateCycle) {
+			t.Errorf("%d duplicates %d: error %v, want %v", link.SourceID, link.TargetID, err, errDuplicateCycle)
+		}
+	}
+
+	if err := checkBugLink(conf.DB, duplicates(3, 4)); err != nil {
+		t.Errorf("3 duplicates 4: %v", err)
+	}
+}
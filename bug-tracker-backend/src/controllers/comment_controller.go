@@ -0,0 +1,398 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/conf"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/events"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/models"
+	types "github.com/WNBARookie/BugTracker/bug-tracker-backend/src/types"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/utils"
+)
+
+func CreateComment(c *gin.Context) {
+	var body types.CreateComment
+	ec := conf.EnhancedContext{Context: c}
+	bug := utils.ExtractBugFromContext(c)
+	user := utils.ExtractUserFromContext(c)
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		ec.ValidationError(err.Error())
+		return
+	}
+
+	// Replies can only be made to comments on the same bug that have not been deleted
+	if body.ParentID != nil {
+		var parent models.Comment
+		if err := conf.DB.Where("bug_id = ?", bug.ID).First(&parent, *body.ParentID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				ec.BadRequestWithMessageAndNoData("Parent comment not found")
+				return
+			}
+			log.Println("Error while retrieving parent comment:", err)
+			ec.BadRequestWithMessageAndNoData("Failed to create comment")
+			return
+		}
+	}
+
+	comment := models.Comment{
+		BugID:    bug.ID,
+		AuthorID: user.ID,
+		ParentID: body.ParentID,
+		Body:     body.Body,
+	}
+
+	err := conf.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&comment).Error; err != nil {
+			return err
+		}
+		return utils.WatchBug(tx, user.ID, bug, utils.WatchReasonCommented)
+	})
+
+	if err != nil {
+		log.Println("Error while creating comment:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to create comment")
+		return
+	}
+
+	// Failing to find the mentions only costs the notifications, the comment is already saved
+	mentioned, err := utils.FindMentionedMembers(conf.DB, bug.ProjectID, comment.Body)
+	if err != nil {
+		log.Println("Error while finding mentioned members:", err)
+	}
+
+	event := events.Event{
+		Type:      events.CommentCreated,
+		ProjectID: bug.ProjectID,
+		BugID:     bug.ID,
+		ActorID:   user.ID,
+		UserIDs:   mentioned,
+		Data:      map[string]any{"comment_id": comment.ID, "body": comment.Body},
+	}
+	events.Publish(event)
+	if len(mentioned) > 0 {
+		event.Type = events.CommentMention
+		events.Publish(event)
+	}
+
+	comment.Author = user
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Comment created successfully",
+		"data":    buildCommentResponse(comment),
+	})
+}
+
+// GetComments lists the comment threads of a bug, oldest first, with the replies nested in every comment.
+// Pagination applies to the top level comments, only the replies of the threads of the page are loaded.
+func GetComments(c *gin.Context) {
+	var params types.PaginationQueryParams
+	ec := conf.EnhancedContext{Context: c}
+	bug := utils.ExtractBugFromContext(c)
+
+	if err := c.ShouldBindQuery(&params); err != nil {
+		ec.ValidationError(err.Error())
+		return
+	}
+
+	// Deleted comments are only listed when their replies have to stay in the thread
+	deletedRootIDs, err := findDeletedThreadsWithReplies(conf.DB, bug.ID)
+	if err != nil {
+		log.Println("Error while retrieving deleted comments:", err)
+		ec.BadRequestWithNoMessageAndNoData()
+		return
+	}
+
+	query := conf.DB.Unscoped().Model(&models.Comment{}).
+		Where("bug_id = ? AND parent_id IS NULL", bug.ID).
+		Where("deleted_at IS NULL OR id IN ?", deletedRootIDs)
+
+	var totalCount int64
+	if err := query.Count(&totalCount).Error; err != nil {
+		log.Println("Error while counting comments:", err)
+		ec.BadRequestWithNoMessageAndNoData()
+		return
+	}
+
+	offset := (params.Page - 1) * params.Limit
+	var comments []models.Comment
+	if err := query.Preload("Author", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Order("created_at ASC, id ASC").
+		Limit(params.Limit).
+		Offset(offset).
+		Find(&comments).Error; err != nil {
+		log.Println("Error while retrieving comments:", err)
+		ec.BadRequestWithNoMessageAndNoData()
+		return
+	}
+
+	replies, err := findReplies(conf.DB, comments)
+	if err != nil {
+		log.Println("Error while retrieving comment replies:", err)
+		ec.BadRequestWithNoMessageAndNoData()
+		return
+	}
+
+	paginatedResponse := utils.Paginate(buildCommentThreads(append(comments, replies...)), params.Page, params.Limit, int(totalCount))
+	c.JSON(http.StatusOK, paginatedResponse)
+}
+
+// findDeletedThreadsWithReplies returns the deleted top level comments of the bug that have a reply still visible,
+// at any depth, walking down the threads one level at a time.
+func findDeletedThreadsWithReplies(tx *gorm.DB, bugID uint) ([]uint, error) {
+	var rootIDs []uint
+	if err := tx.Unscoped().Model(&models.Comment{}).
+		Where("bug_id = ? AND parent_id IS NULL AND deleted_at IS NOT NULL", bugID).
+		Pluck("id", &rootIDs).Error; err != nil {
+		return nil, err
+	}
+
+	threadOf := make(map[uint]uint)
+	for _, id := range rootIDs {
+		threadOf[id] = id
+	}
+
+	var visible []uint
+	found := make(map[uint]bool)
+	frontier := rootIDs
+
+	for len(frontier) > 0 {
+		var replies []models.Comment
+		if err := tx.Unscoped().Select("id", "parent_id", "deleted_at").
+			Where("parent_id IN ?", frontier).
+			Find(&replies).Error; err != nil {
+			return nil, err
+		}
+
+		frontier = nil
+		for _, reply := range replies {
+			root := threadOf[*reply.ParentID]
+			if found[root] {
+				continue
+			}
+
+			if !reply.DeletedAt.Valid {
+				found[root] = true
+				visible = append(visible, root)
+			} else {
+				threadOf[reply.ID] = root
+				frontier = append(frontier, reply.ID)
+			}
+		}
+	}
+
+	return visible, nil
+}
+
+// findReplies returns the replies to the comments at any depth, deleted ones included, oldest first on every level.
+func findReplies(tx *gorm.DB, comments []models.Comment) ([]models.Comment, error) {
+	frontier := make([]uint, 0, len(comments))
+	for _, comment := range comments {
+		frontier = append(frontier, comment.ID)
+	}
+
+	var replies []models.Comment
+	for len(frontier) > 0 {
+		var level []models.Comment
+		if err := tx.Unscoped().
+			Preload("Author", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+			Where("parent_id IN ?", frontier).
+			Order("created_at ASC, id ASC").
+			Find(&level).Error; err != nil {
+			return nil, err
+		}
+
+		frontier = frontier[:0]
+		for _, reply := range level {
+			frontier = append(frontier, reply.ID)
+		}
+		replies = append(replies, level...)
+	}
+
+	return replies, nil
+}
+
+func GetCommentByID(c *gin.Context) {
+	ec := conf.EnhancedContext{Context: c}
+	comment := utils.ExtractCommentFromContext(c)
+
+	if err := conf.DB.Unscoped().First(&comment.Author, comment.AuthorID).Error; err != nil {
+		log.Println("Error while retrieving comment author:", err)
+	}
+
+	ec.Success(buildCommentResponse(comment))
+}
+
+func UpdateComment(c *gin.Context) {
+	var body types.UpdateComment
+	ec := conf.EnhancedContext{Context: c}
+	comment := utils.ExtractCommentFromContext(c)
+	user := utils.ExtractUserFromContext(c)
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		ec.ValidationError(err.Error())
+		return
+	}
+
+	if comment.AuthorID != user.ID {
+		ec.ForbiddenWithMessageAndNoData("Only the author can edit a comment")
+		return
+	}
+
+	if body.Body != comment.Body {
+		err := conf.DB.Transaction(func(tx *gorm.DB) error {
+			// Lock the comment so that concurrent edits each keep the body they replaced
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&comment, comment.ID).Error; err != nil {
+				return err
+			}
+
+			if err := tx.Create(&models.CommentRevision{CommentID: comment.ID, Body: comment.Body}).Error; err != nil {
+				return err
+			}
+
+			now := time.Now()
+			if err := tx.Model(&comment).Updates(models.Comment{Body: body.Body, EditedAt: &now}).Error; err != nil {
+				return err
+			}
+
+			comment.Body = body.Body
+			comment.EditedAt = &now
+			return nil
+		})
+
+		if err != nil {
+			log.Println("Error while updating comment:", err)
+			ec.BadRequestWithMessageAndNoData("Failed to update comment")
+			return
+		}
+	}
+
+	comment.Author = user
+	ec.SuccessWithMessage("Comment updated successfully", buildCommentResponse(comment))
+}
+
+func DeleteComment(c *gin.Context) {
+	ec := conf.EnhancedContext{Context: c}
+	comment := utils.ExtractCommentFromContext(c)
+	user := utils.ExtractUserFromContext(c)
+
+	if comment.AuthorID != user.ID && !checkPermission(c, types.PermissionCommentDelete) {
+		return
+	}
+
+	// Soft delete, replies to the comment stay visible under a placeholder
+	if err := conf.DB.Delete(&comment).Error; err != nil {
+		log.Println("Error while deleting comment:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to delete comment")
+		return
+	}
+
+	ec.SuccessWithMessageAndNoData("Comment deleted successfully")
+}
+
+func GetCommentHistory(c *gin.Context) {
+	var params types.PaginationQueryParams
+	ec := conf.EnhancedContext{Context: c}
+	comment := utils.ExtractCommentFromContext(c)
+
+	if err := c.ShouldBindQuery(&params); err != nil {
+		ec.ValidationError(err.Error())
+		return
+	}
+
+	query := conf.DB.Model(&models.CommentRevision{}).Where("comment_id = ?", comment.ID)
+
+	var totalCount int64
+	if err := query.Count(&totalCount).Error; err != nil {
+		log.Println("Error while counting comment revisions:", err)
+		ec.BadRequestWithNoMessageAndNoData()
+		return
+	}
+
+	offset := (params.Page - 1) * params.Limit
+	query = query.Order("created_at DESC").Limit(params.Limit).Offset(offset)
+
+	var revisions []models.CommentRevision
+	if err := query.Find(&revisions).Error; err != nil {
+		log.Println("Error while retrieving comment revisions:", err)
+		ec.BadRequestWithNoMessageAndNoData()
+		return
+	}
+
+	data := make([]types.CommentRevisionResponse, 0)
+	for _, revision := range revisions {
+		data = append(data, types.CommentRevisionResponse{
+			ID:        revision.ID,
+			Body:      revision.Body,
+			BodyHTML:  utils.RenderMarkdown(revision.Body),
+			CreatedAt: revision.CreatedAt,
+		})
+	}
+
+	paginatedResponse := utils.Paginate(data, params.Page, params.Limit, int(totalCount))
+	c.JSON(http.StatusOK, paginatedResponse)
+}
+
+func buildCommentResponse(comment models.Comment) types.CommentResponse {
+	response := types.CommentResponse{
+		ID:       comment.ID,
+		BugID:    comment.BugID,
+		ParentID: comment.ParentID,
+		Author: types.AssignedTo{
+			ID:    comment.Author.ID,
+			Name:  comment.Author.Name,
+			Email: comment.Author.Email,
+		},
+		Deleted:   comment.DeletedAt.Valid,
+		EditedAt:  comment.EditedAt,
+		CreatedAt: comment.CreatedAt,
+		UpdatedAt: comment.UpdatedAt,
+	}
+
+	if !response.Deleted {
+		response.Body = comment.Body
+		response.BodyHTML = utils.RenderMarkdown(comment.Body)
+	}
+
+	return response
+}
+
+// buildCommentThreads nests the comments, given in the order they should be listed in, under their parents.
+// Deleted comments are dropped unless one of their replies is still visible.
+func buildCommentThreads(comments []models.Comment) []types.CommentResponse {
+	children := make(map[uint][]models.Comment)
+	var roots []models.Comment
+	for _, comment := range comments {
+		if comment.ParentID == nil {
+			roots = append(roots, comment)
+		} else {
+			children[*comment.ParentID] = append(children[*comment.ParentID], comment)
+		}
+	}
+
+	var build func(comment models.Comment) (types.CommentResponse, bool)
+	build = func(comment models.Comment) (types.CommentResponse, bool) {
+		response := buildCommentResponse(comment)
+		for _, child := range children[comment.ID] {
+			if reply, visible := build(child); visible {
+				response.Replies = append(response.Replies, reply)
+			}
+		}
+		return response, !response.Deleted || len(response.Replies) > 0
+	}
+
+	threads := make([]types.CommentResponse, 0)
+	for _, root := range roots {
+		if thread, visible := build(root); visible {
+			threads = append(threads, thread)
+		}
+	}
+
+	return threads
+}
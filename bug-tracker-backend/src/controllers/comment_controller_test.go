@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/conf"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/models"
+	types "github.com/WNBARookie/BugTracker/bug-tracker-backend/src/types"
+)
+
+func TestGetCommentsPaginatesThreads(t *testing.T) {
+	setupTestDB(t, &models.User{}, &models.Comment{})
+	user := createTestUser(t, "a@example.com", "password123")
+	bug := models.Bug{}
+	bug.ID = 1
+
+	created := time.Now().Add(-time.Hour)
+	comment := func(parent *models.Comment, body string, deleted bool) *models.Comment {
+		created = created.Add(time.Minute)
+		c := models.Comment{BugID: bug.ID, AuthorID: user.ID, Body: body}
+		c.CreatedAt = created
+		if parent != nil {
+			c.ParentID = &parent.ID
+		}
+		if err := conf.DB.Create(&c).Error; err != nil {
+			t.Fatal(err)
+		}
+		if deleted {
+			conf.DB.Delete(&c)
+		}
+		return &c
+	}
+
+	first := comment(nil, "first", false)
+	comment(first, "reply", false)
+	// Deleted threads are listed only while a reply, at any depth, is still visible
+	second := comment(nil, "second", true)
+	comment(comment(second, "deleted reply", true), "nested reply", false)
+	comment(comment(nil, "gone", true), "deleted reply", true)
+	comment(nil, "third", false)
+	comment(second, "other reply", false)
+
+	router := gin.New()
+	router.GET("/comments", func(c *gin.Context) { c.Set("bug", bug) }, GetComments)
+
+	get := func(path string) (threads []types.CommentResponse, total int) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: status %d, %s", path, w.Code, w.Body)
+		}
+
+		var response struct {
+			Data       []types.CommentResponse `json:"data"`
+			TotalCount int                     `json:"total_count"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return response.Data, response.TotalCount
+	}
+
+	page, total := get("/comments?page=1&limit=2")
+	if total != 3 {
+		t.Errorf("total = %d, want 3", total)
+	}
+	if len(page) != 2 || page[0].Body != "first" || len(page[0].Replies) != 1 || !page[1].Deleted {
+		t.Fatalf("first page = %+v", page)
+	}
+	if deleted := page[1].Replies; len(deleted) != 2 || len(deleted[0].Replies) != 1 || deleted[0].Replies[0].Body != "nested reply" {
+		t.Errorf("replies of the deleted thread = %+v", deleted)
+	}
+
+	page, _ = get("/comments?page=2&limit=2")
+	if len(page) != 1 || page[0].Body != "third" {
+		t.Errorf("second page = %+v", page)
+	}
+}
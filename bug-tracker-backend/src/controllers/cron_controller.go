@@ -0,0 +1,31 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/conf"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/jobs"
+)
+
+// RunJob runs a background job once, for the serverless deployments where the jobs cannot run on their own.
+func RunJob(c *gin.Context) {
+	ec := conf.EnhancedContext{Context: c}
+	key := c.Param("job")
+
+	if err := jobs.Run(key); err != nil {
+		if errors.Is(err, jobs.ErrUnknownJob) {
+			c.JSON(http.StatusNotFound, gin.H{"message": "Job not found"})
+			return
+		}
+
+		log.Printf("Error while running %s job: %v", key, err)
+		ec.BadRequestWithMessageAndNoData("Failed to run job")
+		return
+	}
+
+	ec.SuccessWithMessageAndNoData("Job completed successfully")
+}
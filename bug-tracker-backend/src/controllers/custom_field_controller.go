@@ -0,0 +1,253 @@
+package controllers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/conf"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/models"
+	types "github.com/WNBARookie/BugTracker/bug-tracker-backend/src/types"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/utils"
+)
+
+var customFieldKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+func GetCustomFields(c *gin.Context) {
+	ec := conf.EnhancedContext{Context: c}
+	project := utils.ExtractProjectFromContext(c)
+
+	fields, err := utils.GetProjectCustomFields(conf.DB, project.ID)
+	if err != nil {
+		log.Println("Error while retrieving custom fields:", err)
+		ec.BadRequestWithNoMessageAndNoData()
+		return
+	}
+
+	data := make([]types.CustomFieldResponse, 0, len(fields))
+	for _, field := range fields {
+		data = append(data, buildCustomFieldResponse(field))
+	}
+
+	ec.SuccessWithMessage("Custom fields retrieved successfully", data)
+}
+
+func CreateCustomField(c *gin.Context) {
+	var body types.CreateCustomField
+	ec := conf.EnhancedContext{Context: c}
+	project := utils.ExtractProjectFromContext(c)
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		ec.ValidationError(err.Error())
+		return
+	}
+
+	if !customFieldKeyPattern.MatchString(body.Key) {
+		ec.BadRequestWithMessageAndNoData("Custom field key must only contain lowercase letters, digits and underscores, and start with a letter")
+		return
+	}
+
+	if message := validateCustomFieldOptions(body.Type, body.Options); message != "" {
+		ec.BadRequestWithMessageAndNoData(message)
+		return
+	}
+
+	var count int64
+	if err := conf.DB.Unscoped().Model(&models.CustomField{}).Where("project_id = ? AND key = ?", project.ID, body.Key).Count(&count).Error; err != nil {
+		log.Println("Error while checking custom field key:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to create custom field")
+		return
+	} else if count > 0 {
+		ec.BadRequestWithMessageAndNoData("Custom field key is already in use")
+		return
+	}
+
+	field := models.CustomField{
+		ProjectID: project.ID,
+		Key:       body.Key,
+		Name:      body.Name,
+		Type:      body.Type.Value(),
+		Options:   body.Options,
+		Required:  body.Required,
+		Position:  body.Position,
+	}
+
+	if err := conf.DB.Create(&field).Error; err != nil {
+		log.Println("Error while creating custom field:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to create custom field")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Custom field created successfully",
+		"data":    buildCustomFieldResponse(field),
+	})
+}
+
+// UpdateCustomField changes the field. Options that bugs still have cannot be removed from select fields.
+func UpdateCustomField(c *gin.Context) {
+	var body types.UpdateCustomField
+	ec := conf.EnhancedContext{Context: c}
+	field := utils.ExtractCustomFieldFromContext(c)
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		ec.ValidationError(err.Error())
+		return
+	}
+
+	updateData := make(map[string]any)
+
+	if body.Name != nil {
+		updateData["name"] = *body.Name
+	}
+	if body.Required != nil {
+		updateData["required"] = *body.Required
+	}
+	if body.Position != nil {
+		updateData["position"] = *body.Position
+	}
+
+	if body.Options != nil {
+		if message := validateCustomFieldOptions(types.CustomFieldType(field.Type), *body.Options); message != "" {
+			ec.BadRequestWithMessageAndNoData(message)
+			return
+		}
+
+		optionsInUse := make([]string, 0)
+		for _, option := range field.Options {
+			if slices.Contains(*body.Options, option) {
+				continue
+			}
+
+			query, err := utils.WhereCustomField(conf.DB.Unscoped().Model(&models.Bug{}).Where("project_id = ?", field.ProjectID), field, option)
+			if err != nil {
+				log.Println("Error while checking custom field options:", err)
+				ec.BadRequestWithMessageAndNoData("Failed to update custom field")
+				return
+			}
+
+			var count int64
+			if err := query.Count(&count).Error; err != nil {
+				log.Println("Error while checking custom field options:", err)
+				ec.BadRequestWithMessageAndNoData("Failed to update custom field")
+				return
+			} else if count > 0 {
+				optionsInUse = append(optionsInUse, option)
+			}
+		}
+
+		if len(optionsInUse) > 0 {
+			c.JSON(http.StatusConflict, gin.H{
+				"message": "Bugs still have options removed from the field, change their value first",
+				"data":    types.CustomFieldOptionsInUseResponse{Options: optionsInUse},
+			})
+			return
+		}
+		updateData["options"] = pq.StringArray(*body.Options)
+	}
+
+	if len(updateData) == 0 {
+		ec.SuccessWithMessage("No changes to update", buildCustomFieldResponse(field))
+		return
+	}
+
+	if err := conf.DB.Model(&field).Updates(updateData).Error; err != nil {
+		log.Println("Error while updating custom field:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to update custom field")
+		return
+	}
+
+	ec.SuccessWithMessage("Custom field updated successfully", buildCustomFieldResponse(field))
+}
+
+// DeleteCustomField removes the field along with its value on every bug of the project.
+func DeleteCustomField(c *gin.Context) {
+	ec := conf.EnhancedContext{Context: c}
+	field := utils.ExtractCustomFieldFromContext(c)
+
+	err := conf.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&models.Bug{}).
+			Where("project_id = ?", field.ProjectID).
+			Update("custom_fields", gorm.Expr("custom_fields - ?", field.Key)).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&field).Error
+	})
+
+	if err != nil {
+		log.Println("Error while deleting custom field:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to delete custom field")
+		return
+	}
+
+	ec.SuccessWithMessageAndNoData("Custom field deleted successfully")
+}
+
+// validateCustomFieldOptions returns a message describing what is wrong with the options of a field of the type, if anything.
+func validateCustomFieldOptions(fieldType types.CustomFieldType, options []string) string {
+	if !fieldType.HasOptions() {
+		if len(options) > 0 {
+			return "Only select and multi_select fields have options"
+		}
+		return ""
+	}
+
+	if len(options) == 0 {
+		return "Select fields need at least one option"
+	}
+
+	for i, option := range options {
+		if slices.Contains(options[:i], option) {
+			return "Option \"" + option + "\" is listed more than once"
+		}
+	}
+
+	return ""
+}
+
+// customFieldHistoryValue formats the value of a custom field for the history of a bug.
+func customFieldHistoryValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []string:
+		return strings.Join(v, ",")
+	case []any:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			parts = append(parts, customFieldHistoryValue(item))
+		}
+		return strings.Join(parts, ",")
+	}
+
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+func buildCustomFieldResponse(field models.CustomField) types.CustomFieldResponse {
+	options := []string(field.Options)
+	if options == nil {
+		options = []string{}
+	}
+
+	return types.CustomFieldResponse{
+		ID:        field.ID,
+		Key:       field.Key,
+		Name:      field.Name,
+		Type:      types.CustomFieldType(field.Type),
+		Options:   options,
+		Required:  field.Required,
+		Position:  field.Position,
+		CreatedAt: field.CreatedAt,
+		UpdatedAt: field.UpdatedAt,
+	}
+}
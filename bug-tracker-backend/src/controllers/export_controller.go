@@ -0,0 +1,281 @@
+package controllers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/conf"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/models"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/notifications"
+	types "github.com/WNBARookie/BugTracker/bug-tracker-backend/src/types"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/utils"
+)
+
+// ExportUserData sends a zip archive with a JSON file for every kind of data tied to the user
+func ExportUserData(c *gin.Context) {
+	ec := conf.EnhancedContext{Context: c}
+	user := utils.ExtractUserFromContext(c)
+
+	files, err := collectUserData(user)
+	if err != nil {
+		log.Println("Error while collecting user data:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to export user data")
+		return
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	exportedAt := time.Now()
+
+	for _, file := range files {
+		content, err := json.MarshalIndent(file.data, "", "  ")
+		if err != nil {
+			log.Println("Error while encoding user data:", err)
+			ec.BadRequestWithMessageAndNoData("Failed to export user data")
+			return
+		}
+
+		w, err := archive.CreateHeader(&zip.FileHeader{Name: file.name, Method: zip.Deflate, Modified: exportedAt})
+		if err == nil {
+			_, err = w.Write(content)
+		}
+		if err != nil {
+			log.Println("Error while writing user data archive:", err)
+			ec.BadRequestWithMessageAndNoData("Failed to export user data")
+			return
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		log.Println("Error while writing user data archive:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to export user data")
+		return
+	}
+
+	filename := fmt.Sprintf("user-%d-export-%s.zip", user.ID, exportedAt.Format("20060102"))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "application/zip", buf.Bytes())
+}
+
+type exportFile struct {
+	name string
+	data any
+}
+
+func collectUserData(user models.User) ([]exportFile, error) {
+	profile := types.ExportedProfile{
+		ID:              user.ID,
+		Name:            user.Name,
+		Username:        user.Username,
+		Email:           user.Email,
+		EmailVerifiedAt: user.EmailVerifiedAt,
+		TOTPEnabledAt:   user.TOTPEnabledAt,
+		CreatedAt:       user.CreatedAt,
+		UpdatedAt:       user.UpdatedAt,
+	}
+
+	var memberships []models.Team
+	if err := conf.DB.Joins("Project").Where("teams.user_id = ?", user.ID).Order("teams.created_at").Find(&memberships).Error; err != nil {
+		return nil, err
+	}
+
+	teams := make([]types.ExportedTeamMembership, 0, len(memberships))
+	for _, member := range memberships {
+		teams = append(teams, types.ExportedTeamMembership{
+			ProjectID:    member.ProjectID,
+			ProjectTitle: member.Project.Title,
+			Role:         types.TeamRole(member.Role),
+			CreatedAt:    member.CreatedAt,
+			UpdatedAt:    member.UpdatedAt,
+		})
+	}
+
+	var createdProjects []models.Project
+	if err := conf.DB.Where("created_by = ?", user.ID).Order("created_at").Find(&createdProjects).Error; err != nil {
+		return nil, err
+	}
+
+	projects := make([]types.ProjectResponse, 0, len(createdProjects))
+	for _, project := range createdProjects {
+		projects = append(projects, types.ProjectResponse{
+			ID:          int(project.ID),
+			Title:       project.Title,
+			Key:         project.Key,
+			Description: project.Description,
+			CreatedBy:   int(project.CreatedBy),
+			CreatedAt:   project.CreatedAt,
+			UpdatedAt:   project.UpdatedAt,
+		})
+	}
+
+	// Bugs in the trash still belong to the user until they are purged
+	var assignedBugs []models.Bug
+	if err := conf.DB.Unscoped().Preload("Project", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Where("assigned_to = ?", user.ID).Order("created_at").Find(&assignedBugs).Error; err != nil {
+		return nil, err
+	}
+
+	bugs := make([]types.ExportedBug, 0, len(assignedBugs))
+	for _, bug := range assignedBugs {
+		exported := types.ExportedBug{
+			BugResponse: types.BugResponse{
+				ID:          bug.ID,
+				Key:         utils.BugKey(bug.Project.Key, bug.Number),
+				Number:      bug.Number,
+				Title:       bug.Title,
+				Description: bug.Description,
+				Tags:        bug.Tags,
+				Deadline:    bug.Deadline,
+				Status:      types.BugStatus(bug.Status),
+				Priority:    types.Priority(bug.Priority),
+				AssignedTo:  types.AssignedTo{ID: user.ID, Name: user.Name, Email: user.Email},
+				ProjectID:   bug.ProjectID,
+				CreatedAt:   bug.CreatedAt,
+				UpdatedAt:   bug.UpdatedAt,
+			},
+		}
+		if bug.DeletedAt.Valid {
+			deletedAt := bug.DeletedAt.Time
+			exported.DeletedAt = &deletedAt
+		}
+		bugs = append(bugs, exported)
+	}
+
+	comments, err := collectUserComments(user)
+	if err != nil {
+		return nil, err
+	}
+
+	var uploadedAttachments []models.Attachment
+	if err := conf.DB.Preload("Blob").Where("uploaded_by = ?", user.ID).Order("created_at").Find(&uploadedAttachments).Error; err != nil {
+		return nil, err
+	}
+
+	attachments := make([]types.AttachmentResponse, 0, len(uploadedAttachments))
+	for _, attachment := range uploadedAttachments {
+		attachment.Uploader = user
+		attachments = append(attachments, buildAttachmentResponse(attachment))
+	}
+
+	var userWatches []models.Watch
+	if err := conf.DB.Preload("Project", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Preload("Bug", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Where("user_id = ?", user.ID).Order("created_at").Find(&userWatches).Error; err != nil {
+		return nil, err
+	}
+
+	watches := make([]types.WatchResponse, 0, len(userWatches))
+	for _, watch := range userWatches {
+		watches = append(watches, buildWatchResponse(watch))
+	}
+
+	var userNotifications []models.Notification
+	if err := conf.DB.Preload("Project", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Preload("Bug", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Preload("Actor", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Where("user_id = ?", user.ID).Order("created_at").Find(&userNotifications).Error; err != nil {
+		return nil, err
+	}
+
+	inAppNotifications := make([]types.NotificationResponse, 0, len(userNotifications))
+	for _, notification := range userNotifications {
+		inAppNotifications = append(inAppNotifications, buildNotificationResponse(notification))
+	}
+
+	preferences, err := loadNotificationPreferences(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	emailSetting := models.EmailNotificationSetting{UserID: user.ID, Frequency: notifications.DefaultEmailFrequency.Value()}
+	if err := conf.DB.Where("user_id = ?", user.ID).FirstOrInit(&emailSetting).Error; err != nil {
+		return nil, err
+	}
+
+	// Only the descriptions of the tokens, their secrets are not stored
+	var userAccessTokens []models.PersonalAccessToken
+	if err := conf.DB.Where("user_id = ?", user.ID).Order("created_at").Find(&userAccessTokens).Error; err != nil {
+		return nil, err
+	}
+
+	accessTokens := make([]types.PersonalAccessTokenResponse, 0, len(userAccessTokens))
+	for _, accessToken := range userAccessTokens {
+		accessTokens = append(accessTokens, buildPersonalAccessTokenResponse(accessToken))
+	}
+
+	return []exportFile{
+		{name: "profile.json", data: profile},
+		{name: "teams.json", data: teams},
+		{name: "projects.json", data: projects},
+		{name: "bugs.json", data: bugs},
+		{name: "comments.json", data: comments},
+		{name: "attachments.json", data: attachments},
+		{name: "watches.json", data: watches},
+		{name: "notifications.json", data: inAppNotifications},
+		{name: "notification_settings.json", data: types.ExportedNotificationSettings{
+			Preferences: preferences,
+			Email:       buildEmailNotificationSettingsResponse(user, emailSetting),
+		}},
+		{name: "access_tokens.json", data: accessTokens},
+	}, nil
+}
+
+// collectUserComments returns the comments written by the user with the bodies they had before every edit.
+// Deleted comments are still stored, so they are exported too.
+func collectUserComments(user models.User) ([]types.ExportedComment, error) {
+	var authoredComments []models.Comment
+	if err := conf.DB.Unscoped().Where("author_id = ?", user.ID).Order("created_at").Find(&authoredComments).Error; err != nil {
+		return nil, err
+	}
+
+	commentIDs := make([]uint, 0, len(authoredComments))
+	for _, comment := range authoredComments {
+		commentIDs = append(commentIDs, comment.ID)
+	}
+
+	var revisions []models.CommentRevision
+	if err := conf.DB.Where("comment_id IN ?", commentIDs).Order("created_at, id").Find(&revisions).Error; err != nil {
+		return nil, err
+	}
+
+	revisionsOf := make(map[uint][]types.ExportedCommentRevision)
+	for _, revision := range revisions {
+		revisionsOf[revision.CommentID] = append(revisionsOf[revision.CommentID], types.ExportedCommentRevision{
+			Body:      revision.Body,
+			CreatedAt: revision.CreatedAt,
+		})
+	}
+
+	comments := make([]types.ExportedComment, 0, len(authoredComments))
+	for _, comment := range authoredComments {
+		exported := types.ExportedComment{
+			ID:        comment.ID,
+			BugID:     comment.BugID,
+			ParentID:  comment.ParentID,
+			Body:      comment.Body,
+			EditedAt:  comment.EditedAt,
+			CreatedAt: comment.CreatedAt,
+			UpdatedAt: comment.UpdatedAt,
+			Revisions: revisionsOf[comment.ID],
+		}
+		if exported.Revisions == nil {
+			exported.Revisions = make([]types.ExportedCommentRevision, 0)
+		}
+		if comment.DeletedAt.Valid {
+			deletedAt := comment.DeletedAt.Time
+			exported.DeletedAt = &deletedAt
+		}
+		comments = append(comments, exported)
+	}
+
+	return comments, nil
+}
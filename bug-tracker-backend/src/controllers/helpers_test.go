@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/conf"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/mailer"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/models"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/throttle"
+)
+
+// setupTestDB points the system at an in-memory SQLite database with the tables of the given models, an in-memory
+// mailer and an in-memory login attempt store, and returns the mailer.
+func setupTestDB(t *testing.T, tables ...any) *mailer.MemoryMailer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	t.Setenv("JWT_SECRET", "test-secret")
+	t.Setenv("JWT_EXPIRES_IN", "60")
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger:                                   logger.Default.LogMode(logger.Silent),
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every connection to :memory: opens a database of its own
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	// AutoMigrate would also create the tables the models reference, which may use types SQLite does not have
+	if err := db.Migrator().CreateTable(tables...); err != nil {
+		t.Fatal(err)
+	}
+
+	previousDB := conf.DB
+	conf.DB = db
+	t.Cleanup(func() { conf.DB = previousDB })
+
+	mail := mailer.NewMemoryMailer()
+	mailer.SetDefault(mail)
+	throttle.SetDefault(&throttle.Limiter{Store: throttle.NewMemoryStore(throttle.AccountPolicy.ResetAfter)})
+
+	return mail
+}
+
+// createTestUser stores a user with the password.
+func createTestUser(t *testing.T, email, password string) models.User {
+	t.Helper()
+
+	// The minimum cost keeps the tests fast
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := models.User{Name: "Test User", Username: email, Email: email, Password: string(hashedPassword)}
+	if err := conf.DB.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+// asUser authenticates the requests of a test router as the user, in place of RequireAuth.
+func asUser(user models.User) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("user", user)
+		c.Next()
+	}
+}
+
+// performJSON sends the body as JSON to the router and returns the recorded response.
+func performJSON(router http.Handler, method, path string, body any) *httptest.ResponseRecorder {
+	payload, _ := json.Marshal(body)
+	req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+var emailTokenPattern = regexp.MustCompile(`token=([^\s"&<]+)`)
+
+// emailedToken returns the token of the link in the last email sent.
+func emailedToken(t *testing.T, mail *mailer.MemoryMailer) string {
+	t.Helper()
+
+	messages := mail.Messages()
+	if len(messages) == 0 {
+		t.Fatal("no email was sent")
+	}
+
+	match := emailTokenPattern.FindStringSubmatch(messages[len(messages)-1].Text)
+	if match == nil {
+		t.Fatal("the email has no link with a token")
+	}
+	token, err := url.QueryUnescape(match[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
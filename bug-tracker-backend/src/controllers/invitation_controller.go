@@ -0,0 +1,385 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/conf"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/events"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/mailer"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/models"
+	types "github.com/WNBARookie/BugTracker/bug-tracker-backend/src/types"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/utils"
+)
+
+const defaultInvitationExpiresIn = 72 // in hours
+
+var (
+	errInvalidInvitation  = errors.New("invitation is invalid or has already been used")
+	errExpiredInvitation  = errors.New("invitation has expired")
+	errInvitationMismatch = errors.New("invitation was sent to a different email address")
+)
+
+func invitationExpiresIn() time.Duration {
+	hours, err := strconv.Atoi(os.Getenv("INVITATION_EXPIRES_IN"))
+	if err != nil || hours <= 0 {
+		hours = defaultInvitationExpiresIn
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// createInvitation replaces any pending invitation of the email to the project with a new one, and emails the invited
+// address the links to accept or decline it. The invitation stands even if the email cannot be sent, the token is
+// also returned to the inviter who can pass it on.
+func createInvitation(project models.Project, inviter models.User, email string, role types.TeamRole) (*models.Invitation, string, error) {
+	token, tokenHash, err := utils.GenerateSignedToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	invitation := models.Invitation{
+		ProjectID: project.ID,
+		Email:     strings.ToLower(email),
+		Role:      role.Value(),
+		InvitedBy: inviter.ID,
+		TokenHash: tokenHash,
+		Status:    types.InvitationStatusPending.Value(),
+		ExpiresAt: time.Now().Add(invitationExpiresIn()),
+	}
+
+	err = conf.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Invitation{}).
+			Where("project_id = ? AND email = ? AND status = ?", project.ID, invitation.Email, types.InvitationStatusPending.Value()).
+			Update("status", types.InvitationStatusRevoked.Value()).Error; err != nil {
+			return err
+		}
+		return tx.Create(&invitation).Error
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := sendInvitationEmail(invitation, project, inviter, token); err != nil {
+		log.Println("Error while sending invitation email:", err)
+	}
+
+	return &invitation, token, nil
+}
+
+// sendInvitationEmail emails the invited address the links to the pages of the frontend accepting or declining the
+// invitation, which carry its token.
+func sendInvitationEmail(invitation models.Invitation, project models.Project, inviter models.User, token string) error {
+	acceptLink := utils.AppURL("/invitations/accept?token=" + url.QueryEscape(token))
+	declineLink := utils.AppURL("/invitations/decline?token=" + url.QueryEscape(token))
+	intro := fmt.Sprintf("%s invited you to join the %s project as %s.", inviter.Name, project.Title, invitation.Role)
+	expiresIn := invitationExpiresIn()
+
+	return mailer.Send(mailer.Message{
+		To:      []string{invitation.Email},
+		Subject: fmt.Sprintf("You are invited to join %s", project.Title),
+		Text: fmt.Sprintf("Hi,\n\n%s\n\nAccept the invitation: %s\nDecline the invitation: %s\n\nThe invitation expires in %s. You need to sign in, or sign up, with this email address to respond to it.\n",
+			intro, acceptLink, declineLink, expiresIn),
+		HTML: fmt.Sprintf(`<p>Hi,</p><p>%s</p><p><a href="%s">Accept the invitation</a> or <a href="%s">decline it</a>.</p><p>The invitation expires in %s. You need to sign in, or sign up, with this email address to respond to it.</p>`,
+			html.EscapeString(intro), html.EscapeString(acceptLink), html.EscapeString(declineLink), expiresIn),
+	})
+}
+
+// respondToInvitation accepts or declines the pending invitation identified by the token on behalf of the user.
+// Accepting an invitation adds the user to the project team, the membership is returned if it was created.
+func respondToInvitation(tx *gorm.DB, token string, user models.User, accept bool) (*models.Invitation, *models.Team, error) {
+	tokenHash, ok := utils.VerifySignedToken(token)
+	if !ok {
+		return nil, nil, errInvalidInvitation
+	}
+
+	var invitation models.Invitation
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("token_hash = ? AND status = ?", tokenHash, types.InvitationStatusPending.Value()).
+		First(&invitation).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, errInvalidInvitation
+		}
+		return nil, nil, err
+	}
+
+	if time.Now().After(invitation.ExpiresAt) {
+		return nil, nil, errExpiredInvitation
+	}
+
+	if !strings.EqualFold(invitation.Email, user.Email) {
+		return nil, nil, errInvitationMismatch
+	}
+
+	var member *models.Team
+	status := types.InvitationStatusDeclined
+	if accept {
+		status = types.InvitationStatusAccepted
+
+		var memberCount int64
+		if err := tx.Model(&models.Team{}).Where("project_id = ? AND user_id = ?", invitation.ProjectID, user.ID).Count(&memberCount).Error; err != nil {
+			return nil, nil, err
+		}
+		if memberCount == 0 {
+			member = &models.Team{
+				ProjectID: invitation.ProjectID,
+				UserID:    user.ID,
+				Role:      invitation.Role,
+			}
+			if err := tx.Create(member).Error; err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	now := time.Now()
+	if err := tx.Model(&invitation).Updates(map[string]any{
+		"status":       status.Value(),
+		"responded_at": now,
+	}).Error; err != nil {
+		return nil, nil, err
+	}
+
+	return &invitation, member, nil
+}
+
+func handleInvitationError(ec conf.EnhancedContext, err error, message string) {
+	switch {
+	case errors.Is(err, errInvalidInvitation):
+		ec.BadRequestWithMessageAndNoData("Invitation is invalid or has already been used")
+	case errors.Is(err, errExpiredInvitation):
+		ec.BadRequestWithMessageAndNoData("Invitation has expired")
+	case errors.Is(err, errInvitationMismatch):
+		ec.ForbiddenWithMessageAndNoData("Invitation was sent to a different email address")
+	default:
+		log.Println("Error while responding to invitation:", err)
+		ec.BadRequestWithMessageAndNoData(message)
+	}
+}
+
+func InviteToTeam(c *gin.Context) {
+	var invite types.InviteTeamMember
+	ec := conf.EnhancedContext{Context: c}
+	project := utils.ExtractProjectFromContext(c)
+	user := utils.ExtractUserFromContext(c)
+
+	if err := c.ShouldBindJSON(&invite); err != nil {
+		ec.ValidationError(err.Error())
+		return
+	}
+
+	if existingUser, _ := utils.LookupUserUsingEmail(invite.Email); existingUser != nil {
+		role, err := utils.CheckIfUserIsProjectMember(existingUser.ID, project.ID)
+		if err != nil {
+			log.Println("Error while checking project membership:", err)
+			ec.BadRequestWithMessageAndNoData("Failed to create invitation")
+			return
+		} else if role != "" {
+			ec.BadRequestWithMessageAndNoData("User is already a member of this project")
+			return
+		}
+	}
+
+	invitation, token, err := createInvitation(project, user, invite.Email, invite.Role)
+	if err != nil {
+		log.Println("Error while creating invitation:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to create invitation")
+		return
+	}
+
+	response := buildInvitationResponse(*invitation, project, user)
+	response.Token = token
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Invitation created successfully",
+		"data":    response,
+	})
+}
+
+func GetProjectInvitations(c *gin.Context) {
+	var params types.PaginationQueryParams
+	ec := conf.EnhancedContext{Context: c}
+	project := utils.ExtractProjectFromContext(c)
+
+	if err := c.ShouldBindQuery(&params); err != nil {
+		ec.ValidationError(err.Error())
+		return
+	}
+
+	query := conf.DB.Model(&models.Invitation{}).
+		Where("project_id = ? AND status = ? AND expires_at > ?", project.ID, types.InvitationStatusPending.Value(), time.Now())
+
+	var totalCount int64
+	if err := query.Count(&totalCount).Error; err != nil {
+		log.Println("Error while counting invitations:", err)
+		ec.BadRequestWithNoMessageAndNoData()
+		return
+	}
+
+	offset := (params.Page - 1) * params.Limit
+	query = query.Preload("Inviter").Order("created_at DESC").Limit(params.Limit).Offset(offset)
+
+	var invitations []models.Invitation
+	if err := query.Find(&invitations).Error; err != nil {
+		log.Println("Error while retrieving invitations:", err)
+		ec.BadRequestWithNoMessageAndNoData()
+		return
+	}
+
+	data := make([]types.InvitationResponse, 0)
+	for _, invitation := range invitations {
+		data = append(data, buildInvitationResponse(invitation, project, invitation.Inviter))
+	}
+
+	paginatedResponse := utils.Paginate(data, params.Page, params.Limit, int(totalCount))
+	c.JSON(http.StatusOK, paginatedResponse)
+}
+
+func RevokeInvitation(c *gin.Context) {
+	var uri types.InvitationURI
+	ec := conf.EnhancedContext{Context: c}
+	project := utils.ExtractProjectFromContext(c)
+
+	if err := c.ShouldBindUri(&uri); err != nil {
+		ec.BadRequestWithMessageAndNoData("Invalid invitation ID")
+		return
+	}
+
+	result := conf.DB.Model(&models.Invitation{}).
+		Where("id = ? AND project_id = ? AND status = ?", uri.InvitationID, project.ID, types.InvitationStatusPending.Value()).
+		Update("status", types.InvitationStatusRevoked.Value())
+	if result.Error != nil {
+		log.Println("Error while revoking invitation:", result.Error)
+		ec.BadRequestWithMessageAndNoData("Failed to revoke invitation")
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Pending invitation not found"})
+		return
+	}
+
+	ec.SuccessWithMessageAndNoData("Invitation revoked successfully")
+}
+
+func GetUserInvitations(c *gin.Context) {
+	var params types.PaginationQueryParams
+	ec := conf.EnhancedContext{Context: c}
+	user := utils.ExtractUserFromContext(c)
+
+	if err := c.ShouldBindQuery(&params); err != nil {
+		ec.ValidationError(err.Error())
+		return
+	}
+
+	query := conf.DB.Model(&models.Invitation{}).
+		Where("LOWER(email) = LOWER(?) AND status = ? AND expires_at > ?", user.Email, types.InvitationStatusPending.Value(), time.Now())
+
+	var totalCount int64
+	if err := query.Count(&totalCount).Error; err != nil {
+		log.Println("Error while counting invitations:", err)
+		ec.BadRequestWithNoMessageAndNoData()
+		return
+	}
+
+	offset := (params.Page - 1) * params.Limit
+	query = query.Preload("Project").Preload("Inviter").Order("created_at DESC").Limit(params.Limit).Offset(offset)
+
+	var invitations []models.Invitation
+	if err := query.Find(&invitations).Error; err != nil {
+		log.Println("Error while retrieving invitations:", err)
+		ec.BadRequestWithNoMessageAndNoData()
+		return
+	}
+
+	data := make([]types.InvitationResponse, 0)
+	for _, invitation := range invitations {
+		data = append(data, buildInvitationResponse(invitation, invitation.Project, invitation.Inviter))
+	}
+
+	paginatedResponse := utils.Paginate(data, params.Page, params.Limit, int(totalCount))
+	c.JSON(http.StatusOK, paginatedResponse)
+}
+
+func AcceptInvitation(c *gin.Context) {
+	var body types.InvitationToken
+	ec := conf.EnhancedContext{Context: c}
+	user := utils.ExtractUserFromContext(c)
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		ec.ValidationError(err.Error())
+		return
+	}
+
+	var invitation *models.Invitation
+	var member *models.Team
+	err := conf.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		invitation, member, err = respondToInvitation(tx, body.Token, user, true)
+		return err
+	})
+	if err != nil {
+		handleInvitationError(ec, err, "Failed to accept invitation")
+		return
+	}
+
+	if member != nil {
+		publishTeamEvent(events.TeamMemberAdded, *member, user, user.ID)
+	}
+
+	ec.SuccessWithMessage("Invitation accepted successfully", gin.H{
+		"project_id": invitation.ProjectID,
+		"role":       invitation.Role,
+	})
+}
+
+func DeclineInvitation(c *gin.Context) {
+	var body types.InvitationToken
+	ec := conf.EnhancedContext{Context: c}
+	user := utils.ExtractUserFromContext(c)
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		ec.ValidationError(err.Error())
+		return
+	}
+
+	err := conf.DB.Transaction(func(tx *gorm.DB) error {
+		_, _, err := respondToInvitation(tx, body.Token, user, false)
+		return err
+	})
+	if err != nil {
+		handleInvitationError(ec, err, "Failed to decline invitation")
+		return
+	}
+
+	ec.SuccessWithMessageAndNoData("Invitation declined successfully")
+}
+
+func buildInvitationResponse(invitation models.Invitation, project models.Project, inviter models.User) types.InvitationResponse {
+	return types.InvitationResponse{
+		ID:           invitation.ID,
+		ProjectID:    invitation.ProjectID,
+		ProjectTitle: project.Title,
+		Email:        invitation.Email,
+		Role:         types.TeamRole(invitation.Role),
+		Status:       types.InvitationStatus(invitation.Status),
+		InvitedBy: types.AssignedTo{
+			ID:    inviter.ID,
+			Name:  inviter.Name,
+			Email: inviter.Email,
+		},
+		ExpiresAt: invitation.ExpiresAt,
+		CreatedAt: invitation.CreatedAt,
+	}
+}
@@ -0,0 +1,311 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/conf"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/models"
+	types "github.com/WNBARookie/BugTracker/bug-tracker-backend/src/types"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/utils"
+)
+
+func CreateMilestone(c *gin.Context) {
+	var body types.CreateMilestone
+	ec := conf.EnhancedContext{Context: c}
+	project := utils.ExtractProjectFromContext(c)
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		ec.ValidationError(err.Error())
+		return
+	}
+
+	if body.EndDate.Before(body.StartDate) {
+		ec.BadRequestWithMessageAndNoData("End date cannot be before the start date")
+		return
+	}
+
+	if body.State == "" {
+		body.State = types.MilestoneStatePlanned
+	}
+
+	milestone := models.Milestone{
+		ProjectID:   project.ID,
+		Title:       body.Title,
+		Description: body.Description,
+		StartDate:   body.StartDate,
+		EndDate:     body.EndDate,
+		State:       body.State.Value(),
+	}
+
+	if err := conf.DB.Create(&milestone).Error; err != nil {
+		log.Println("Error while creating milestone:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to create milestone")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Milestone created successfully",
+		"data":    buildMilestoneResponse(milestone),
+	})
+}
+
+func GetMilestones(c *gin.Context) {
+	var params types.MilestoneListQueryParams
+	ec := conf.EnhancedContext{Context: c}
+	project := utils.ExtractProjectFromContext(c)
+
+	if err := c.ShouldBindQuery(&params); err != nil {
+		ec.ValidationError(err.Error())
+		return
+	}
+
+	query := conf.DB.Model(&models.Milestone{}).Where("project_id = ?", project.ID)
+
+	if params.State != nil {
+		query = query.Where("state = ?", *params.State)
+	}
+
+	var totalCount int64
+	if err := query.Count(&totalCount).Error; err != nil {
+		log.Println("Error while counting milestones:", err)
+		ec.BadRequestWithNoMessageAndNoData()
+		return
+	}
+
+	offset := (params.Page - 1) * params.Limit
+	query = query.Order("start_date ASC").Limit(params.Limit).Offset(offset)
+
+	var milestones []models.Milestone
+	if err := query.Find(&milestones).Error; err != nil {
+		log.Println("Error while retrieving milestones:", err)
+		ec.BadRequestWithNoMessageAndNoData()
+		return
+	}
+
+	data := make([]types.MilestoneResponse, 0, len(milestones))
+	for _, milestone := range milestones {
+		data = append(data, buildMilestoneResponse(milestone))
+	}
+
+	paginatedResponse := utils.Paginate(data, params.Page, params.Limit, int(totalCount))
+	c.JSON(http.StatusOK, paginatedResponse)
+}
+
+// GetMilestoneByID returns the milestone with the number of its bugs that are open and closed.
+func GetMilestoneByID(c *gin.Context) {
+	ec := conf.EnhancedContext{Context: c}
+	milestone := utils.ExtractMilestoneFromContext(c)
+
+	workflow, err := utils.GetProjectWorkflow(conf.DB, milestone.ProjectID)
+	if err != nil {
+		log.Println("Error while retrieving project workflow:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to retrieve milestone")
+		return
+	}
+
+	var counts types.MilestoneBugCounts
+	if err := conf.DB.Model(&models.Bug{}).
+		Select("COUNT(*) AS total, COUNT(*) FILTER (WHERE status IN ?) AS closed", workflow.StatesInCategory(types.WorkflowCategoryClosed)).
+		Where("milestone_id = ?", milestone.ID).
+		Scan(&counts).Error; err != nil {
+		log.Println("Error while counting milestone bugs:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to retrieve milestone")
+		return
+	}
+	counts.Open = counts.Total - counts.Closed
+
+	ec.SuccessWithMessage("Milestone retrieved successfully", types.MilestoneDetailResponse{
+		MilestoneResponse: buildMilestoneResponse(milestone),
+		Bugs:              counts,
+	})
+}
+
+func UpdateMilestone(c *gin.Context) {
+	var body types.UpdateMilestone
+	ec := conf.EnhancedContext{Context: c}
+	milestone := utils.ExtractMilestoneFromContext(c)
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		ec.ValidationError(err.Error())
+		return
+	}
+
+	updateData := make(map[string]any)
+
+	if body.Title != nil {
+		updateData["title"] = *body.Title
+	}
+	if body.Description != nil {
+		updateData["description"] = *body.Description
+	}
+	if body.State != nil {
+		updateData["state"] = body.State.Value()
+	}
+
+	startDate, endDate := milestone.StartDate, milestone.EndDate
+	if body.StartDate != nil {
+		startDate = *body.StartDate
+		updateData["start_date"] = startDate
+	}
+	if body.EndDate != nil {
+		endDate = *body.EndDate
+		updateData["end_date"] = endDate
+	}
+	if endDate.Before(startDate) {
+		ec.BadRequestWithMessageAndNoData("End date cannot be before the start date")
+		return
+	}
+
+	if len(updateData) == 0 {
+		ec.SuccessWithMessage("No changes to update", buildMilestoneResponse(milestone))
+		return
+	}
+
+	if err := conf.DB.Model(&milestone).Updates(updateData).Error; err != nil {
+		log.Println("Error while updating milestone:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to update milestone")
+		return
+	}
+
+	ec.SuccessWithMessage("Milestone updated successfully", buildMilestoneResponse(milestone))
+}
+
+// DeleteMilestone removes the milestone, the bugs that were planned for it are kept without a milestone.
+func DeleteMilestone(c *gin.Context) {
+	ec := conf.EnhancedContext{Context: c}
+	milestone := utils.ExtractMilestoneFromContext(c)
+
+	err := conf.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&models.Bug{}).Where("milestone_id = ?", milestone.ID).Update("milestone_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&milestone).Error
+	})
+
+	if err != nil {
+		log.Println("Error while deleting milestone:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to delete milestone")
+		return
+	}
+
+	ec.SuccessWithMessageAndNoData("Milestone deleted successfully")
+}
+
+// GetMilestoneBurndown returns the daily number of open bugs of the milestone, computed from the status history
+// of the bugs currently planned for it.
+func GetMilestoneBurndown(c *gin.Context) {
+	ec := conf.EnhancedContext{Context: c}
+	milestone := utils.ExtractMilestoneFromContext(c)
+
+	workflow, err := utils.GetProjectWorkflow(conf.DB, milestone.ProjectID)
+	if err != nil {
+		log.Println("Error while retrieving project workflow:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to compute burndown")
+		return
+	}
+
+	var bugs []models.Bug
+	if err := conf.DB.Select("id", "status", "created_at").Where("milestone_id = ?", milestone.ID).Find(&bugs).Error; err != nil {
+		log.Println("Error while retrieving milestone bugs:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to compute burndown")
+		return
+	}
+
+	bugIDs := make([]uint, 0, len(bugs))
+	for _, bug := range bugs {
+		bugIDs = append(bugIDs, bug.ID)
+	}
+
+	var history []models.BugHistory
+	if len(bugIDs) > 0 {
+		if err := conf.DB.Where("field = ? AND bug_id IN ?", "status", bugIDs).Order("created_at ASC, id ASC").Find(&history).Error; err != nil {
+			log.Println("Error while retrieving bug history:", err)
+			ec.BadRequestWithMessageAndNoData("Failed to compute burndown")
+			return
+		}
+	}
+
+	start := utils.TruncateToDay(milestone.StartDate)
+	end := utils.TruncateToDay(milestone.EndDate)
+	last := end
+	if today := utils.TruncateToDay(time.Now()); today.Before(last) {
+		last = today
+	}
+
+	// The ideal line goes from every bug of the milestone to none over the whole milestone
+	totalDays := end.Sub(start).Hours() / 24
+	total := float64(len(bugs))
+
+	points := make([]types.BurndownPoint, 0)
+	for _, day := range utils.Burndown(bugs, history, workflow, start, last) {
+		ideal := 0.0
+		if elapsed := day.Date.Sub(start).Hours() / 24; totalDays > 0 && elapsed < totalDays {
+			ideal = total * (1 - elapsed/totalDays)
+		}
+
+		points = append(points, types.BurndownPoint{
+			Date:      day.Date.Format("2006-01-02"),
+			Remaining: day.Remaining,
+			Closed:    day.Closed,
+			Ideal:     ideal,
+		})
+	}
+
+	ec.SuccessWithMessage("Burndown computed successfully", types.MilestoneBurndownResponse{
+		MilestoneID: milestone.ID,
+		StartDate:   start.Format("2006-01-02"),
+		EndDate:     end.Format("2006-01-02"),
+		Points:      points,
+	})
+}
+
+// resolveMilestone looks up a milestone of the project that bugs can be planned for, which excludes completed ones.
+// It writes the error response and returns false if there is no such milestone.
+func resolveMilestone(c *gin.Context, project models.Project, milestoneID uint) (models.Milestone, bool) {
+	ec := conf.EnhancedContext{Context: c}
+
+	var milestone models.Milestone
+	if err := conf.DB.Where("project_id = ?", project.ID).First(&milestone, milestoneID).Error; err != nil {
+		ec.BadRequestWithMessageAndNoData("Milestone not found")
+		return milestone, false
+	}
+
+	if milestone.State == types.MilestoneStateCompleted.Value() {
+		ec.BadRequestWithMessageAndNoData("Bugs cannot be added to a completed milestone")
+		return milestone, false
+	}
+
+	return milestone, true
+}
+
+// milestoneTitle returns the title of the milestone for the history, or an empty string if there is none.
+func milestoneTitle(db *gorm.DB, milestoneID *uint) (string, error) {
+	if milestoneID == nil {
+		return "", nil
+	}
+
+	var milestone models.Milestone
+	if err := db.Unscoped().Select("id", "title").First(&milestone, *milestoneID).Error; err != nil {
+		return "", err
+	}
+	return milestone.Title, nil
+}
+
+func buildMilestoneResponse(milestone models.Milestone) types.MilestoneResponse {
+	return types.MilestoneResponse{
+		ID:          milestone.ID,
+		ProjectID:   milestone.ProjectID,
+		Title:       milestone.Title,
+		Description: milestone.Description,
+		StartDate:   milestone.StartDate,
+		EndDate:     milestone.EndDate,
+		State:       types.MilestoneState(milestone.State),
+		CreatedAt:   milestone.CreatedAt,
+		UpdatedAt:   milestone.UpdatedAt,
+	}
+}
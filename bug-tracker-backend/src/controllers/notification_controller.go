@@ -0,0 +1,406 @@
+package controllers
+
+import (
+	"bytes"
+	"html/template"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/conf"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/events"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/models"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/notifications"
+	types "github.com/WNBARookie/BugTracker/bug-tracker-backend/src/types"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/utils"
+)
+
+func GetNotifications(c *gin.Context) {
+	var params types.NotificationListQueryParams
+	ec := conf.EnhancedContext{Context: c}
+	user := utils.ExtractUserFromContext(c)
+
+	if err := c.ShouldBindQuery(&params); err != nil {
+		ec.ValidationError(err.Error())
+		return
+	}
+
+	query := conf.DB.Model(&models.Notification{}).Where("user_id = ?", user.ID)
+
+	if params.Unread != nil {
+		if *params.Unread {
+			query = query.Where("read_at IS NULL")
+		} else {
+			query = query.Where("read_at IS NOT NULL")
+		}
+	}
+
+	var totalCount int64
+	if err := query.Count(&totalCount).Error; err != nil {
+		log.Println("Error while counting notifications:", err)
+		ec.BadRequestWithNoMessageAndNoData()
+		return
+	}
+
+	offset := (params.Page - 1) * params.Limit
+	query = query.Preload("Project", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Preload("Bug", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Preload("Actor", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Order("created_at DESC, id DESC").Limit(params.Limit).Offset(offset)
+
+	var notifications []models.Notification
+	if err := query.Find(&notifications).Error; err != nil {
+		log.Println("Error while retrieving notifications:", err)
+		ec.BadRequestWithNoMessageAndNoData()
+		return
+	}
+
+	data := make([]types.NotificationResponse, 0, len(notifications))
+	for _, notification := range notifications {
+		data = append(data, buildNotificationResponse(notification))
+	}
+
+	paginatedResponse := utils.Paginate(data, params.Page, params.Limit, int(totalCount))
+	c.JSON(http.StatusOK, paginatedResponse)
+}
+
+func GetUnreadNotificationCount(c *gin.Context) {
+	ec := conf.EnhancedContext{Context: c}
+	user := utils.ExtractUserFromContext(c)
+
+	var count int64
+	if err := conf.DB.Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", user.ID).Count(&count).Error; err != nil {
+		log.Println("Error while counting unread notifications:", err)
+		ec.BadRequestWithNoMessageAndNoData()
+		return
+	}
+
+	ec.SuccessWithMessage("Unread notifications counted successfully", types.UnreadNotificationsResponse{Unread: count})
+}
+
+func MarkNotificationRead(c *gin.Context) {
+	var notificationURI types.NotificationURI
+	ec := conf.EnhancedContext{Context: c}
+	user := utils.ExtractUserFromContext(c)
+
+	if err := c.ShouldBindUri(&notificationURI); err != nil {
+		ec.ValidationError(err.Error())
+		return
+	}
+
+	var notification models.Notification
+	if err := conf.DB.Where("user_id = ?", user.ID).First(&notification, notificationURI.NotificationID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Notification not found"})
+		return
+	}
+
+	if notification.ReadAt == nil {
+		if err := conf.DB.Model(&notification).Update("read_at", time.Now()).Error; err != nil {
+			log.Println("Error while marking notification as read:", err)
+			ec.BadRequestWithMessageAndNoData("Failed to mark notification as read")
+			return
+		}
+	}
+
+	ec.SuccessWithMessageAndNoData("Notification marked as read")
+}
+
+func MarkAllNotificationsRead(c *gin.Context) {
+	ec := conf.EnhancedContext{Context: c}
+	user := utils.ExtractUserFromContext(c)
+
+	if err := conf.DB.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", user.ID).
+		Update("read_at", time.Now()).Error; err != nil {
+		log.Println("Error while marking notifications as read:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to mark notifications as read")
+		return
+	}
+
+	ec.SuccessWithMessageAndNoData("All notifications marked as read")
+}
+
+// GetNotificationPreferences lists every type of event with whether the user receives it in the app.
+func GetNotificationPreferences(c *gin.Context) {
+	ec := conf.EnhancedContext{Context: c}
+	user := utils.ExtractUserFromContext(c)
+
+	preferences, err := loadNotificationPreferences(user.ID)
+	if err != nil {
+		log.Println("Error while retrieving notification preferences:", err)
+		ec.BadRequestWithNoMessageAndNoData()
+		return
+	}
+
+	ec.SuccessWithMessage("Notification preferences retrieved successfully", preferences)
+}
+
+// UpdateNotificationPreferences changes the preferences of the listed types of event, leaving the others as they are.
+func UpdateNotificationPreferences(c *gin.Context) {
+	var body types.UpdateNotificationPreferences
+	ec := conf.EnhancedContext{Context: c}
+	user := utils.ExtractUserFromContext(c)
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		ec.ValidationError(err.Error())
+		return
+	}
+
+	// A type of event listed twice takes the last value, since a row cannot be upserted twice in one statement
+	preferences := make([]models.NotificationPreference, 0, len(body.Preferences))
+	for _, preference := range body.Preferences {
+		i := slices.IndexFunc(preferences, func(p models.NotificationPreference) bool { return p.Event == preference.Event.Value() })
+		if i >= 0 {
+			preferences[i].InApp = *preference.InApp
+			continue
+		}
+
+		preferences = append(preferences, models.NotificationPreference{
+			UserID: user.ID,
+			Event:  preference.Event.Value(),
+			InApp:  *preference.InApp,
+		})
+	}
+
+	if err := conf.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "event"}},
+		DoUpdates: clause.AssignmentColumns([]string{"in_app", "updated_at"}),
+	}).Create(&preferences).Error; err != nil {
+		log.Println("Error while updating notification preferences:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to update notification preferences")
+		return
+	}
+
+	updated, err := loadNotificationPreferences(user.ID)
+	if err != nil {
+		log.Println("Error while retrieving notification preferences:", err)
+		ec.BadRequestWithNoMessageAndNoData()
+		return
+	}
+
+	ec.SuccessWithMessage("Notification preferences updated successfully", updated)
+}
+
+// loadNotificationPreferences returns the preferences of the user for every type of event, defaults included.
+func loadNotificationPreferences(userID uint) ([]types.NotificationPreferenceResponse, error) {
+	var stored []models.NotificationPreference
+	if err := conf.DB.Where("user_id = ?", userID).Find(&stored).Error; err != nil {
+		return nil, err
+	}
+
+	inApp := make(map[string]bool, len(stored))
+	for _, preference := range stored {
+		inApp[preference.Event] = preference.InApp
+	}
+
+	preferences := make([]types.NotificationPreferenceResponse, 0, len(events.NotificationTypes))
+	for _, eventType := range events.NotificationTypes {
+		enabled, ok := inApp[eventType.Value()]
+		preferences = append(preferences, types.NotificationPreferenceResponse{
+			Event: eventType,
+			InApp: enabled || !ok,
+		})
+	}
+
+	return preferences, nil
+}
+
+// GetEmailNotificationSettings returns how often the user is emailed about their notifications.
+func GetEmailNotificationSettings(c *gin.Context) {
+	ec := conf.EnhancedContext{Context: c}
+	user := utils.ExtractUserFromContext(c)
+
+	setting := models.EmailNotificationSetting{UserID: user.ID, Frequency: notifications.DefaultEmailFrequency.Value()}
+	if err := conf.DB.Where("user_id = ?", user.ID).FirstOrInit(&setting).Error; err != nil {
+		log.Println("Error while retrieving email notification settings:", err)
+		ec.BadRequestWithNoMessageAndNoData()
+		return
+	}
+
+	ec.SuccessWithMessage("Email notification settings retrieved successfully", buildEmailNotificationSettingsResponse(user, setting))
+}
+
+func UpdateEmailNotificationSettings(c *gin.Context) {
+	var body types.UpdateEmailNotificationSettings
+	ec := conf.EnhancedContext{Context: c}
+	user := utils.ExtractUserFromContext(c)
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		ec.ValidationError(err.Error())
+		return
+	}
+
+	setting, err := setEmailFrequency(user.ID, body.Frequency)
+	if err != nil {
+		log.Println("Error while updating email notification settings:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to update email notification settings")
+		return
+	}
+
+	ec.SuccessWithMessage("Email notification settings updated successfully", buildEmailNotificationSettingsResponse(user, setting))
+}
+
+// unsubscribePage is shown by the unsubscribe links of the notification emails. Opening the link only shows the page,
+// since mail scanners open links on their own, and the button of the page posts the unsubscribe.
+var unsubscribePage = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Bug Tracker notification emails</title>
+</head>
+<body style="font-family:sans-serif;max-width:32rem;margin:4rem auto;padding:0 1rem">
+<h1 style="font-size:1.25rem">Bug Tracker notification emails</h1>
+<p>{{.Message}}</p>
+{{if .Confirm}}<form method="post" action="?token={{.Token}}">
+<button type="submit">Unsubscribe</button>
+</form>
+{{end}}</body>
+</html>
+`))
+
+type unsubscribePageData struct {
+	Message string
+	Token   string
+	Confirm bool // Shows the button that unsubscribes
+}
+
+// GetUnsubscribePage asks the user to confirm that they want to stop receiving notification emails.
+func GetUnsubscribePage(c *gin.Context) {
+	if _, ok := utils.VerifyUnsubscribeToken(c.Query("token")); !ok {
+		renderUnsubscribePage(c, http.StatusBadRequest, unsubscribePageData{Message: "This unsubscribe link is invalid."})
+		return
+	}
+
+	renderUnsubscribePage(c, http.StatusOK, unsubscribePageData{
+		Message: "Stop receiving notification emails? You can turn them back on in your notification settings.",
+		Token:   c.Query("token"),
+		Confirm: true,
+	})
+}
+
+// UnsubscribeFromEmails turns off the notification emails of the user the token of the link was made for. It does
+// not need the user to log in, and is the target of both the unsubscribe page and the one-click unsubscribe of the
+// mail clients. Browsers get a page back, other clients the standard JSON response.
+func UnsubscribeFromEmails(c *gin.Context) {
+	var params types.UnsubscribeQueryParams
+	ec := conf.EnhancedContext{Context: c}
+	isPage := c.NegotiateFormat(gin.MIMEJSON, gin.MIMEHTML) == gin.MIMEHTML
+
+	if err := c.ShouldBindQuery(&params); err != nil {
+		ec.ValidationError(err.Error())
+		return
+	}
+
+	userID, ok := utils.VerifyUnsubscribeToken(params.Token)
+	if !ok {
+		if isPage {
+			renderUnsubscribePage(c, http.StatusBadRequest, unsubscribePageData{Message: "This unsubscribe link is invalid."})
+		} else {
+			ec.BadRequestWithMessageAndNoData("Unsubscribe link is invalid")
+		}
+		return
+	}
+
+	var user models.User
+	if err := conf.DB.Select("id").First(&user, userID).Error; err != nil {
+		if isPage {
+			renderUnsubscribePage(c, http.StatusNotFound, unsubscribePageData{Message: "This account no longer exists."})
+		} else {
+			c.JSON(http.StatusNotFound, gin.H{"message": "User not found"})
+		}
+		return
+	}
+
+	if _, err := setEmailFrequency(user.ID, types.EmailFrequencyOff); err != nil {
+		log.Println("Error while unsubscribing from notification emails:", err)
+		if isPage {
+			renderUnsubscribePage(c, http.StatusBadRequest, unsubscribePageData{Message: "Failed to unsubscribe, please try again later."})
+		} else {
+			ec.BadRequestWithMessageAndNoData("Failed to unsubscribe from notification emails")
+		}
+		return
+	}
+
+	if isPage {
+		renderUnsubscribePage(c, http.StatusOK, unsubscribePageData{
+			Message: "You will no longer receive notification emails. You can turn them back on in your notification settings.",
+		})
+		return
+	}
+	ec.SuccessWithMessageAndNoData("Unsubscribed from notification emails")
+}
+
+func renderUnsubscribePage(c *gin.Context, status int, data unsubscribePageData) {
+	var page bytes.Buffer
+	if err := unsubscribePage.Execute(&page, data); err != nil {
+		log.Println("Error while rendering unsubscribe page:", err)
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+
+	// The button must not be clicked through a page of another site
+	c.Header("X-Frame-Options", "DENY")
+	c.Data(status, "text/html; charset=utf-8", page.Bytes())
+}
+
+// setEmailFrequency changes how often the user is emailed. Switching to a digest starts it from now on, since the
+// earlier notifications were already emailed or not meant to be.
+func setEmailFrequency(userID uint, frequency types.EmailFrequency) (models.EmailNotificationSetting, error) {
+	setting := models.EmailNotificationSetting{UserID: userID}
+	if err := conf.DB.Where("user_id = ?", userID).FirstOrInit(&setting).Error; err != nil {
+		return setting, err
+	}
+
+	if setting.ID != 0 && setting.Frequency == frequency.Value() {
+		return setting, nil
+	}
+
+	now := time.Now()
+	setting.Frequency = frequency.Value()
+	setting.LastDigestAt = &now
+
+	return setting, conf.DB.Save(&setting).Error
+}
+
+func buildEmailNotificationSettingsResponse(user models.User, setting models.EmailNotificationSetting) types.EmailNotificationSettingsResponse {
+	return types.EmailNotificationSettingsResponse{
+		Frequency:       types.EmailFrequency(setting.Frequency),
+		ImmediateEvents: events.EmailTypes,
+		EmailVerified:   user.EmailVerifiedAt != nil,
+		LastDigestAt:    setting.LastDigestAt,
+	}
+}
+
+func buildNotificationResponse(notification models.Notification) types.NotificationResponse {
+	response := types.NotificationResponse{
+		ID:        notification.ID,
+		Event:     events.Type(notification.Event),
+		Title:     notification.Title,
+		Body:      notification.Body,
+		ProjectID: notification.ProjectID,
+		BugID:     notification.BugID,
+		Read:      notification.ReadAt != nil,
+		ReadAt:    notification.ReadAt,
+		CreatedAt: notification.CreatedAt,
+	}
+
+	if notification.Bug != nil {
+		response.BugKey = utils.BugKey(notification.Project.Key, notification.Bug.Number)
+	}
+
+	if notification.Actor != nil {
+		response.Actor = &types.AssignedTo{
+			ID:    notification.Actor.ID,
+			Name:  notification.Actor.Name,
+			Email: notification.Actor.Email,
+		}
+	}
+
+	return response
+}
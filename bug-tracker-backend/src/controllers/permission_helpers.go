@@ -0,0 +1,25 @@
+package controllers
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/conf"
+	types "github.com/WNBARookie/BugTracker/bug-tracker-backend/src/types"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/utils"
+)
+
+// checkPermission is the in-handler counterpart of middlewares.RequirePermission, for actions that
+// depend on the request body. It writes the forbidden response and returns false if the action is not allowed.
+func checkPermission(c *gin.Context, permission types.Permission) bool {
+	role := types.TeamRole(utils.ExtractUserRoleFromContext(c))
+	if role.HasPermission(permission) {
+		return true
+	}
+
+	ec := conf.EnhancedContext{Context: c}
+	ec.ForbiddenWithMessage(types.PermissionDeniedMessage, types.PermissionDeniedResponse{
+		Permission: permission,
+		Role:       role,
+	})
+	return false
+}
@@ -3,6 +3,7 @@ package controllers
 import (
 	"log"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 
@@ -27,6 +28,8 @@ func CreateProject(c *gin.Context) {
 		Title:       project.Title,
 		Description: project.Description,
 		CreatedBy:   user.ID,
+
+		AllowCloseWithOpenChildren: project.AllowCloseWithOpenChildren,
 	}
 
 	// Start transaction
@@ -37,6 +40,36 @@ func CreateProject(c *gin.Context) {
 		return
 	}
 
+	if project.Key != "" {
+		newProject.Key = strings.ToUpper(project.Key)
+		if !utils.IsValidProjectKey(newProject.Key) {
+			tx.Rollback()
+			ec.BadRequestWithMessageAndNoData("Project key must start with a letter")
+			return
+		}
+
+		taken, err := utils.IsProjectKeyTaken(tx, newProject.Key, 0)
+		if err != nil {
+			tx.Rollback()
+			log.Println("Error while checking project key:", err)
+			ec.BadRequestWithMessageAndNoData("Failed to create project")
+			return
+		} else if taken {
+			tx.Rollback()
+			ec.BadRequestWithMessageAndNoData("Project key is already in use")
+			return
+		}
+	} else {
+		key, err := utils.GenerateProjectKey(tx, newProject.Title)
+		if err != nil {
+			tx.Rollback()
+			log.Println("Error while generating project key:", err)
+			ec.BadRequestWithMessageAndNoData("Failed to create project")
+			return
+		}
+		newProject.Key = key
+	}
+
 	if err := tx.Create(&newProject).Error; err != nil {
 		tx.Rollback()
 		log.Println("Error while creating project:", err)
@@ -71,10 +104,13 @@ func CreateProject(c *gin.Context) {
 		"data": types.ProjectResponse{
 			ID:          int(newProject.ID),
 			Title:       newProject.Title,
+			Key:         newProject.Key,
 			Description: newProject.Description,
 			CreatedBy:   int(newProject.CreatedBy),
 			CreatedAt:   newProject.CreatedAt,
 			UpdatedAt:   newProject.UpdatedAt,
+
+			AllowCloseWithOpenChildren: newProject.AllowCloseWithOpenChildren,
 		},
 	})
 }
@@ -127,10 +163,13 @@ func GetProjectByID(c *gin.Context) {
 		types.ProjectResponse{
 			ID:          int(project.ID),
 			Title:       project.Title,
+			Key:         project.Key,
 			Description: project.Description,
 			CreatedBy:   int(project.CreatedBy),
 			CreatedAt:   project.CreatedAt,
 			UpdatedAt:   project.UpdatedAt,
+
+			AllowCloseWithOpenChildren: project.AllowCloseWithOpenChildren,
 		},
 	)
 }
@@ -153,6 +192,27 @@ func UpdateProject(c *gin.Context) {
 	if updatedProject.Description != nil {
 		updateData["description"] = *updatedProject.Description
 	}
+	if updatedProject.AllowCloseWithOpenChildren != nil {
+		updateData["allow_close_with_open_children"] = *updatedProject.AllowCloseWithOpenChildren
+	}
+	if updatedProject.Key != nil {
+		key := strings.ToUpper(*updatedProject.Key)
+		if !utils.IsValidProjectKey(key) {
+			ec.BadRequestWithMessageAndNoData("Project key must start with a letter")
+			return
+		}
+
+		taken, err := utils.IsProjectKeyTaken(conf.DB, key, project.ID)
+		if err != nil {
+			log.Println("Error while checking project key:", err)
+			ec.BadRequestWithMessageAndNoData("Failed to update project")
+			return
+		} else if taken {
+			ec.BadRequestWithMessageAndNoData("Project key is already in use")
+			return
+		}
+		updateData["key"] = key
+	}
 
 	result := conf.DB.Model(&project).Updates(updateData)
 
@@ -166,10 +226,13 @@ func UpdateProject(c *gin.Context) {
 		types.ProjectResponse{
 			ID:          int(project.ID),
 			Title:       project.Title,
+			Key:         project.Key,
 			Description: project.Description,
 			CreatedBy:   int(project.CreatedBy),
 			CreatedAt:   project.CreatedAt,
 			UpdatedAt:   project.UpdatedAt,
+
+			AllowCloseWithOpenChildren: project.AllowCloseWithOpenChildren,
 		},
 	)
 }
@@ -178,13 +241,6 @@ func DeleteProject(c *gin.Context) {
 	ec := conf.EnhancedContext{Context: c}
 	project := utils.ExtractProjectFromContext(c)
 
-	// Check if user is admin of the project
-	contextUserRole, exists := c.Get("userRole")
-	if !exists || contextUserRole != types.TeamRoleAdmin.Value() {
-		c.JSON(http.StatusForbidden, gin.H{"message": "Only project admins can delete the project"})
-		return
-	}
-
 	if err := conf.DB.Delete(&project).Error; err != nil {
 		log.Println("Error while deleting project:", err)
 		ec.BadRequestWithMessageAndNoData("Failed to delete project")
@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/conf"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/events"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/realtime"
+	types "github.com/WNBARookie/BugTracker/bug-tracker-backend/src/types"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/utils"
+)
+
+// CreateStreamTicket returns a ticket that opens the event stream of the project, for the clients that cannot send the
+// access token in a header. The stream lasts as long as the access token the ticket was requested with.
+func CreateStreamTicket(c *gin.Context) {
+	ec := conf.EnhancedContext{Context: c}
+	project := utils.ExtractProjectFromContext(c)
+	user := utils.ExtractUserFromContext(c)
+	tokenID, expiresAt := utils.ExtractAccessTokenFromContext(c)
+
+	ticket := utils.StreamTicket{
+		UserID:    user.ID,
+		ProjectID: project.ID,
+		TokenID:   tokenID,
+		ExpiresAt: time.Now().Add(utils.StreamTicketLifetime).Unix(),
+	}
+	if !expiresAt.IsZero() {
+		ticket.SessionExpiresAt = expiresAt.Unix()
+	}
+
+	token, err := utils.CreateStreamTicket(ticket)
+	if err != nil {
+		log.Println("Error while creating stream ticket:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to create stream ticket")
+		return
+	}
+
+	ec.SuccessWithMessage("Stream ticket created successfully", types.StreamTicketResponse{
+		Ticket:    token,
+		ExpiresAt: time.Unix(ticket.ExpiresAt, 0),
+	})
+}
+
+// StreamProjectEvents pushes the changes to the bugs and the team of the project as Server-Sent Events until the client
+// disconnects. Events sent while a client is disconnected are not replayed, so clients should reload the board when
+// they reconnect, with a new ticket once theirs has expired. The stream ends when the access token the ticket was
+// requested with expires or the user is removed from the team.
+func StreamProjectEvents(c *gin.Context) {
+	ec := conf.EnhancedContext{Context: c}
+	project := utils.ExtractProjectFromContext(c)
+	user := utils.ExtractUserFromContext(c)
+
+	messages, unsubscribe := realtime.Default().Subscribe(project.ID)
+	defer unsubscribe()
+
+	// Personal access tokens have no expiry in the context, their streams last until the client disconnects
+	var expired <-chan time.Time
+	if _, expiresAt := utils.ExtractAccessTokenFromContext(c); !expiresAt.IsZero() {
+		timer := time.NewTimer(time.Until(expiresAt))
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	heartbeat := time.NewTicker(realtime.HeartbeatInterval)
+	defer heartbeat.Stop()
+
+	ec.StartStream()
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Keeps proxies such as nginx from buffering the stream
+	c.Status(http.StatusOK)
+	c.SSEvent("ready", gin.H{"project_id": project.ID})
+	c.Writer.Flush()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+
+		case <-expired:
+			c.SSEvent("token_expired", gin.H{"message": "Access token has expired, reconnect with a new ticket"})
+			c.Writer.Flush()
+			return
+
+		case <-heartbeat.C:
+			c.Writer.WriteString(": heartbeat\n\n")
+			c.Writer.Flush()
+
+		case message, ok := <-messages:
+			if !ok {
+				// Dropped for falling behind, the client reconnects and reloads
+				return
+			}
+
+			c.SSEvent(message.Type.Value(), message)
+			c.Writer.Flush()
+
+			if message.Type == events.TeamMemberRemoved && slices.Contains(message.UserIDs, user.ID) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/conf"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/models"
+	types "github.com/WNBARookie/BugTracker/bug-tracker-backend/src/types"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/utils"
+)
+
+// GetBugChildren lists the sub-tasks of the bug, each with the progress of its own sub-tasks.
+func GetBugChildren(c *gin.Context) {
+	var params types.PaginationQueryParams
+	ec := conf.EnhancedContext{Context: c}
+	bug := utils.ExtractBugFromContext(c)
+	project := utils.ExtractProjectFromContext(c)
+
+	if err := c.ShouldBindQuery(&params); err != nil {
+		ec.ValidationError(err.Error())
+		return
+	}
+
+	query := conf.DB.Model(&models.Bug{}).Where("parent_id = ?", bug.ID)
+
+	var totalCount int64
+	if err := query.Count(&totalCount).Error; err != nil {
+		log.Println("Error while counting sub-tasks:", err)
+		ec.BadRequestWithNoMessageAndNoData()
+		return
+	}
+
+	offset := (params.Page - 1) * params.Limit
+	query = query.Order("number ASC").Limit(params.Limit).Offset(offset)
+
+	var children []models.Bug
+	if err := query.Find(&children).Error; err != nil {
+		log.Println("Error while retrieving sub-tasks:", err)
+		ec.BadRequestWithNoMessageAndNoData()
+		return
+	}
+
+	workflow, err := utils.GetProjectWorkflow(conf.DB, project.ID)
+	if err != nil {
+		log.Println("Error while retrieving project workflow:", err)
+		ec.BadRequestWithNoMessageAndNoData()
+		return
+	}
+
+	data := make([]types.BugResponse, 0, len(children))
+	for _, child := range children {
+		data = append(data, buildBugResponse(child, project.Key))
+	}
+
+	if err := addBugProgress(conf.DB, workflow, data); err != nil {
+		log.Println("Error while counting sub-tasks:", err)
+		ec.BadRequestWithNoMessageAndNoData()
+		return
+	}
+
+	paginatedResponse := utils.Paginate(data, params.Page, params.Limit, int(totalCount))
+	c.JSON(http.StatusOK, paginatedResponse)
+}
+
+// resolveParentBug looks up the bug a sub-task is attached to, which must be a bug of the project that is not in the trash.
+// It writes the error response and returns false if there is no such bug.
+func resolveParentBug(c *gin.Context, project models.Project, reference string) (models.Bug, bool) {
+	ec := conf.EnhancedContext{Context: c}
+
+	var parent models.Bug
+	query, ok := utils.WhereBugReference(conf.DB, project, reference)
+	if !ok {
+		ec.BadRequestWithMessageAndNoData("Invalid parent bug")
+		return parent, false
+	}
+
+	if err := query.First(&parent).Error; err != nil {
+		ec.BadRequestWithMessageAndNoData("Parent bug not found")
+		return parent, false
+	}
+
+	return parent, true
+}
+
+// checkParentChange writes the error response and returns false if making the parent the parent of the bug
+// would make the bug a sub-task of itself, directly or not.
+func checkParentChange(c *gin.Context, bug, parent models.Bug) bool {
+	ec := conf.EnhancedContext{Context: c}
+
+	if parent.ID == bug.ID {
+		ec.BadRequestWithMessageAndNoData("A bug cannot be a sub-task of itself")
+		return false
+	}
+
+	cycle, err := utils.IsDescendant(conf.DB, parent.ID, bug.ID)
+	if err != nil {
+		log.Println("Error while checking sub-tasks:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to update bug")
+		return false
+	} else if cycle {
+		ec.BadRequestWithMessageAndNoData("Parent bug is a sub-task of this bug")
+		return false
+	}
+
+	return true
+}
+
+// parentKey returns the key of the parent of the bug for the history, or an empty string if it is top level.
+func parentKey(db *gorm.DB, projectKey string, parentID *uint) (string, error) {
+	if parentID == nil {
+		return "", nil
+	}
+
+	var parent models.Bug
+	if err := db.Unscoped().Select("id", "number").First(&parent, *parentID).Error; err != nil {
+		return "", err
+	}
+	return utils.BugKey(projectKey, parent.Number), nil
+}
+
+// canCloseWithChildren reports whether the bug may be moved to a closed state, which the project
+// only allows while some of its sub-tasks are open if it has been configured to.
+func canCloseWithChildren(db *gorm.DB, project models.Project, workflow utils.Workflow, bugID uint) (bool, error) {
+	if project.AllowCloseWithOpenChildren {
+		return true, nil
+	}
+
+	open, err := utils.CountOpenChildren(db, workflow, bugID)
+	return open == 0, err
+}
+
+// addBugProgress sets the progress of the bugs of the responses that have sub-tasks.
+func addBugProgress(db *gorm.DB, workflow utils.Workflow, responses []types.BugResponse) error {
+	ids := make([]uint, 0, len(responses))
+	for _, response := range responses {
+		ids = append(ids, response.ID)
+	}
+
+	counts, err := utils.CountChildren(db, workflow, ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range responses {
+		count, ok := counts[responses[i].ID]
+		if !ok || count.Total == 0 {
+			continue
+		}
+		responses[i].Progress = &types.BugProgress{
+			Total:   count.Total,
+			Closed:  count.Closed,
+			Percent: int(count.Closed * 100 / count.Total),
+		}
+	}
+
+	return nil
+}
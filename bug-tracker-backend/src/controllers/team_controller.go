@@ -1,12 +1,313 @@
 package controllers
 
-import "github.com/gin-gonic/gin"
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/conf"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/events"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/models"
+	types "github.com/WNBARookie/BugTracker/bug-tracker-backend/src/types"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/utils"
+)
+
+var errLastAdmin = errors.New("project must have at least one admin")
+
+// rolesBySeniority lists the team roles from the least to the most senior.
+var rolesBySeniority = []types.TeamRole{types.TeamRoleTester, types.TeamRoleDeveloper, types.TeamRoleAdmin}
 
 func AddToTeam(c *gin.Context) {
+	var member types.AddTeamMember
+	ec := conf.EnhancedContext{Context: c}
+	project := utils.ExtractProjectFromContext(c)
+
+	if err := c.ShouldBindJSON(&member); err != nil {
+		ec.ValidationError(err.Error())
+		return
+	}
+
+	var user *models.User
+	if member.Email != nil {
+		user, _ = utils.LookupUserUsingEmail(*member.Email)
+	} else {
+		user, _ = utils.LookupUserUsingUsername(*member.Username)
+	}
+	if user == nil && member.Email != nil {
+		// Users that have not signed up yet are invited to the project instead
+		inviter := utils.ExtractUserFromContext(c)
+		invitation, token, err := createInvitation(project, inviter, *member.Email, member.Role)
+		if err != nil {
+			log.Println("Error while creating invitation:", err)
+			ec.BadRequestWithMessageAndNoData("Failed to add team member")
+			return
+		}
+
+		response := buildInvitationResponse(*invitation, project, inviter)
+		response.Token = token
+
+		c.JSON(http.StatusAccepted, gin.H{
+			"message": "User not found, an invitation has been created instead",
+			"data":    response,
+		})
+		return
+	} else if user == nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "User not found"})
+		return
+	}
+
+	role, err := utils.CheckIfUserIsProjectMember(user.ID, project.ID)
+	if err != nil {
+		log.Println("Error while checking project membership:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to add team member")
+		return
+	} else if role != "" {
+		ec.BadRequestWithMessageAndNoData("User is already a member of this project")
+		return
+	}
+
+	newMember := models.Team{
+		ProjectID: project.ID,
+		UserID:    user.ID,
+		Role:      member.Role.Value(),
+	}
+
+	if err := conf.DB.Create(&newMember).Error; err != nil {
+		log.Println("Error while adding team member:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to add team member")
+		return
+	}
+
+	publishTeamEvent(events.TeamMemberAdded, newMember, *user, utils.ExtractUserFromContext(c).ID)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Team member added successfully",
+		"data":    buildTeamMemberResponse(newMember, *user),
+	})
 }
 
 func GetTeamMembers(c *gin.Context) {
+	var params types.TeamListQueryParams
+	ec := conf.EnhancedContext{Context: c}
+
+	if err := c.ShouldBindQuery(&params); err != nil {
+		ec.ValidationError(err.Error())
+		return
+	}
+
+	project := utils.ExtractProjectFromContext(c)
+	query := conf.DB.Model(&models.Team{}).Joins("User").Where("teams.project_id = ?", project.ID)
+
+	if params.Search != nil && *params.Search != "" {
+		searchTerm := "%" + *params.Search + "%"
+		query = query.Where(`"User".name ILIKE ? OR "User".username ILIKE ? OR "User".email ILIKE ?`, searchTerm, searchTerm, searchTerm)
+	}
+
+	if params.Role != nil {
+		query = query.Where("teams.role = ?", *params.Role)
+	}
+
+	var totalCount int64
+	if err := query.Count(&totalCount).Error; err != nil {
+		log.Println("Error while counting team members:", err)
+		ec.BadRequestWithNoMessageAndNoData()
+		return
+	}
+
+	offset := (params.Page - 1) * params.Limit
+	query = query.Order("teams.created_at ASC").Limit(params.Limit).Offset(offset)
+
+	var members []models.Team
+	if err := query.Find(&members).Error; err != nil {
+		log.Println("Error while retrieving team members:", err)
+		ec.BadRequestWithNoMessageAndNoData()
+		return
+	}
+
+	data := make([]types.TeamMemberResponse, 0)
+	for _, member := range members {
+		data = append(data, buildTeamMemberResponse(member, member.User))
+	}
+
+	paginatedResponse := utils.Paginate(data, params.Page, params.Limit, int(totalCount))
+	c.JSON(http.StatusOK, paginatedResponse)
 }
 
 func TeamAction(c *gin.Context) {
+	var action types.TeamAction
+	ec := conf.EnhancedContext{Context: c}
+	project := utils.ExtractProjectFromContext(c)
+	user := utils.ExtractUserFromContext(c)
+
+	if err := c.ShouldBindJSON(&action); err != nil {
+		ec.ValidationError(err.Error())
+		return
+	}
+
+	if action.Action == types.TeamActionLeave {
+		action.UserID = user.ID
+	} else if !checkPermission(c, types.PermissionTeamManage) {
+		// Leaving is the only team action that does not act on other team members
+		return
+	}
+
+	var member models.Team
+	var message string
+
+	err := conf.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock the team of the project so that concurrent actions cannot remove the last admin
+		var team []models.Team
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("project_id = ?", project.ID).Find(&team).Error; err != nil {
+			return err
+		}
+
+		adminCount := 0
+		found := false
+		for _, t := range team {
+			if t.Role == types.TeamRoleAdmin.Value() {
+				adminCount++
+			}
+			if t.UserID == action.UserID {
+				member = t
+				found = true
+			}
+		}
+		if !found {
+			return gorm.ErrRecordNotFound
+		}
+
+		currentRole := types.TeamRole(member.Role)
+
+		switch action.Action {
+		case types.TeamActionPromote, types.TeamActionDemote:
+			newRole, err := resolveNewRole(action, currentRole)
+			if err != nil {
+				return err
+			}
+			if currentRole == types.TeamRoleAdmin && adminCount <= 1 {
+				return errLastAdmin
+			}
+			if err := tx.Model(&member).Update("role", newRole.Value()).Error; err != nil {
+				return err
+			}
+			message = "Team member role updated successfully"
+
+		case types.TeamActionRemove, types.TeamActionLeave:
+			if currentRole == types.TeamRoleAdmin && adminCount <= 1 {
+				return errLastAdmin
+			}
+			// Hard delete so that the user can be added to the team again later
+			if err := tx.Unscoped().Delete(&member).Error; err != nil {
+				return err
+			}
+			// Former members stop following the project and its bugs
+			if err := tx.Unscoped().Where("user_id = ? AND project_id = ?", member.UserID, member.ProjectID).Delete(&models.Watch{}).Error; err != nil {
+				return err
+			}
+			if action.Action == types.TeamActionLeave {
+				message = "Left the project successfully"
+			} else {
+				message = "Team member removed successfully"
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		var roleErr *invalidRoleChangeError
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"message": "User is not a member of this project"})
+		case errors.Is(err, errLastAdmin):
+			ec.BadRequestWithMessageAndNoData("A project must have at least one admin, promote another member first")
+		case errors.As(err, &roleErr):
+			ec.BadRequestWithMessageAndNoData(roleErr.Error())
+		default:
+			log.Println("Error while performing team action:", err)
+			ec.BadRequestWithMessageAndNoData("Failed to perform team action")
+		}
+		return
+	}
+
+	memberUser, _ := utils.LookupUserUsingID(member.UserID)
+	if memberUser == nil {
+		memberUser = &models.User{}
+	}
+
+	if action.Action == types.TeamActionRemove || action.Action == types.TeamActionLeave {
+		publishTeamEvent(events.TeamMemberRemoved, member, *memberUser, user.ID)
+		ec.SuccessWithMessageAndNoData(message)
+		return
+	}
+
+	publishTeamEvent(events.TeamMemberUpdated, member, *memberUser, user.ID)
+	ec.SuccessWithMessage(message, buildTeamMemberResponse(member, *memberUser))
+}
+
+type invalidRoleChangeError struct {
+	message string
+}
+
+func (e *invalidRoleChangeError) Error() string {
+	return e.message
+}
+
+// resolveNewRole works out the role a member is promoted or demoted to,
+// defaulting to the next role in seniority when no role is requested.
+func resolveNewRole(action types.TeamAction, currentRole types.TeamRole) (types.TeamRole, error) {
+	if action.Role != nil {
+		if action.Action == types.TeamActionPromote && action.Role.Rank() <= currentRole.Rank() {
+			return "", &invalidRoleChangeError{"Member can only be promoted to a more senior role"}
+		}
+		if action.Action == types.TeamActionDemote && action.Role.Rank() >= currentRole.Rank() {
+			return "", &invalidRoleChangeError{"Member can only be demoted to a less senior role"}
+		}
+		return *action.Role, nil
+	}
+
+	for i, role := range rolesBySeniority {
+		if role != currentRole {
+			continue
+		}
+		if action.Action == types.TeamActionPromote && i+1 < len(rolesBySeniority) {
+			return rolesBySeniority[i+1], nil
+		}
+		if action.Action == types.TeamActionDemote && i > 0 {
+			return rolesBySeniority[i-1], nil
+		}
+	}
+
+	if action.Action == types.TeamActionPromote {
+		return "", &invalidRoleChangeError{"Member already has the most senior role"}
+	}
+	return "", &invalidRoleChangeError{"Member already has the least senior role"}
+}
+
+// publishTeamEvent publishes a change to the team of the project, once it is committed.
+func publishTeamEvent(eventType events.Type, member models.Team, user models.User, actorID uint) {
+	events.Publish(events.Event{
+		Type:      eventType,
+		ProjectID: member.ProjectID,
+		ActorID:   actorID,
+		UserIDs:   []uint{member.UserID},
+		Data:      map[string]any{"member": buildTeamMemberResponse(member, user)},
+	})
+}
+
+func buildTeamMemberResponse(member models.Team, user models.User) types.TeamMemberResponse {
+	return types.TeamMemberResponse{
+		ID:        member.ID,
+		UserID:    member.UserID,
+		Name:      user.Name,
+		Username:  user.Username,
+		Email:     user.Email,
+		Role:      types.TeamRole(member.Role),
+		CreatedAt: member.CreatedAt,
+		UpdatedAt: member.UpdatedAt,
+	}
 }
@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/conf"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/models"
+	types "github.com/WNBARookie/BugTracker/bug-tracker-backend/src/types"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/utils"
+)
+
+func CreatePersonalAccessToken(c *gin.Context) {
+	var body types.CreatePersonalAccessToken
+	ec := conf.EnhancedContext{Context: c}
+	user := utils.ExtractUserFromContext(c)
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		ec.ValidationError(err.Error())
+		return
+	}
+
+	token, tokenHash, prefix, err := utils.GeneratePersonalAccessToken()
+	if err != nil {
+		log.Println("Error while generating access token:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to create access token")
+		return
+	}
+
+	scopes := make(pq.StringArray, 0, len(body.Scopes))
+	for _, scope := range body.Scopes {
+		scopes = append(scopes, scope.Value())
+	}
+
+	accessToken := models.PersonalAccessToken{
+		UserID:    user.ID,
+		Name:      body.Name,
+		Prefix:    prefix,
+		TokenHash: tokenHash,
+		Scopes:    scopes,
+	}
+	if body.ExpiresInDays != nil {
+		expiresAt := time.Now().Add(time.Duration(*body.ExpiresInDays) * 24 * time.Hour)
+		accessToken.ExpiresAt = &expiresAt
+	}
+
+	if err := conf.DB.Create(&accessToken).Error; err != nil {
+		log.Println("Error while creating access token:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to create access token")
+		return
+	}
+
+	response := buildPersonalAccessTokenResponse(accessToken)
+	response.Token = token
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Access token created successfully, copy it now as it will not be shown again",
+		"data":    response,
+	})
+}
+
+func GetPersonalAccessTokens(c *gin.Context) {
+	ec := conf.EnhancedContext{Context: c}
+	user := utils.ExtractUserFromContext(c)
+
+	var accessTokens []models.PersonalAccessToken
+	if err := conf.DB.Where("user_id = ?", user.ID).Order("created_at DESC").Find(&accessTokens).Error; err != nil {
+		log.Println("Error while retrieving access tokens:", err)
+		ec.BadRequestWithNoMessageAndNoData()
+		return
+	}
+
+	data := make([]types.PersonalAccessTokenResponse, 0)
+	for _, accessToken := range accessTokens {
+		data = append(data, buildPersonalAccessTokenResponse(accessToken))
+	}
+
+	ec.Success(data)
+}
+
+func RevokePersonalAccessToken(c *gin.Context) {
+	var uri types.PersonalAccessTokenURI
+	ec := conf.EnhancedContext{Context: c}
+	user := utils.ExtractUserFromContext(c)
+
+	if err := c.ShouldBindUri(&uri); err != nil {
+		ec.BadRequestWithMessageAndNoData("Invalid access token ID")
+		return
+	}
+
+	result := conf.DB.Where("id = ? AND user_id = ?", uri.TokenID, user.ID).Delete(&models.PersonalAccessToken{})
+	if result.Error != nil {
+		log.Println("Error while revoking access token:", result.Error)
+		ec.BadRequestWithMessageAndNoData("Failed to revoke access token")
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Access token not found"})
+		return
+	}
+
+	ec.SuccessWithMessageAndNoData("Access token revoked successfully")
+}
+
+func buildPersonalAccessTokenResponse(accessToken models.PersonalAccessToken) types.PersonalAccessTokenResponse {
+	scopes := make([]types.TokenScope, 0, len(accessToken.Scopes))
+	for _, scope := range accessToken.Scopes {
+		scopes = append(scopes, types.TokenScope(scope))
+	}
+
+	return types.PersonalAccessTokenResponse{
+		ID:         accessToken.ID,
+		Name:       accessToken.Name,
+		Prefix:     accessToken.Prefix,
+		Scopes:     scopes,
+		ExpiresAt:  accessToken.ExpiresAt,
+		LastUsedAt: accessToken.LastUsedAt,
+		CreatedAt:  accessToken.CreatedAt,
+	}
+}
@@ -0,0 +1,336 @@
+package controllers
+
+import (
+	"crypto/rand"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/conf"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/models"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/throttle"
+	types "github.com/WNBARookie/BugTracker/bug-tracker-backend/src/types"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/utils"
+)
+
+const (
+	totpIssuer         = "Bug Tracker"
+	recoveryCodeCount  = 10
+	recoveryCodeLength = 10
+)
+
+var errInvalidSecondFactor = errors.New("invalid two-factor code")
+
+// normalizeRecoveryCode makes recovery codes case and separator insensitive.
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
+
+// generateRecoveryCodes replaces the recovery codes of the user with new ones and returns them.
+func generateRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	const charset = "abcdefghjkmnpqrstuvwxyz23456789" // Without look-alike characters
+
+	if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	rows := make([]models.RecoveryCode, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, recoveryCodeLength)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		for j := range b {
+			b[j] = charset[int(b[j])%len(charset)]
+		}
+
+		code := string(b[:recoveryCodeLength/2]) + "-" + string(b[recoveryCodeLength/2:])
+		codes = append(codes, code)
+		rows = append(rows, models.RecoveryCode{
+			UserID:   userID,
+			CodeHash: utils.HashToken(normalizeRecoveryCode(code)),
+		})
+	}
+
+	if err := tx.Create(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// verifySecondFactor checks either a TOTP code or a recovery code of the user. Both can only be used once.
+func verifySecondFactor(tx *gorm.DB, user models.User, code, recoveryCode string) error {
+	if code != "" {
+		step, ok := utils.ValidateTOTP(user.TOTPSecret, code, time.Now())
+		if !ok {
+			return errInvalidSecondFactor
+		}
+
+		// The condition makes the update fail if the code, or a later one, has already been used
+		result := tx.Model(&models.User{}).
+			Where("id = ? AND totp_last_used_step < ?", user.ID, step).
+			Update("totp_last_used_step", step)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errInvalidSecondFactor
+		}
+		return nil
+	}
+
+	var storedCode models.RecoveryCode
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", user.ID, utils.HashToken(normalizeRecoveryCode(recoveryCode))).
+		First(&storedCode).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errInvalidSecondFactor
+		}
+		return err
+	}
+
+	return tx.Model(&storedCode).Update("used_at", time.Now()).Error
+}
+
+func EnrollTwoFactor(c *gin.Context) {
+	ec := conf.EnhancedContext{Context: c}
+	user := utils.ExtractUserFromContext(c)
+
+	if user.TOTPEnabledAt != nil {
+		ec.BadRequestWithMessageAndNoData("Two-factor authentication is already enabled")
+		return
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		log.Println("Error while generating TOTP secret:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to enroll two-factor authentication")
+		return
+	}
+
+	if err := conf.DB.Model(&user).Updates(map[string]any{
+		"totp_secret":         secret,
+		"totp_last_used_step": 0,
+	}).Error; err != nil {
+		log.Println("Error while saving TOTP secret:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to enroll two-factor authentication")
+		return
+	}
+
+	ec.SuccessWithMessage(
+		"Scan the provisioning URI with an authenticator app and confirm with a code",
+		types.TwoFactorEnrollResponse{
+			Secret:          secret,
+			ProvisioningURI: utils.TOTPProvisioningURI(secret, user.Email, totpIssuer),
+		},
+	)
+}
+
+func ConfirmTwoFactor(c *gin.Context) {
+	var body types.TwoFactorCode
+	ec := conf.EnhancedContext{Context: c}
+	user := utils.ExtractUserFromContext(c)
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		ec.ValidationError(err.Error())
+		return
+	}
+
+	if user.TOTPEnabledAt != nil {
+		ec.BadRequestWithMessageAndNoData("Two-factor authentication is already enabled")
+		return
+	} else if user.TOTPSecret == "" {
+		ec.BadRequestWithMessageAndNoData("Two-factor authentication enrollment has not been started")
+		return
+	}
+
+	var codes []string
+	err := conf.DB.Transaction(func(tx *gorm.DB) error {
+		if err := verifySecondFactor(tx, user, body.Code, ""); err != nil {
+			return err
+		}
+
+		if err := tx.Model(&user).Update("totp_enabled_at", time.Now()).Error; err != nil {
+			return err
+		}
+
+		var err error
+		codes, err = generateRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, errInvalidSecondFactor) {
+			ec.BadRequestWithMessageAndNoData("Invalid two-factor code")
+		} else {
+			log.Println("Error while confirming two-factor authentication:", err)
+			ec.BadRequestWithMessageAndNoData("Failed to enable two-factor authentication")
+		}
+		return
+	}
+
+	ec.SuccessWithMessage(
+		"Two-factor authentication enabled, store the recovery codes somewhere safe as they will not be shown again",
+		types.RecoveryCodesResponse{RecoveryCodes: codes},
+	)
+}
+
+func DisableTwoFactor(c *gin.Context) {
+	var body types.DisableTwoFactor
+	ec := conf.EnhancedContext{Context: c}
+	user := utils.ExtractUserFromContext(c)
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		ec.ValidationError(err.Error())
+		return
+	}
+
+	if user.TOTPEnabledAt == nil {
+		ec.BadRequestWithMessageAndNoData("Two-factor authentication is not enabled")
+		return
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(body.Password)); err != nil {
+		ec.BadRequestWithMessageAndNoData("Invalid password")
+		return
+	}
+
+	err := conf.DB.Transaction(func(tx *gorm.DB) error {
+		if err := verifySecondFactor(tx, user, body.Code, body.RecoveryCode); err != nil {
+			return err
+		}
+
+		if err := tx.Model(&user).Updates(map[string]any{
+			"totp_secret":         "",
+			"totp_enabled_at":     nil,
+			"totp_last_used_step": 0,
+		}).Error; err != nil {
+			return err
+		}
+
+		return tx.Unscoped().Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{}).Error
+	})
+	if err != nil {
+		if errors.Is(err, errInvalidSecondFactor) {
+			ec.BadRequestWithMessageAndNoData("Invalid two-factor code")
+		} else {
+			log.Println("Error while disabling two-factor authentication:", err)
+			ec.BadRequestWithMessageAndNoData("Failed to disable two-factor authentication")
+		}
+		return
+	}
+
+	ec.SuccessWithMessageAndNoData("Two-factor authentication disabled")
+}
+
+func RegenerateRecoveryCodes(c *gin.Context) {
+	var body types.TwoFactorCode
+	ec := conf.EnhancedContext{Context: c}
+	user := utils.ExtractUserFromContext(c)
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		ec.ValidationError(err.Error())
+		return
+	}
+
+	if user.TOTPEnabledAt == nil {
+		ec.BadRequestWithMessageAndNoData("Two-factor authentication is not enabled")
+		return
+	}
+
+	var codes []string
+	err := conf.DB.Transaction(func(tx *gorm.DB) error {
+		if err := verifySecondFactor(tx, user, body.Code, ""); err != nil {
+			return err
+		}
+
+		var err error
+		codes, err = generateRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, errInvalidSecondFactor) {
+			ec.BadRequestWithMessageAndNoData("Invalid two-factor code")
+		} else {
+			log.Println("Error while regenerating recovery codes:", err)
+			ec.BadRequestWithMessageAndNoData("Failed to regenerate recovery codes")
+		}
+		return
+	}
+
+	ec.SuccessWithMessage("Recovery codes regenerated", types.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+func LoginTwoFactor(c *gin.Context) {
+	var body types.LoginTwoFactor
+	ec := conf.EnhancedContext{Context: c}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		ec.ValidationError(err.Error())
+		return
+	}
+
+	userID, jti, expiresAt, err := utils.ParseChallengeToken(body.ChallengeToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid or expired challenge token, please log in again"})
+		return
+	}
+
+	user, _ := utils.LookupUserUsingID(userID)
+	if user == nil || user.TOTPEnabledAt == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid or expired challenge token, please log in again"})
+		return
+	}
+
+	// Wrong codes count towards the same lockout as wrong passwords
+	limiter := throttle.Default()
+	accountKey, ipKey := throttle.AccountKey(user.Email), throttle.IPKey(c.ClientIP())
+
+	retryAfter, err := limiter.RetryAfter(accountKey, ipKey)
+	if err != nil {
+		log.Println("Error while checking login attempts:", err)
+	} else if retryAfter > 0 {
+		respondTooManyAttempts(c, retryAfter)
+		return
+	}
+
+	err = conf.DB.Transaction(func(tx *gorm.DB) error {
+		return verifySecondFactor(tx, *user, body.Code, body.RecoveryCode)
+	})
+	if err != nil {
+		if errors.Is(err, errInvalidSecondFactor) {
+			recordFailedLogin(accountKey, ipKey)
+			c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid two-factor code"})
+		} else {
+			log.Println("Error while verifying two-factor code:", err)
+			ec.BadRequestWithMessageAndNoData("Failed to verify two-factor code")
+		}
+		return
+	}
+
+	if err := limiter.Reset(accountKey); err != nil {
+		log.Println("Error while resetting login attempts:", err)
+	}
+
+	// The challenge is spent once it has been completed
+	if err := utils.RevokeAccessToken(jti, expiresAt); err != nil {
+		log.Println("Error while revoking challenge token:", err)
+	}
+
+	tokens, err := issueTokenPair(user.ID)
+	if err != nil {
+		log.Println("Failed to issue tokens:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to generate token")
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
@@ -1,13 +1,19 @@
 package controllers
 
 import (
+	"errors"
 	"log"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/conf"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/models"
 	types "github.com/WNBARookie/BugTracker/bug-tracker-backend/src/types"
 	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/utils"
 )
@@ -20,12 +26,14 @@ func GetUserProfile(c *gin.Context) {
 		"success": true,
 		"message": "User found.",
 		"data": gin.H{
-			"id":         user.ID,
-			"name":       user.Name,
-			"email":      user.Email,
-			"username":   user.Username,
-			"created_at": user.CreatedAt,
-			"updated_at": user.UpdatedAt,
+			"id":                user.ID,
+			"name":              user.Name,
+			"email":             user.Email,
+			"email_verified_at": user.EmailVerifiedAt,
+			"totp_enabled_at":   user.TOTPEnabledAt,
+			"username":          user.Username,
+			"created_at":        user.CreatedAt,
+			"updated_at":        user.UpdatedAt,
 		},
 	}
 	c.JSON(http.StatusOK, response)
@@ -51,7 +59,17 @@ func UpdateUserProfile(c *gin.Context) {
 		updateData["username"] = *updatedUser.Username
 	}
 	if updatedUser.Password != nil {
-		updateData["password"] = *updatedUser.Password
+		if _, isAccessToken := utils.ExtractTokenScopesFromContext(c); isAccessToken {
+			ec.ForbiddenWithMessageAndNoData("Password cannot be changed with a personal access token")
+			return
+		}
+
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(*updatedUser.Password), bcrypt.DefaultCost)
+		if err != nil {
+			ec.BadRequestWithMessageAndNoData("Failed to hash password")
+			return
+		}
+		updateData["password"] = string(hashedPassword)
 	}
 
 	result := conf.DB.Model(&user).Updates(updateData)
@@ -64,22 +82,227 @@ func UpdateUserProfile(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"message": "User updated successfully",
 		"data": types.UserResponse{
-			ID:        user.ID,
-			Name:      user.Name,
-			Username:  user.Username,
-			Email:     user.Email,
-			CreatedAt: user.CreatedAt,
-			UpdatedAt: user.UpdatedAt,
+			ID:              user.ID,
+			Name:            user.Name,
+			Username:        user.Username,
+			Email:           user.Email,
+			EmailVerifiedAt: user.EmailVerifiedAt,
+			CreatedAt:       user.CreatedAt,
+			UpdatedAt:       user.UpdatedAt,
 		},
 	})
 }
 
+var errSoleAdmin = errors.New("user is the only admin of projects with other members")
+
 func DeleteUserProfile(c *gin.Context) {
+	var body types.DeleteUser
+	ec := conf.EnhancedContext{Context: c}
+	user := utils.ExtractUserFromContext(c)
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		ec.ValidationError(err.Error())
+		return
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(body.Password)); err != nil {
+		ec.BadRequestWithMessageAndNoData("Invalid password")
+		return
+	}
+
+	if user.TOTPEnabledAt != nil && body.Code == "" && body.RecoveryCode == "" {
+		ec.BadRequestWithMessageAndNoData("Two-factor code is required")
+		return
+	}
+
+	transfers := make(map[uint]uint)
+	for _, transfer := range body.Transfers {
+		transfers[transfer.ProjectID] = transfer.UserID
+	}
+
+	var blockingProjects []types.BlockingProject
+	err := conf.DB.Transaction(func(tx *gorm.DB) error {
+		if user.TOTPEnabledAt != nil {
+			if err := verifySecondFactor(tx, user, body.Code, body.RecoveryCode); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		blockingProjects, err = deleteUserAccount(tx, user, transfers)
+		return err
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, errInvalidSecondFactor):
+			ec.BadRequestWithMessageAndNoData("Invalid two-factor code")
+		case errors.Is(err, errSoleAdmin):
+			c.JSON(http.StatusConflict, gin.H{
+				"message": "You are the only admin of these projects, transfer them to another team member before deleting your account",
+				"data":    gin.H{"projects": blockingProjects},
+			})
+		default:
+			log.Println("Error while deleting user:", err)
+			ec.BadRequestWithMessageAndNoData("Failed to delete user")
+		}
+		return
+	}
+
+	// The current session ends with the account
+	if jti, expiresAt := utils.ExtractAccessTokenFromContext(c); jti != "" {
+		if err := utils.RevokeAccessToken(jti, expiresAt); err != nil {
+			log.Println("Error while revoking access token:", err)
+		}
+	}
+
+	ec.SuccessWithMessageAndNoData("User deleted successfully")
+}
+
+// deleteUserAccount hands the projects of the user over to their successors, reassigns the bugs of the user
+// and anonymizes the user, which is kept so that the history of the projects still references a valid user.
+// Projects where the user is the only admin need a successor in transfers, unless the user is their only member.
+func deleteUserAccount(tx *gorm.DB, user models.User, transfers map[uint]uint) ([]types.BlockingProject, error) {
+	var memberships []models.Team
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("Project").
+		Where("project_id IN (?)", tx.Model(&models.Team{}).Select("project_id").Where("user_id = ?", user.ID)).
+		Find(&memberships).Error; err != nil {
+		return nil, err
+	}
+
+	// Group the teams of every project the user is a member of
+	teams := make(map[uint][]models.Team)
+	projects := make(map[uint]models.Project)
+	for _, member := range memberships {
+		teams[member.ProjectID] = append(teams[member.ProjectID], member)
+		projects[member.ProjectID] = member.Project
+	}
+
+	// Work out who takes over from the user in every project, 0 meaning the user is the only member
+	successors := make(map[uint]uint)
+	var blockingProjects []types.BlockingProject
+	for projectID, team := range teams {
+		var userRole string
+		var otherAdmin, otherMember uint
+		requested, hasTransfer := transfers[projectID]
+		var transferTarget *models.Team
+
+		for i, member := range team {
+			switch {
+			case member.UserID == user.ID:
+				userRole = member.Role
+			case member.Role == types.TeamRoleAdmin.Value() && otherAdmin == 0:
+				otherAdmin = member.UserID
+			case otherMember == 0:
+				otherMember = member.UserID
+			}
+			if hasTransfer && member.UserID == requested && requested != user.ID {
+				transferTarget = &team[i]
+			}
+		}
+
+		switch {
+		case transferTarget != nil:
+			if transferTarget.Role != types.TeamRoleAdmin.Value() {
+				if err := tx.Model(transferTarget).Update("role", types.TeamRoleAdmin.Value()).Error; err != nil {
+					return nil, err
+				}
+			}
+			successors[projectID] = transferTarget.UserID
+		case otherAdmin != 0:
+			successors[projectID] = otherAdmin
+		case otherMember != 0 && userRole == types.TeamRoleAdmin.Value():
+			blockingProjects = append(blockingProjects, types.BlockingProject{ID: projectID, Title: projects[projectID].Title})
+		case otherMember != 0:
+			// Unreachable in practice since every project has an admin, fall back to any member
+			successors[projectID] = otherMember
+		default:
+			successors[projectID] = 0
+		}
+	}
+
+	if len(blockingProjects) > 0 {
+		return blockingProjects, errSoleAdmin
+	}
+
+	for projectID, successor := range successors {
+		if successor == 0 {
+			// Nobody else is left in the project
+			if err := tx.Delete(&models.Project{}, projectID).Error; err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if projects[projectID].CreatedBy == user.ID {
+			if err := tx.Model(&models.Project{}).Where("id = ?", projectID).Update("created_by", successor).Error; err != nil {
+				return nil, err
+			}
+		}
+
+		var bugs []models.Bug
+		if err := tx.Unscoped().Where("project_id = ? AND assigned_to = ?", projectID, user.ID).Find(&bugs).Error; err != nil {
+			return nil, err
+		}
+
+		for _, bug := range bugs {
+			if err := tx.Unscoped().Model(&bug).Update("assigned_to", successor).Error; err != nil {
+				return nil, err
+			}
+			if err := tx.Create(&models.BugHistory{
+				BugID:     bug.ID,
+				ChangedBy: user.ID,
+				Field:     "assigned_to",
+				OldValue:  strconv.FormatUint(uint64(user.ID), 10),
+				NewValue:  strconv.FormatUint(uint64(successor), 10),
+			}).Error; err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Remove everything that lets the user sign in or act on their own
+	if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(&models.Team{}).Error; err != nil {
+		return nil, err
+	}
+	for _, model := range []any{&models.RefreshToken{}, &models.UserToken{}, &models.RecoveryCode{}, &models.PersonalAccessToken{}, &models.Watch{}, &models.Notification{}, &models.NotificationPreference{}, &models.EmailNotificationSetting{}} {
+		if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(model).Error; err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Model(&models.Invitation{}).
+		Where("LOWER(email) = LOWER(?) AND status = ?", user.Email, types.InvitationStatusPending.Value()).
+		Update("status", types.InvitationStatusRevoked.Value()).Error; err != nil {
+		return nil, err
+	}
+
+	// Anonymize the user rather than deleting the row, so that the references to it stay valid
+	anonymizedID := strconv.FormatUint(uint64(user.ID), 10)
+	if err := tx.Model(&user).Updates(map[string]any{
+		"name":                "Deleted User",
+		"username":            "deleted-user-" + anonymizedID,
+		"email":               "deleted-user-" + anonymizedID + "@deleted.invalid",
+		"password":            "",
+		"email_verified_at":   nil,
+		"totp_secret":         "",
+		"totp_enabled_at":     nil,
+		"totp_last_used_step": 0,
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	if err := tx.Delete(&user).Error; err != nil {
+		return nil, err
+	}
+
+	return nil, nil
 }
 
 func GetUserBugs(c *gin.Context) {
 	type bugResult struct {
 		ID           uint      `json:"id"`
+		Number       uint      `json:"number"`
 		Title        string    `json:"title"`
 		Status       string    `json:"status"`
 		Priority     uint      `json:"priority"`
@@ -87,6 +310,7 @@ func GetUserBugs(c *gin.Context) {
 		UpdatedAt    time.Time `json:"updated_at"`
 		ProjectID    uint      `json:"project_id"`
 		ProjectTitle string    `json:"project_title"`
+		ProjectKey   string    `json:"project_key"`
 	}
 
 	var userBugs []types.UserBugsResponse
@@ -98,13 +322,15 @@ func GetUserBugs(c *gin.Context) {
 	query := conf.DB.Table("bugs").
 		Select(`
             bugs.id,
+            bugs.number,
             bugs.title,
             bugs.status,
             bugs.priority,
             bugs.created_at,
             bugs.updated_at,
             bugs.project_id as "project_id",
-            projects.title as "project_title"
+            projects.title as "project_title",
+            projects.key as "project_key"
         `).
 		Joins("JOIN projects ON bugs.project_id = projects.id").
 		Where("bugs.assigned_to = ?", user.ID).
@@ -120,6 +346,7 @@ func GetUserBugs(c *gin.Context) {
 	for _, result := range rawResults {
 		userBugs = append(userBugs, types.UserBugsResponse{
 			ID:        result.ID,
+			Key:       utils.BugKey(result.ProjectKey, result.Number),
 			Title:     result.Title,
 			Status:    types.BugStatus(result.Status),
 			Priority:  types.Priority(result.Priority),
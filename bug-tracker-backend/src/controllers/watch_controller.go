@@ -0,0 +1,237 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/conf"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/models"
+	types "github.com/WNBARookie/BugTracker/bug-tracker-backend/src/types"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/utils"
+)
+
+func WatchProject(c *gin.Context) {
+	ec := conf.EnhancedContext{Context: c}
+	project := utils.ExtractProjectFromContext(c)
+	user := utils.ExtractUserFromContext(c)
+
+	if err := utils.WatchProject(conf.DB, user.ID, project.ID, utils.WatchReasonManual); err != nil {
+		log.Println("Error while watching project:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to watch project")
+		return
+	}
+
+	ec.SuccessWithMessageAndNoData("You are now watching this project")
+}
+
+// UnwatchProject stops the notifications of the project, along with the bugs muted while watching it.
+// Bugs watched on their own are still watched.
+func UnwatchProject(c *gin.Context) {
+	ec := conf.EnhancedContext{Context: c}
+	project := utils.ExtractProjectFromContext(c)
+	user := utils.ExtractUserFromContext(c)
+
+	if err := conf.DB.Unscoped().
+		Where("user_id = ? AND project_id = ? AND (bug_id IS NULL OR muted)", user.ID, project.ID).
+		Delete(&models.Watch{}).Error; err != nil {
+		log.Println("Error while unwatching project:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to unwatch project")
+		return
+	}
+
+	ec.SuccessWithMessageAndNoData("You are no longer watching this project")
+}
+
+// WatchBug subscribes the member to the bug, unmuting it if they had muted it.
+func WatchBug(c *gin.Context) {
+	ec := conf.EnhancedContext{Context: c}
+	bug := utils.ExtractBugFromContext(c)
+	user := utils.ExtractUserFromContext(c)
+
+	err := conf.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ? AND bug_id = ? AND muted", user.ID, bug.ID).Delete(&models.Watch{}).Error; err != nil {
+			return err
+		}
+		return utils.WatchBug(tx, user.ID, bug, utils.WatchReasonManual)
+	})
+
+	if err != nil {
+		log.Println("Error while watching bug:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to watch bug")
+		return
+	}
+
+	ec.SuccessWithMessageAndNoData("You are now watching this bug")
+}
+
+// UnwatchBug stops the notifications of the bug. If the member watches the project, the bug is muted instead,
+// so that its changes are left out of the notifications of the project.
+func UnwatchBug(c *gin.Context) {
+	ec := conf.EnhancedContext{Context: c}
+	bug := utils.ExtractBugFromContext(c)
+	user := utils.ExtractUserFromContext(c)
+
+	err := conf.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ? AND bug_id = ?", user.ID, bug.ID).Delete(&models.Watch{}).Error; err != nil {
+			return err
+		}
+
+		watchingProject, err := utils.IsWatchingProject(tx, user.ID, bug.ProjectID)
+		if err != nil || !watchingProject {
+			return err
+		}
+
+		return tx.Create(&models.Watch{
+			UserID:    user.ID,
+			ProjectID: bug.ProjectID,
+			BugID:     &bug.ID,
+			Reason:    utils.WatchReasonManual,
+			Muted:     true,
+		}).Error
+	})
+
+	if err != nil {
+		log.Println("Error while unwatching bug:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to unwatch bug")
+		return
+	}
+
+	ec.SuccessWithMessageAndNoData("You are no longer watching this bug")
+}
+
+// GetBugWatchers lists the members who are notified about the changes to the bug.
+func GetBugWatchers(c *gin.Context) {
+	ec := conf.EnhancedContext{Context: c}
+	bug := utils.ExtractBugFromContext(c)
+
+	subscribers, err := utils.GetBugSubscribers(conf.DB, bug)
+	if err != nil {
+		log.Println("Error while retrieving bug watchers:", err)
+		ec.BadRequestWithNoMessageAndNoData()
+		return
+	}
+
+	data := make([]types.WatcherResponse, 0, len(subscribers))
+	if len(subscribers) == 0 {
+		ec.SuccessWithMessage("Watchers retrieved successfully", data)
+		return
+	}
+
+	// Watches of the bug itself come first, so that they win over the watch of the project
+	var watches []models.Watch
+	if err := conf.DB.Preload("User").
+		Where("project_id = ? AND (bug_id = ? OR bug_id IS NULL) AND user_id IN ? AND NOT muted", bug.ProjectID, bug.ID, subscribers).
+		Order("bug_id IS NULL, created_at ASC").
+		Find(&watches).Error; err != nil {
+		log.Println("Error while retrieving bug watchers:", err)
+		ec.BadRequestWithNoMessageAndNoData()
+		return
+	}
+
+	listed := make(map[uint]bool)
+	for _, watch := range watches {
+		if listed[watch.UserID] {
+			continue
+		}
+		listed[watch.UserID] = true
+
+		via := types.WatchTargetBug
+		if watch.BugID == nil {
+			via = types.WatchTargetProject
+		}
+
+		data = append(data, types.WatcherResponse{
+			User: types.AssignedTo{
+				ID:    watch.User.ID,
+				Name:  watch.User.Name,
+				Email: watch.User.Email,
+			},
+			Via:    via,
+			Reason: types.WatchReason(watch.Reason),
+		})
+	}
+
+	ec.SuccessWithMessage("Watchers retrieved successfully", data)
+}
+
+// GetUserWatches lists the projects and bugs the user watches, leaving out the ones in the trash and the projects
+// the user is no longer a member of.
+func GetUserWatches(c *gin.Context) {
+	var params types.WatchListQueryParams
+	ec := conf.EnhancedContext{Context: c}
+	user := utils.ExtractUserFromContext(c)
+
+	if err := c.ShouldBindQuery(&params); err != nil {
+		ec.ValidationError(err.Error())
+		return
+	}
+
+	query := conf.DB.Model(&models.Watch{}).
+		Joins("INNER JOIN teams ON teams.user_id = watches.user_id AND teams.project_id = watches.project_id AND teams.deleted_at IS NULL").
+		Where("watches.user_id = ?", user.ID).
+		Where("watches.project_id IN (?)", conf.DB.Model(&models.Project{}).Select("id")).
+		Where("watches.bug_id IS NULL OR watches.bug_id IN (?)", conf.DB.Model(&models.Bug{}).Select("id"))
+
+	if params.Target != nil {
+		if types.WatchTarget(*params.Target) == types.WatchTargetProject {
+			query = query.Where("watches.bug_id IS NULL")
+		} else {
+			query = query.Where("watches.bug_id IS NOT NULL")
+		}
+	}
+
+	var totalCount int64
+	if err := query.Count(&totalCount).Error; err != nil {
+		log.Println("Error while counting watches:", err)
+		ec.BadRequestWithNoMessageAndNoData()
+		return
+	}
+
+	offset := (params.Page - 1) * params.Limit
+	query = query.Preload("Project").Preload("Bug").Order("watches.created_at DESC").Limit(params.Limit).Offset(offset)
+
+	var watches []models.Watch
+	if err := query.Find(&watches).Error; err != nil {
+		log.Println("Error while retrieving watches:", err)
+		ec.BadRequestWithNoMessageAndNoData()
+		return
+	}
+
+	data := make([]types.WatchResponse, 0, len(watches))
+	for _, watch := range watches {
+		data = append(data, buildWatchResponse(watch))
+	}
+
+	paginatedResponse := utils.Paginate(data, params.Page, params.Limit, int(totalCount))
+	c.JSON(http.StatusOK, paginatedResponse)
+}
+
+func buildWatchResponse(watch models.Watch) types.WatchResponse {
+	response := types.WatchResponse{
+		ID:     watch.ID,
+		Target: types.WatchTargetProject,
+		Project: types.WatchedProject{
+			ID:    watch.Project.ID,
+			Key:   watch.Project.Key,
+			Title: watch.Project.Title,
+		},
+		Reason:    types.WatchReason(watch.Reason),
+		Muted:     watch.Muted,
+		CreatedAt: watch.CreatedAt,
+	}
+
+	if watch.Bug != nil {
+		response.Target = types.WatchTargetBug
+		response.Bug = &types.LinkedBug{
+			ID:     watch.Bug.ID,
+			Key:    utils.BugKey(watch.Project.Key, watch.Bug.Number),
+			Title:  watch.Bug.Title,
+			Status: types.BugStatus(watch.Bug.Status),
+		}
+	}
+
+	return response
+}
@@ -0,0 +1,404 @@
+package controllers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/conf"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/events"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/models"
+	types "github.com/WNBARookie/BugTracker/bug-tracker-backend/src/types"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/utils"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/webhooks"
+)
+
+// CreateWebhook registers a URL the events of the project are posted to. The secret signing the payloads is
+// only returned in this response.
+func CreateWebhook(c *gin.Context) {
+	var body types.CreateWebhook
+	ec := conf.EnhancedContext{Context: c}
+	project := utils.ExtractProjectFromContext(c)
+	user := utils.ExtractUserFromContext(c)
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		ec.ValidationError(err.Error())
+		return
+	}
+
+	if !checkWebhookURL(c, body.URL) {
+		return
+	}
+
+	secret, err := webhooks.GenerateSecret()
+	if err != nil {
+		log.Println("Error while generating webhook secret:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to create webhook")
+		return
+	}
+
+	webhook := models.Webhook{
+		ProjectID: project.ID,
+		URL:       body.URL,
+		Secret:    secret,
+		Events:    webhookEvents(body.Events),
+		Active:    true,
+		CreatedBy: user.ID,
+	}
+
+	if err := conf.DB.Create(&webhook).Error; err != nil {
+		log.Println("Error while creating webhook:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to create webhook")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Webhook created successfully",
+		"data": types.WebhookSecretResponse{
+			WebhookResponse: buildWebhookResponse(webhook),
+			Secret:          secret,
+		},
+	})
+}
+
+func GetWebhooks(c *gin.Context) {
+	ec := conf.EnhancedContext{Context: c}
+	project := utils.ExtractProjectFromContext(c)
+
+	var hooks []models.Webhook
+	if err := conf.DB.Where("project_id = ?", project.ID).Order("created_at ASC").Find(&hooks).Error; err != nil {
+		log.Println("Error while retrieving webhooks:", err)
+		ec.BadRequestWithNoMessageAndNoData()
+		return
+	}
+
+	data := make([]types.WebhookResponse, 0, len(hooks))
+	for _, webhook := range hooks {
+		data = append(data, buildWebhookResponse(webhook))
+	}
+
+	ec.SuccessWithMessage("Webhooks retrieved successfully", data)
+}
+
+func GetWebhookByID(c *gin.Context) {
+	ec := conf.EnhancedContext{Context: c}
+	webhook := utils.ExtractWebhookFromContext(c)
+
+	ec.SuccessWithMessage("Webhook retrieved successfully", buildWebhookResponse(webhook))
+}
+
+func UpdateWebhook(c *gin.Context) {
+	var body types.UpdateWebhook
+	ec := conf.EnhancedContext{Context: c}
+	webhook := utils.ExtractWebhookFromContext(c)
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		ec.ValidationError(err.Error())
+		return
+	}
+
+	updateData := make(map[string]any)
+	if body.URL != nil {
+		if !checkWebhookURL(c, *body.URL) {
+			return
+		}
+		updateData["url"] = *body.URL
+	}
+	if body.Events != nil {
+		updateData["events"] = webhookEvents(*body.Events)
+	}
+	if body.Active != nil {
+		updateData["active"] = *body.Active
+		if *body.Active && !webhook.Active {
+			// A webhook enabled again gets a fresh start, the deliveries it missed are sent by the jobs
+			updateData["failure_count"] = 0
+			updateData["disabled_reason"] = ""
+		}
+	}
+
+	secret := ""
+	if body.RotateSecret {
+		var err error
+		if secret, err = webhooks.GenerateSecret(); err != nil {
+			log.Println("Error while generating webhook secret:", err)
+			ec.BadRequestWithMessageAndNoData("Failed to update webhook")
+			return
+		}
+		updateData["secret"] = secret
+	}
+
+	if len(updateData) == 0 {
+		ec.BadRequestWithMessageAndNoData("No fields to update")
+		return
+	}
+
+	if err := conf.DB.Model(&webhook).Updates(updateData).Error; err != nil {
+		log.Println("Error while updating webhook:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to update webhook")
+		return
+	}
+
+	if secret != "" {
+		ec.SuccessWithMessage("Webhook updated successfully", types.WebhookSecretResponse{
+			WebhookResponse: buildWebhookResponse(webhook),
+			Secret:          secret,
+		})
+		return
+	}
+
+	ec.SuccessWithMessage("Webhook updated successfully", buildWebhookResponse(webhook))
+}
+
+// DeleteWebhook permanently deletes the webhook along with its deliveries.
+func DeleteWebhook(c *gin.Context) {
+	ec := conf.EnhancedContext{Context: c}
+	webhook := utils.ExtractWebhookFromContext(c)
+
+	if err := conf.DB.Unscoped().Delete(&webhook).Error; err != nil {
+		log.Println("Error while deleting webhook:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to delete webhook")
+		return
+	}
+
+	ec.SuccessWithMessageAndNoData("Webhook deleted successfully")
+}
+
+func GetWebhookDeliveries(c *gin.Context) {
+	var params types.WebhookDeliveryListQueryParams
+	ec := conf.EnhancedContext{Context: c}
+	webhook := utils.ExtractWebhookFromContext(c)
+
+	if err := c.ShouldBindQuery(&params); err != nil {
+		ec.ValidationError(err.Error())
+		return
+	}
+
+	query := conf.DB.Model(&models.WebhookDelivery{}).Where("webhook_id = ?", webhook.ID)
+
+	if params.Status != nil {
+		query = query.Where("status = ?", *params.Status)
+	}
+	if params.Event != nil {
+		query = query.Where("event = ?", *params.Event)
+	}
+
+	var totalCount int64
+	if err := query.Count(&totalCount).Error; err != nil {
+		log.Println("Error while counting webhook deliveries:", err)
+		ec.BadRequestWithNoMessageAndNoData()
+		return
+	}
+
+	offset := (params.Page - 1) * params.Limit
+	query = query.Omit("payload", "response_body").Order("created_at DESC, id DESC").Limit(params.Limit).Offset(offset)
+
+	var deliveries []models.WebhookDelivery
+	if err := query.Find(&deliveries).Error; err != nil {
+		log.Println("Error while retrieving webhook deliveries:", err)
+		ec.BadRequestWithNoMessageAndNoData()
+		return
+	}
+
+	data := make([]types.WebhookDeliveryResponse, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		data = append(data, buildWebhookDeliveryResponse(delivery))
+	}
+
+	paginatedResponse := utils.Paginate(data, params.Page, params.Limit, int(totalCount))
+	c.JSON(http.StatusOK, paginatedResponse)
+}
+
+// GetWebhookDelivery returns a delivery along with the payload sent and the start of the last response.
+func GetWebhookDelivery(c *gin.Context) {
+	ec := conf.EnhancedContext{Context: c}
+
+	delivery, ok := findWebhookDelivery(c)
+	if !ok {
+		return
+	}
+
+	ec.SuccessWithMessage("Webhook delivery retrieved successfully", buildWebhookDeliveryDetailResponse(delivery))
+}
+
+// RedeliverWebhookDelivery sends the payload of a past delivery again as a new delivery, with the same event ID so
+// that receivers can tell it apart from a new event. The first attempt is made before responding.
+func RedeliverWebhookDelivery(c *gin.Context) {
+	ec := conf.EnhancedContext{Context: c}
+	webhook := utils.ExtractWebhookFromContext(c)
+
+	delivery, ok := findWebhookDelivery(c)
+	if !ok {
+		return
+	}
+
+	if !webhook.Active {
+		ec.BadRequestWithMessageAndNoData("Webhook is disabled, enable it before redelivering")
+		return
+	}
+
+	now := time.Now()
+	redelivery := models.WebhookDelivery{
+		WebhookID:      webhook.ID,
+		EventID:        delivery.EventID,
+		Event:          delivery.Event,
+		Payload:        delivery.Payload,
+		Status:         types.WebhookDeliveryStatusPending.Value(),
+		NextAttemptAt:  &now,
+		RedeliveryOfID: &delivery.ID,
+	}
+	sendWebhookDelivery(c, redelivery, "Webhook redelivered")
+}
+
+// PingWebhook sends a ping event to the webhook, to check that the receiver gets and verifies the payloads.
+func PingWebhook(c *gin.Context) {
+	ec := conf.EnhancedContext{Context: c}
+	project := utils.ExtractProjectFromContext(c)
+	user := utils.ExtractUserFromContext(c)
+	webhook := utils.ExtractWebhookFromContext(c)
+
+	if !webhook.Active {
+		ec.BadRequestWithMessageAndNoData("Webhook is disabled, enable it before pinging it")
+		return
+	}
+
+	payload, err := webhooks.BuildPayload(conf.DB, events.Event{
+		Type:      events.WebhookPing,
+		ProjectID: project.ID,
+		ActorID:   user.ID,
+		Data:      map[string]any{"webhook_id": webhook.ID, "events": webhook.Events},
+	})
+	if err != nil {
+		log.Println("Error while building webhook payload:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to ping webhook")
+		return
+	}
+
+	delivery, err := webhooks.NewDelivery(webhook, payload)
+	if err != nil {
+		log.Println("Error while building webhook delivery:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to ping webhook")
+		return
+	}
+	sendWebhookDelivery(c, delivery, "Webhook pinged")
+}
+
+// sendWebhookDelivery records the delivery and makes its first attempt, responding with the outcome.
+// A failed attempt is still retried by the jobs like any other delivery.
+// checkWebhookURL responds with a validation error if payloads cannot be sent to the URL, and reports whether they can.
+func checkWebhookURL(c *gin.Context, rawURL string) bool {
+	if err := webhooks.ValidateURL(c.Request.Context(), rawURL); err != nil {
+		ec := conf.EnhancedContext{Context: c}
+		ec.BadRequestWithMessageAndNoData("Webhook URL must be an http or https URL of a public host")
+		return false
+	}
+	return true
+}
+
+func sendWebhookDelivery(c *gin.Context, delivery models.WebhookDelivery, message string) {
+	ec := conf.EnhancedContext{Context: c}
+
+	if err := conf.DB.Create(&delivery).Error; err != nil {
+		log.Println("Error while creating webhook delivery:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to send webhook delivery")
+		return
+	}
+
+	sent, err := webhooks.Deliver(conf.DB, delivery.ID)
+	if err != nil {
+		log.Println("Error while delivering webhook:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to send webhook delivery")
+		return
+	}
+
+	if sent.Status != types.WebhookDeliveryStatusSucceeded.Value() {
+		message += ", but the receiver did not accept it"
+	} else {
+		message += " successfully"
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": message,
+		"data":    buildWebhookDeliveryDetailResponse(sent),
+	})
+}
+
+func findWebhookDelivery(c *gin.Context) (models.WebhookDelivery, bool) {
+	var deliveryURI types.WebhookDeliveryURI
+	ec := conf.EnhancedContext{Context: c}
+	webhook := utils.ExtractWebhookFromContext(c)
+
+	if err := c.ShouldBindUri(&deliveryURI); err != nil {
+		ec.ValidationError(err.Error())
+		return models.WebhookDelivery{}, false
+	}
+
+	var delivery models.WebhookDelivery
+	if err := conf.DB.Where("webhook_id = ?", webhook.ID).First(&delivery, deliveryURI.DeliveryID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Webhook delivery not found"})
+		return models.WebhookDelivery{}, false
+	}
+
+	return delivery, true
+}
+
+// webhookEvents removes the repeated types of event, keeping the order they were given in.
+func webhookEvents(eventTypes []events.Type) pq.StringArray {
+	values := make(pq.StringArray, 0, len(eventTypes))
+	for _, eventType := range eventTypes {
+		if !slices.Contains(values, eventType.Value()) {
+			values = append(values, eventType.Value())
+		}
+	}
+	return values
+}
+
+func buildWebhookResponse(webhook models.Webhook) types.WebhookResponse {
+	eventTypes := make([]events.Type, 0, len(webhook.Events))
+	for _, eventType := range webhook.Events {
+		eventTypes = append(eventTypes, events.Type(eventType))
+	}
+
+	return types.WebhookResponse{
+		ID:             webhook.ID,
+		ProjectID:      webhook.ProjectID,
+		URL:            webhook.URL,
+		Events:         eventTypes,
+		Active:         webhook.Active,
+		FailureCount:   webhook.FailureCount,
+		DisabledReason: webhook.DisabledReason,
+		CreatedBy:      webhook.CreatedBy,
+		CreatedAt:      webhook.CreatedAt,
+		UpdatedAt:      webhook.UpdatedAt,
+	}
+}
+
+func buildWebhookDeliveryResponse(delivery models.WebhookDelivery) types.WebhookDeliveryResponse {
+	return types.WebhookDeliveryResponse{
+		ID:             delivery.ID,
+		WebhookID:      delivery.WebhookID,
+		EventID:        delivery.EventID,
+		Event:          events.Type(delivery.Event),
+		Status:         types.WebhookDeliveryStatus(delivery.Status),
+		Attempts:       delivery.Attempts,
+		NextAttemptAt:  delivery.NextAttemptAt,
+		ResponseStatus: delivery.ResponseStatus,
+		Error:          delivery.Error,
+		DurationMs:     delivery.DurationMs,
+		DeliveredAt:    delivery.DeliveredAt,
+		RedeliveryOfID: delivery.RedeliveryOfID,
+		CreatedAt:      delivery.CreatedAt,
+	}
+}
+
+func buildWebhookDeliveryDetailResponse(delivery models.WebhookDelivery) types.WebhookDeliveryDetailResponse {
+	return types.WebhookDeliveryDetailResponse{
+		WebhookDeliveryResponse: buildWebhookDeliveryResponse(delivery),
+		Payload:                 json.RawMessage(delivery.Payload),
+		ResponseBody:            delivery.ResponseBody,
+	}
+}
@@ -0,0 +1,237 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/conf"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/models"
+	types "github.com/WNBARookie/BugTracker/bug-tracker-backend/src/types"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/utils"
+)
+
+var workflowStateKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+func GetWorkflow(c *gin.Context) {
+	ec := conf.EnhancedContext{Context: c}
+	project := utils.ExtractProjectFromContext(c)
+
+	workflow, err := utils.GetProjectWorkflow(conf.DB, project.ID)
+	if err != nil {
+		log.Println("Error while retrieving project workflow:", err)
+		ec.BadRequestWithNoMessageAndNoData()
+		return
+	}
+
+	ec.SuccessWithMessage("Workflow retrieved successfully", buildWorkflowResponse(workflow))
+}
+
+// UpdateWorkflow replaces the workflow of the project. States that bugs are still in cannot be removed.
+func UpdateWorkflow(c *gin.Context) {
+	var body types.UpdateWorkflow
+	ec := conf.EnhancedContext{Context: c}
+	project := utils.ExtractProjectFromContext(c)
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		ec.ValidationError(err.Error())
+		return
+	}
+
+	if message := validateWorkflow(body); message != "" {
+		ec.BadRequestWithMessageAndNoData(message)
+		return
+	}
+
+	keys := make([]string, 0, len(body.States))
+	for _, state := range body.States {
+		keys = append(keys, state.Key.Value())
+	}
+
+	var statesInUse []types.BugStatus
+	err := conf.DB.Transaction(func(tx *gorm.DB) error {
+		// Serialize the updates of the workflow of the project
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&models.Project{}, project.ID).Error; err != nil {
+			return err
+		}
+
+		// Bugs in the trash are checked too, since they can be restored
+		if err := tx.Unscoped().Model(&models.Bug{}).
+			Where("project_id = ? AND status NOT IN ?", project.ID, keys).
+			Distinct().Pluck("status", &statesInUse).Error; err != nil {
+			return err
+		} else if len(statesInUse) > 0 {
+			return nil
+		}
+
+		if err := tx.Unscoped().Where("project_id = ?", project.ID).Delete(&models.WorkflowTransition{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("project_id = ?", project.ID).Delete(&models.WorkflowState{}).Error; err != nil {
+			return err
+		}
+
+		states := make([]models.WorkflowState, 0, len(body.States))
+		for i, state := range body.States {
+			states = append(states, models.WorkflowState{
+				ProjectID: project.ID,
+				Key:       state.Key.Value(),
+				Name:      state.Name,
+				Category:  state.Category.Value(),
+				Position:  i,
+				Initial:   state.Initial,
+			})
+		}
+		if err := tx.Create(&states).Error; err != nil {
+			return err
+		}
+
+		if len(body.Transitions) == 0 {
+			return nil
+		}
+
+		transitions := make([]models.WorkflowTransition, 0, len(body.Transitions))
+		for _, transition := range body.Transitions {
+			roles := make([]string, 0, len(transition.Roles))
+			for _, role := range transition.Roles {
+				roles = append(roles, role.Value())
+			}
+			transitions = append(transitions, models.WorkflowTransition{
+				ProjectID: project.ID,
+				FromState: transition.From.Value(),
+				ToState:   transition.To.Value(),
+				Roles:     roles,
+			})
+		}
+		return tx.Create(&transitions).Error
+	})
+
+	if err != nil {
+		log.Println("Error while updating project workflow:", err)
+		ec.BadRequestWithMessageAndNoData("Failed to update workflow")
+		return
+	}
+
+	if len(statesInUse) > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"message": "Bugs are still in states removed from the workflow, move them to other states first",
+			"data":    types.WorkflowStatesInUseResponse{States: statesInUse},
+		})
+		return
+	}
+
+	workflow, err := utils.GetProjectWorkflow(conf.DB, project.ID)
+	if err != nil {
+		log.Println("Error while retrieving project workflow:", err)
+		ec.BadRequestWithNoMessageAndNoData()
+		return
+	}
+
+	ec.SuccessWithMessage("Workflow updated successfully", buildWorkflowResponse(workflow))
+}
+
+// validateWorkflow checks the rules that binding cannot express, returning a message describing the first problem found.
+func validateWorkflow(body types.UpdateWorkflow) string {
+	states := make(map[types.BugStatus]bool)
+	initialStates := 0
+	for _, state := range body.States {
+		if !workflowStateKeyPattern.MatchString(state.Key.Value()) {
+			return fmt.Sprintf("State key %q must only contain lowercase letters, digits and underscores, and start with a letter", state.Key)
+		}
+		if states[state.Key] {
+			return fmt.Sprintf("State %q is listed more than once", state.Key)
+		}
+		states[state.Key] = true
+
+		if state.Initial {
+			initialStates++
+		}
+	}
+
+	if initialStates != 1 {
+		return "Exactly one state must be the initial state"
+	}
+
+	transitions := make(map[[2]types.BugStatus]bool)
+	for _, transition := range body.Transitions {
+		if !states[transition.From] || !states[transition.To] {
+			return fmt.Sprintf("Transition from %q to %q uses a state that is not part of the workflow", transition.From, transition.To)
+		}
+		if transition.From == transition.To {
+			return fmt.Sprintf("Transition from %q cannot lead to the same state", transition.From)
+		}
+
+		pair := [2]types.BugStatus{transition.From, transition.To}
+		if transitions[pair] {
+			return fmt.Sprintf("Transition from %q to %q is listed more than once", transition.From, transition.To)
+		}
+		transitions[pair] = true
+	}
+
+	return ""
+}
+
+// checkStatusTransition writes the error response and returns false if the member is not allowed to move a bug
+// between the states. Moving a bug into or out of a closed state also requires the bug.close permission.
+func checkStatusTransition(c *gin.Context, workflow utils.Workflow, from, to string) bool {
+	ec := conf.EnhancedContext{Context: c}
+	role := utils.ExtractUserRoleFromContext(c)
+
+	if workflow.State(to) == nil {
+		ec.BadRequestWithMessageAndNoData(fmt.Sprintf("Status %q is not part of the project workflow", to))
+		return false
+	}
+
+	if workflow.Transition(from, to) == nil {
+		ec.BadRequestWithMessageAndNoData(fmt.Sprintf("Bugs cannot move from %q to %q", from, to))
+		return false
+	}
+
+	if !workflow.CanTransition(from, to, role) {
+		ec.ForbiddenWithMessageAndNoData(fmt.Sprintf("Your role cannot move bugs from %q to %q", from, to))
+		return false
+	}
+
+	if workflow.IsClosed(from) != workflow.IsClosed(to) {
+		return checkPermission(c, types.PermissionBugClose)
+	}
+
+	return true
+}
+
+func buildWorkflowResponse(workflow utils.Workflow) types.WorkflowResponse {
+	response := types.WorkflowResponse{
+		States:      make([]types.WorkflowStateResponse, 0, len(workflow.States)),
+		Transitions: make([]types.WorkflowTransitionResponse, 0, len(workflow.Transitions)),
+		IsDefault:   workflow.IsDefault,
+	}
+
+	for _, state := range workflow.States {
+		response.States = append(response.States, types.WorkflowStateResponse{
+			Key:      types.BugStatus(state.Key),
+			Name:     state.Name,
+			Category: types.WorkflowCategory(state.Category),
+			Position: state.Position,
+			Initial:  state.Initial,
+		})
+	}
+
+	for _, transition := range workflow.Transitions {
+		roles := make([]types.TeamRole, 0, len(transition.Roles))
+		for _, role := range transition.Roles {
+			roles = append(roles, types.TeamRole(role))
+		}
+		response.Transitions = append(response.Transitions, types.WorkflowTransitionResponse{
+			From:  types.BugStatus(transition.FromState),
+			To:    types.BugStatus(transition.ToState),
+			Roles: roles,
+		})
+	}
+
+	return response
+}
@@ -0,0 +1,116 @@
+package events
+
+import (
+	"log"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// Type identifies what happened, and is also the name under which users pick the notifications they receive.
+type Type string
+
+const (
+	BugCreated             Type = "bug.created"
+	BugUpdated             Type = "bug.updated"
+	BugDeleted             Type = "bug.deleted"
+	BugRestored            Type = "bug.restored"
+	BugAssigned            Type = "bug.assigned"
+	BugStatusChanged       Type = "bug.status_changed"
+	BugDeadlineApproaching Type = "bug.deadline_approaching"
+	CommentCreated         Type = "comment.created"
+	CommentMention         Type = "comment.mention"
+	TeamMemberAdded        Type = "team.member_added"
+	TeamMemberUpdated      Type = "team.member_updated"
+	TeamMemberRemoved      Type = "team.member_removed"
+	WebhookPing            Type = "ping"
+)
+
+// NotificationTypes lists the types of event users can be notified about, in the order they are shown to them.
+var NotificationTypes = []Type{BugAssigned, BugStatusChanged, CommentCreated, CommentMention, BugDeadlineApproaching}
+
+// EmailTypes lists the types of event that are emailed as they happen to the users who chose immediate emails.
+// The digests summarize all the unread notifications instead.
+var EmailTypes = []Type{BugAssigned, CommentMention, BugDeadlineApproaching}
+
+// WebhookTypes lists the types of event webhooks can subscribe to. Mentions are left out, as they are about
+// the mentioned users rather than the project.
+var WebhookTypes = []Type{
+	BugCreated, BugUpdated, BugDeleted, BugRestored, BugAssigned, BugStatusChanged, BugDeadlineApproaching,
+	CommentCreated, TeamMemberAdded, TeamMemberUpdated, TeamMemberRemoved,
+}
+
+func (t Type) Value() string {
+	return string(t)
+}
+
+// Event is a change to the data of a project that users may want to hear about.
+type Event struct {
+	Type       Type
+	ProjectID  uint
+	BugID      uint           // 0 for events that are not about a bug
+	ActorID    uint           // User who caused the event, 0 for the ones raised by the server
+	UserIDs    []uint         // Users the event is directly about, such as the new assignee or the mentioned users
+	Data       map[string]any // Details depending on the type, such as the old and new status
+	OccurredAt time.Time
+}
+
+// Handler reacts to an event. Handlers run on the goroutine that publishes the event, after the change is committed,
+// so they should be quick and report their own errors.
+type Handler func(Event)
+
+// Bus delivers the published events to every subscribed handler.
+type Bus struct {
+	mu       sync.RWMutex
+	handlers []Handler
+}
+
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+func (b *Bus) Subscribe(handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, handler)
+}
+
+// Publish hands the event to the handlers one after the other. A handler that panics does not prevent
+// the others from running, nor fails the request that published the event.
+func (b *Bus) Publish(event Event) {
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+
+	b.mu.RLock()
+	handlers := append([]Handler(nil), b.handlers...)
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("Error while handling %s event: %v\n%s", event.Type, r, debug.Stack())
+				}
+			}()
+			handler(event)
+		}()
+	}
+}
+
+var defaultBus = NewBus()
+
+// Default returns the bus the controllers publish to.
+func Default() *Bus {
+	return defaultBus
+}
+
+// Subscribe adds the handler to the default bus.
+func Subscribe(handler Handler) {
+	defaultBus.Subscribe(handler)
+}
+
+// Publish sends the event to the handlers of the default bus.
+func Publish(event Event) {
+	defaultBus.Publish(event)
+}
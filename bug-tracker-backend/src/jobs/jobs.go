@@ -0,0 +1,91 @@
+package jobs
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/conf"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/notifications"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/throttle"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/utils"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/webhooks"
+)
+
+// ErrUnknownJob is returned by Run for a key that names no job.
+var ErrUnknownJob = errors.New("unknown job")
+
+// job is a background job of the system. Long running servers run it on every tick of its interval, serverless
+// deployments run it from the cron endpoint with its key.
+type job struct {
+	key      string
+	name     string
+	interval time.Duration
+	run      func() error
+}
+
+// schedule lists the background jobs of the system.
+func schedule() []job {
+	jobs := []job{
+		{key: "trash_purge", name: "trash purge", interval: time.Hour, run: purgeTrash},
+		{key: "expired_token_purge", name: "expired token purge", interval: time.Hour, run: utils.PurgeExpiredTokens},
+		{key: "orphaned_attachment_purge", name: "orphaned attachment purge", interval: time.Hour, run: utils.PurgeOrphanedAttachmentBlobs},
+		{key: "deadline_notification", name: "deadline notification", interval: 15 * time.Minute, run: func() error {
+			return notifications.NotifyApproachingDeadlines(notifications.DeadlineWindow())
+		}},
+		{key: "notification_digest", name: "notification digest", interval: 15 * time.Minute, run: notifications.SendDigests},
+		{key: "webhook_delivery", name: "webhook delivery", interval: time.Minute, run: webhooks.DeliverDue},
+		{key: "webhook_delivery_purge", name: "webhook delivery purge", interval: time.Hour, run: webhooks.PurgeOldDeliveries},
+	}
+
+	if store, ok := throttle.Default().Store.(*throttle.DatabaseStore); ok {
+		jobs = append(jobs, job{key: "stale_login_attempt_purge", name: "stale login attempt purge", interval: time.Hour, run: store.PurgeStale})
+	}
+
+	return jobs
+}
+
+// every runs the job once immediately and then on every tick of the interval, for as long as the process lives.
+func every(name string, interval time.Duration, job func() error) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := job(); err != nil {
+				log.Printf("Error while running %s job: %v", name, err)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// Start schedules all the background jobs of the system.
+// It should only be called by long running servers, not by serverless handlers, which run the jobs with Run instead.
+func Start() {
+	if conf.DB == nil {
+		log.Println("Warning: Database is not connected, background jobs will not be started")
+		return
+	}
+
+	for _, job := range schedule() {
+		every(job.name, job.interval, job.run)
+	}
+}
+
+// Run runs the job with the key once, for the deployments that cannot keep the jobs running in the background,
+// such as serverless ones where a scheduler calls the cron endpoint instead.
+func Run(key string) error {
+	for _, job := range schedule() {
+		if job.key != key {
+			continue
+		}
+
+		if conf.DB == nil {
+			return errors.New("database is not connected")
+		}
+		return job.run()
+	}
+
+	return ErrUnknownJob
+}
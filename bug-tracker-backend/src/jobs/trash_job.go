@@ -0,0 +1,8 @@
+package jobs
+
+import "github.com/WNBARookie/BugTracker/bug-tracker-backend/src/utils"
+
+func purgeTrash() error {
+	_, err := utils.PurgeExpiredBugs(0)
+	return err
+}
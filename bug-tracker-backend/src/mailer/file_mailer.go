@@ -0,0 +1,34 @@
+package mailer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileMailer writes every email to a .eml file instead of delivering it, for local development.
+type FileMailer struct {
+	Dir string
+}
+
+func NewFileMailer(dir string) *FileMailer {
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), "bug-tracker-mails")
+	}
+	return &FileMailer{Dir: dir}
+}
+
+func (m *FileMailer) Send(message Message) error {
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return err
+	}
+
+	body, err := buildMIMEMessage(fromAddress(), message)
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102T150405.000000000"), randomBoundary()[:8])
+	return os.WriteFile(filepath.Join(m.Dir, name), body, 0o644)
+}
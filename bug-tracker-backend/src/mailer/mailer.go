@@ -0,0 +1,76 @@
+package mailer
+
+import (
+	"log"
+	"os"
+	"sync"
+)
+
+// Message is an email to be delivered by a Mailer. Either Text or HTML, or both, should be set.
+type Message struct {
+	To      []string
+	Subject string
+	Text    string
+	HTML    string
+	Headers map[string]string
+}
+
+// Mailer delivers emails. Implementations must be safe for concurrent use.
+type Mailer interface {
+	Send(message Message) error
+}
+
+var (
+	defaultMailer Mailer
+	once          sync.Once
+)
+
+// newMailerFromEnv picks the mailer implementation based on the MAILER_DRIVER environment variable.
+func newMailerFromEnv() Mailer {
+	driver := os.Getenv("MAILER_DRIVER")
+	if driver == "" {
+		if os.Getenv("SMTP_HOST") != "" {
+			driver = "smtp"
+		} else {
+			driver = "file"
+		}
+	}
+
+	switch driver {
+	case "smtp":
+		return NewSMTPMailerFromEnv()
+	case "memory":
+		return NewMemoryMailer()
+	case "file":
+		return NewFileMailer(os.Getenv("MAIL_DIR"))
+	default:
+		log.Printf("Warning: Unknown mailer driver %q, emails will be written to files", driver)
+		return NewFileMailer(os.Getenv("MAIL_DIR"))
+	}
+}
+
+// SetDefault replaces the mailer used by Send, for example with a MemoryMailer in tests.
+func SetDefault(mailer Mailer) {
+	once.Do(func() {})
+	defaultMailer = mailer
+}
+
+// Default returns the mailer used by Send, configuring it from the environment on first use.
+func Default() Mailer {
+	once.Do(func() {
+		defaultMailer = newMailerFromEnv()
+	})
+	return defaultMailer
+}
+
+// Send delivers the message using the default mailer.
+func Send(message Message) error {
+	return Default().Send(message)
+}
+
+func fromAddress() string {
+	if from := os.Getenv("MAIL_FROM"); from != "" {
+		return from
+	}
+	return "Bug Tracker <no-reply@bugtracker.local>"
+}
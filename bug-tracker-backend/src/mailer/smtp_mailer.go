@@ -0,0 +1,135 @@
+package mailer
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SMTPMailer delivers emails through an SMTP server. Authentication is only used when a username is set,
+// so it also works with local SMTP stand-ins such as MailHog or Mailpit.
+type SMTPMailer struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+func NewSMTPMailerFromEnv() *SMTPMailer {
+	port := os.Getenv("SMTP_PORT")
+	if port == "" {
+		port = "587"
+	}
+
+	return &SMTPMailer{
+		Host:     os.Getenv("SMTP_HOST"),
+		Port:     port,
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     fromAddress(),
+	}
+}
+
+func (m *SMTPMailer) Send(message Message) error {
+	from, err := mail.ParseAddress(m.From)
+	if err != nil {
+		return fmt.Errorf("invalid from address: %w", err)
+	}
+
+	body, err := buildMIMEMessage(m.From, message)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+
+	return smtp.SendMail(net.JoinHostPort(m.Host, m.Port), auth, from.Address, message.To, body)
+}
+
+func randomBoundary() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func writeQuotedPrintable(buf *bytes.Buffer, content string) error {
+	w := quotedprintable.NewWriter(buf)
+	if _, err := w.Write([]byte(content)); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// buildMIMEMessage renders the message as an RFC 5322 email, with a multipart/alternative body
+// when both a text and an HTML version are given.
+func buildMIMEMessage(from string, message Message) ([]byte, error) {
+	var buf bytes.Buffer
+
+	headers := map[string]string{
+		"From":         from,
+		"To":           strings.Join(message.To, ", "),
+		"Subject":      mime.QEncoding.Encode("utf-8", message.Subject),
+		"Date":         time.Now().Format(time.RFC1123Z),
+		"MIME-Version": "1.0",
+	}
+	for key, value := range message.Headers {
+		headers[key] = value
+	}
+
+	keys := make([]string, 0, len(headers))
+	for key := range headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(&buf, "%s: %s\r\n", key, headers[key])
+	}
+
+	switch {
+	case message.Text != "" && message.HTML != "":
+		boundary := randomBoundary()
+		fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", boundary)
+
+		parts := []struct{ contentType, content string }{
+			{"text/plain", message.Text},
+			{"text/html", message.HTML},
+		}
+		for _, part := range parts {
+			fmt.Fprintf(&buf, "--%s\r\n", boundary)
+			fmt.Fprintf(&buf, "Content-Type: %s; charset=utf-8\r\nContent-Transfer-Encoding: quoted-printable\r\n\r\n", part.contentType)
+			if err := writeQuotedPrintable(&buf, part.content); err != nil {
+				return nil, err
+			}
+			buf.WriteString("\r\n")
+		}
+		fmt.Fprintf(&buf, "--%s--\r\n", boundary)
+
+	case message.HTML != "":
+		buf.WriteString("Content-Type: text/html; charset=utf-8\r\nContent-Transfer-Encoding: quoted-printable\r\n\r\n")
+		if err := writeQuotedPrintable(&buf, message.HTML); err != nil {
+			return nil, err
+		}
+
+	default:
+		buf.WriteString("Content-Type: text/plain; charset=utf-8\r\nContent-Transfer-Encoding: quoted-printable\r\n\r\n")
+		if err := writeQuotedPrintable(&buf, message.Text); err != nil {
+			return nil, err
+		}
+	}
+
+	return buf.Bytes(), nil
+}
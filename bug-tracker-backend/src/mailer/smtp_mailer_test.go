@@ -0,0 +1,182 @@
+package mailer
+
+import (
+	"bufio"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+// smtpEnvelope is what the local SMTP listener received.
+type smtpEnvelope struct {
+	from string
+	to   []string
+	data string
+}
+
+// listenSMTP starts an SMTP server on a local port that accepts one email and sends it on the returned channel.
+func listenSMTP(t *testing.T) (string, <-chan smtpEnvelope) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	received := make(chan smtpEnvelope, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		reader := bufio.NewReader(conn)
+		reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+
+		var envelope smtpEnvelope
+		reply("220 localhost ESMTP")
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			command := strings.TrimSpace(line)
+
+			switch verb := strings.ToUpper(strings.SplitN(command, " ", 2)[0]); verb {
+			case "EHLO", "HELO":
+				reply("250 localhost")
+			case "MAIL":
+				envelope.from = strings.Trim(strings.TrimPrefix(command, "MAIL FROM:"), "<>")
+				reply("250 OK")
+			case "RCPT":
+				envelope.to = append(envelope.to, strings.Trim(strings.TrimPrefix(command, "RCPT TO:"), "<>"))
+				reply("250 OK")
+			case "DATA":
+				reply("354 End data with <CR><LF>.<CR><LF>")
+				var data strings.Builder
+				for {
+					line, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if line == ".\r\n" {
+						break
+					}
+					data.WriteString(strings.TrimPrefix(line, "."))
+				}
+				envelope.data = data.String()
+				reply("250 OK")
+			case "QUIT":
+				reply("221 Bye")
+				received <- envelope
+				return
+			default:
+				reply("502 Command not implemented")
+			}
+		}
+	}()
+
+	return listener.Addr().String(), received
+}
+
+func TestSMTPMailerSend(t *testing.T) {
+	addr, received := listenSMTP(t)
+	host, port, _ := net.SplitHostPort(addr)
+
+	m := &SMTPMailer{Host: host, Port: port, From: "Bug Tracker <noreply@example.com>"}
+	err := m.Send(Message{
+		To:      []string{"a@example.com", "b@example.com"},
+		Subject: "Réinitialiser",
+		Text:    "Hi,\nopen the link.",
+		HTML:    "<p>Hi,</p><p>open the link.</p>",
+		Headers: map[string]string{"List-Unsubscribe": "<https://example.com/unsubscribe>"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	envelope := <-received
+	if envelope.from != "noreply@example.com" {
+		t.Errorf("envelope sender = %q", envelope.from)
+	}
+	if strings.Join(envelope.to, ",") != "a@example.com,b@example.com" {
+		t.Errorf("envelope recipients = %q", envelope.to)
+	}
+
+	email, err := mail.ReadMessage(strings.NewReader(envelope.data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]string{
+		"From":             "Bug Tracker <noreply@example.com>",
+		"To":               "a@example.com, b@example.com",
+		"List-Unsubscribe": "<https://example.com/unsubscribe>",
+	} {
+		if got := email.Header.Get(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	if subject, _ := new(mime.WordDecoder).DecodeHeader(email.Header.Get("Subject")); subject != "Réinitialiser" {
+		t.Errorf("Subject = %q, want %q", subject, "Réinitialiser")
+	}
+
+	mediaType, params, err := mime.ParseMediaType(email.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q", email.Header.Get("Content-Type"))
+	}
+
+	parts := multipart.NewReader(email.Body, params["boundary"])
+	for _, want := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", "Hi,\r\nopen the link."}, // Line breaks are sent as CRLF
+		{"text/html; charset=utf-8", "<p>Hi,</p><p>open the link.</p>"},
+	} {
+		part, err := parts.NextPart()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The reader decodes the quoted-printable content
+		content, _ := io.ReadAll(part)
+		if got := part.Header.Get("Content-Type"); got != want.contentType {
+			t.Errorf("part Content-Type = %q, want %q", got, want.contentType)
+		}
+		if string(content) != want.content {
+			t.Errorf("part content = %q, want %q", content, want.content)
+		}
+	}
+}
+
+func TestSMTPMailerRejectsInvalidFromAddress(t *testing.T) {
+	m := &SMTPMailer{Host: "127.0.0.1", Port: "1", From: "not an address"}
+	if err := m.Send(Message{To: []string{"a@example.com"}, Text: "Hi"}); err == nil {
+		t.Error("email was sent from an invalid address")
+	}
+}
+
+func TestMemoryMailerKeepsMessagesUntilReset(t *testing.T) {
+	m := NewMemoryMailer()
+	m.Send(Message{To: []string{"a@example.com"}, Subject: "First"})
+	m.Send(Message{To: []string{"b@example.com"}, Subject: "Second"})
+
+	messages := m.Messages()
+	if len(messages) != 2 || messages[0].Subject != "First" || messages[1].Subject != "Second" {
+		t.Fatalf("messages = %+v", messages)
+	}
+
+	// The returned slice is a copy
+	messages[0].Subject = "Changed"
+	if m.Messages()[0].Subject != "First" {
+		t.Error("messages can be changed through the returned slice")
+	}
+
+	m.Reset()
+	if len(m.Messages()) != 0 {
+		t.Error("messages were kept after a reset")
+	}
+}
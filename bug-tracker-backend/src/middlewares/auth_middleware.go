@@ -4,18 +4,21 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/conf"
+	api "github.com/WNBARookie/BugTracker/bug-tracker-backend/src/types"
 	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/utils"
 )
 
 func RequireAuth(c *gin.Context) {
 	// Extract the token from the Authorization header
-	tokenString := strings.Split(c.GetHeader("Authorization"), " ")[1]
+	tokenString, _ := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
 
 	if tokenString == "" {
 		c.JSON(http.StatusUnauthorized, gin.H{"message": "Authorization header is required"})
@@ -23,6 +26,11 @@ func RequireAuth(c *gin.Context) {
 		return
 	}
 
+	if strings.HasPrefix(tokenString, utils.PersonalAccessTokenPrefix) {
+		requirePersonalAccessToken(c, tokenString)
+		return
+	}
+
 	// Validate the token using the JWT secret
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (any, error) {
 		return []byte(os.Getenv("JWT_SECRET")), nil
@@ -35,7 +43,8 @@ func RequireAuth(c *gin.Context) {
 	}
 
 	if claims, ok := token.Claims.(jwt.MapClaims); ok {
-		if float64(time.Now().Unix()) > claims["exp"].(float64) {
+		exp, ok := claims["exp"].(float64)
+		if !ok || float64(time.Now().Unix()) > exp {
 			c.JSON(http.StatusUnauthorized, gin.H{"message": "Token has expired"})
 			c.Abort()
 			return
@@ -49,6 +58,35 @@ func RequireAuth(c *gin.Context) {
 			return
 		}
 
+		// Only access tokens can be used to authenticate, not two-factor challenge tokens
+		if claims["typ"] != utils.TokenTypeAccess {
+			log.Println("Invalid token type")
+			c.JSON(http.StatusUnauthorized, gin.H{"message": "Failed to authorize token"})
+			c.Abort()
+			return
+		}
+
+		// Tokens without an ID cannot be revoked, so they are not accepted
+		jti, ok := claims["jti"].(string)
+		if !ok || jti == "" {
+			log.Println("Missing token ID in token")
+			c.JSON(http.StatusUnauthorized, gin.H{"message": "Failed to authorize token"})
+			c.Abort()
+			return
+		}
+
+		revoked, err := utils.IsAccessTokenRevoked(jti)
+		if err != nil {
+			log.Printf("Error checking token revocation: %v", err)
+			c.JSON(http.StatusUnauthorized, gin.H{"message": "Failed to authorize token"})
+			c.Abort()
+			return
+		} else if revoked {
+			c.JSON(http.StatusUnauthorized, gin.H{"message": "Token has been revoked"})
+			c.Abort()
+			return
+		}
+
 		user, _ := utils.LookupUserUsingID(uint(subFloat))
 		if user == nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"message": "User not found"})
@@ -56,8 +94,19 @@ func RequireAuth(c *gin.Context) {
 			return
 		}
 
-		// Set the user in the context for further use
+		// Tokens issued before the password was reset may be held by whoever made the reset necessary. The claim only
+		// has a precision of a second, so the tokens issued during the second of the reset are rejected too
+		iat, ok := claims["iat"].(float64)
+		if user.PasswordResetAt != nil && (!ok || int64(iat) <= user.PasswordResetAt.Unix()) {
+			c.JSON(http.StatusUnauthorized, gin.H{"message": "Token has been revoked"})
+			c.Abort()
+			return
+		}
+
+		// Set the user and the token in the context for further use
 		c.Set("user", *user)
+		c.Set("tokenID", jti)
+		c.Set("tokenExpiresAt", time.Unix(int64(exp), 0))
 
 		// Proceed with the request
 		c.Next()
@@ -68,3 +117,107 @@ func RequireAuth(c *gin.Context) {
 		return
 	}
 }
+
+// RequireStreamTicket authenticates the event stream of a project with the ticket in the query, since EventSource
+// cannot set the Authorization header. The ticket only opens the stream of the project it was requested for.
+func RequireStreamTicket(c *gin.Context) {
+	ticket, ok := utils.VerifyStreamTicket(c.Query("ticket"))
+	projectID, err := strconv.ParseUint(c.Param("projectID"), 10, 64)
+	if !ok || err != nil || uint(projectID) != ticket.ProjectID {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid or expired stream ticket"})
+		c.Abort()
+		return
+	}
+
+	if ticket.TokenID != "" {
+		revoked, err := utils.IsAccessTokenRevoked(ticket.TokenID)
+		if err != nil {
+			log.Printf("Error checking token revocation: %v", err)
+			c.JSON(http.StatusUnauthorized, gin.H{"message": "Failed to authorize token"})
+			c.Abort()
+			return
+		} else if revoked {
+			c.JSON(http.StatusUnauthorized, gin.H{"message": "Token has been revoked"})
+			c.Abort()
+			return
+		}
+	}
+
+	user, _ := utils.LookupUserUsingID(ticket.UserID)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "User not found"})
+		c.Abort()
+		return
+	}
+
+	// Set the user and the access token the ticket was requested with in the context for further use
+	c.Set("user", *user)
+	if ticket.TokenID != "" {
+		c.Set("tokenID", ticket.TokenID)
+		c.Set("tokenExpiresAt", time.Unix(ticket.SessionExpiresAt, 0))
+	}
+
+	c.Next()
+}
+
+func requirePersonalAccessToken(c *gin.Context, tokenString string) {
+	accessToken, _ := utils.LookupPersonalAccessToken(tokenString)
+	if accessToken == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid or expired access token"})
+		c.Abort()
+		return
+	}
+
+	user, _ := utils.LookupUserUsingID(accessToken.UserID)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "User not found"})
+		c.Abort()
+		return
+	}
+
+	if err := utils.TouchPersonalAccessToken(accessToken); err != nil {
+		log.Printf("Error recording access token use: %v", err)
+	}
+
+	// Set the user and the scopes of the token in the context for further use
+	c.Set("user", *user)
+	c.Set("tokenScopes", []string(accessToken.Scopes))
+
+	c.Next()
+}
+
+// RequireScope only lets requests authenticated with a personal access token through if the token has the scope.
+// Requests authenticated with a login session are not restricted by scopes.
+func RequireScope(scope api.TokenScope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scopes, isAccessToken := utils.ExtractTokenScopesFromContext(c)
+		if !isAccessToken {
+			c.Next()
+			return
+		}
+
+		for _, s := range scopes {
+			if s == scope.Value() {
+				c.Next()
+				return
+			}
+		}
+
+		ec := conf.EnhancedContext{Context: c}
+		ec.ForbiddenWithMessage(api.ScopeDeniedMessage, api.ScopeDeniedResponse{Scope: scope})
+		c.Abort()
+	}
+}
+
+// RequireSession rejects requests authenticated with a personal access token, for sensitive actions
+// such as managing the tokens themselves.
+func RequireSession(c *gin.Context) {
+	if _, isAccessToken := utils.ExtractTokenScopesFromContext(c); isAccessToken {
+		ec := conf.EnhancedContext{Context: c}
+		ec.ForbiddenWithMessageAndNoData("This action cannot be performed with a personal access token")
+		c.Abort()
+		return
+	}
+
+	c.Next()
+}
@@ -0,0 +1,25 @@
+package middlewares
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireCronSecret only lets the scheduler of the deployment through, such as Vercel Cron, which sends the
+// CRON_SECRET environment variable as a bearer token. The cron endpoints are closed while the secret is not set.
+func RequireCronSecret(c *gin.Context) {
+	secret := os.Getenv("CRON_SECRET")
+	token, _ := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+
+	if secret == "" || subtle.ConstantTimeCompare([]byte(token), []byte(secret)) != 1 {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid cron secret"})
+		c.Abort()
+		return
+	}
+
+	c.Next()
+}
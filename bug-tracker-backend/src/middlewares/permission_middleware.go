@@ -0,0 +1,29 @@
+package middlewares
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/conf"
+	api "github.com/WNBARookie/BugTracker/bug-tracker-backend/src/types"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/utils"
+)
+
+// RequirePermission only lets the request through if the user's role in the project allows the action.
+// It must be used after ProjectCheckMiddleware, which sets the user's role in the context.
+func RequirePermission(permission api.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := api.TeamRole(utils.ExtractUserRoleFromContext(c))
+
+		if !role.HasPermission(permission) {
+			ec := conf.EnhancedContext{Context: c}
+			ec.ForbiddenWithMessage(api.PermissionDeniedMessage, api.PermissionDeniedResponse{
+				Permission: permission,
+				Role:       role,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
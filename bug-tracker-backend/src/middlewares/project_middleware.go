@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 
 	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/conf"
 	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/models"
@@ -52,8 +53,18 @@ func BugCheckMiddleware(c *gin.Context) {
 		return
 	}
 
+	// Only bugs that belong to the project in the path are accessible
+	project := utils.ExtractProjectFromContext(c)
+
+	query, ok := utils.WhereBugReference(conf.DB, project, bugURI.BugID)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid bug ID"})
+		c.Abort()
+		return
+	}
+
 	var bug models.Bug
-	if err := conf.DB.First(&bug, bugURI.BugID).Error; err != nil {
+	if err := query.First(&bug).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"message": "Bug not found"})
 		c.Abort()
 		return
@@ -62,3 +73,137 @@ func BugCheckMiddleware(c *gin.Context) {
 	c.Set("bug", bug)
 	c.Next()
 }
+
+func TrashedBugCheckMiddleware(c *gin.Context) {
+	var bugURI api.BugURI
+	if err := c.ShouldBindUri(&bugURI); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid bug ID"})
+		c.Abort()
+		return
+	}
+
+	project := utils.ExtractProjectFromContext(c)
+
+	query, ok := utils.WhereBugReference(conf.DB.Unscoped(), project, bugURI.BugID)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid bug ID"})
+		c.Abort()
+		return
+	}
+
+	var bug models.Bug
+	if err := query.Where("deleted_at IS NOT NULL").First(&bug).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Bug not found in trash"})
+		c.Abort()
+		return
+	}
+
+	c.Set("bug", bug)
+	c.Next()
+}
+
+func CommentCheckMiddleware(c *gin.Context) {
+	var commentURI api.CommentURI
+	if err := c.ShouldBindUri(&commentURI); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid comment ID"})
+		c.Abort()
+		return
+	}
+
+	bug := utils.ExtractBugFromContext(c)
+
+	var comment models.Comment
+	if err := conf.DB.Where("bug_id = ?", bug.ID).First(&comment, commentURI.CommentID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Comment not found"})
+		c.Abort()
+		return
+	}
+
+	c.Set("comment", comment)
+	c.Next()
+}
+
+func AttachmentCheckMiddleware(c *gin.Context) {
+	var attachmentURI api.AttachmentURI
+	if err := c.ShouldBindUri(&attachmentURI); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid attachment ID"})
+		c.Abort()
+		return
+	}
+
+	bug := utils.ExtractBugFromContext(c)
+
+	var attachment models.Attachment
+	if err := conf.DB.Joins("Blob").Preload("Uploader", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Where("attachments.bug_id = ?", bug.ID).First(&attachment, attachmentURI.AttachmentID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Attachment not found"})
+		c.Abort()
+		return
+	}
+
+	c.Set("attachment", attachment)
+	c.Next()
+}
+
+func MilestoneCheckMiddleware(c *gin.Context) {
+	var milestoneURI api.MilestoneURI
+	if err := c.ShouldBindUri(&milestoneURI); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid milestone ID"})
+		c.Abort()
+		return
+	}
+
+	project := utils.ExtractProjectFromContext(c)
+
+	var milestone models.Milestone
+	if err := conf.DB.Where("project_id = ?", project.ID).First(&milestone, milestoneURI.MilestoneID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Milestone not found"})
+		c.Abort()
+		return
+	}
+
+	c.Set("milestone", milestone)
+	c.Next()
+}
+
+func CustomFieldCheckMiddleware(c *gin.Context) {
+	var fieldURI api.CustomFieldURI
+	if err := c.ShouldBindUri(&fieldURI); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid custom field ID"})
+		c.Abort()
+		return
+	}
+
+	project := utils.ExtractProjectFromContext(c)
+
+	var field models.CustomField
+	if err := conf.DB.Where("project_id = ?", project.ID).First(&field, fieldURI.FieldID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Custom field not found"})
+		c.Abort()
+		return
+	}
+
+	c.Set("customField", field)
+	c.Next()
+}
+
+func WebhookCheckMiddleware(c *gin.Context) {
+	var webhookURI api.WebhookURI
+	if err := c.ShouldBindUri(&webhookURI); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid webhook ID"})
+		c.Abort()
+		return
+	}
+
+	project := utils.ExtractProjectFromContext(c)
+
+	var webhook models.Webhook
+	if err := conf.DB.Where("project_id = ?", project.ID).First(&webhook, webhookURI.WebhookID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Webhook not found"})
+		c.Abort()
+		return
+	}
+
+	c.Set("webhook", webhook)
+	c.Next()
+}
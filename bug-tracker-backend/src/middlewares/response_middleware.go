@@ -22,6 +22,11 @@ func StandardResponseMiddleware(c *gin.Context) {
 	// Process the request
 	c.Next()
 
+	// Streamed responses were already sent as they were written
+	if w.Streaming {
+		return
+	}
+
 	contentType := c.Writer.Header().Get("Content-Type")
 	isJSONResponse := strings.Contains(contentType, "application/json")
 
@@ -147,12 +152,7 @@ func StandardResponseMiddleware(c *gin.Context) {
 		w.ResponseWriter.WriteHeader(statusCode)
 		w.ResponseWriter.Write(finalResponse)
 	} else if len(responseBody) > 0 {
-		// Non-JSON response, pass through as-is
-		for key, values := range w.Header() {
-			for _, value := range values {
-				w.ResponseWriter.Header().Add(key, value)
-			}
-		}
+		// Non-JSON response, pass through as-is, the headers are already set on the underlying writer
 		w.ResponseWriter.WriteHeader(statusCode)
 		w.ResponseWriter.Write(responseBody)
 	} else {
@@ -10,9 +10,13 @@ import (
 type Project struct {
 	gorm.Model
 	Title       string `json:"title" gorm:"not null;type:varchar(100)"`
+	Key         string `json:"key" gorm:"not null;uniqueIndex;type:varchar(10)"` // Prefix of the keys of the bugs of the project, such as PROJ in PROJ-42
+	BugSequence uint   `json:"-" gorm:"not null;default:0"`                      // Number given to the last bug created in the project
 	Description string `json:"description"`
 	CreatedBy   uint   `json:"created_by" gorm:"not null"`
 	User        User   `json:"-" gorm:"foreignKey:CreatedBy;references:ID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"` // User who created the project
+
+	AllowCloseWithOpenChildren bool `json:"allow_close_with_open_children" gorm:"not null;default:false"` // Lets bugs be closed while some of their sub-tasks are still open
 }
 
 type Team struct {
@@ -30,10 +34,195 @@ type Bug struct {
 	Description  string         `json:"description"`
 	Tags         pq.StringArray `json:"tags" gorm:"type:varchar[]"`
 	Deadline     time.Time      `json:"deadline" gorm:"not null"`
-	Status       string         `json:"status" gorm:"not null;default:'todo'"` // todo, in_progress, done
+	Status       string         `json:"status" gorm:"not null;default:'todo'"` // Key of a state of the project workflow, such as todo, in_progress or done
 	Priority     uint           `json:"priority" gorm:"not null"`              // 1: High, 2: Medium, 3: Low
 	AssignedTo   uint           `json:"assigned_to" gorm:"not null"`
 	AssignedUser User           `json:"-" gorm:"foreignKey:AssignedTo;references:ID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"` // User to whom the bug is assigned
-	ProjectID    uint           `json:"project_id" gorm:"not null"`
+	ProjectID    uint           `json:"project_id" gorm:"not null;uniqueIndex:idx_project_bug_number"`
 	Project      Project        `json:"-" gorm:"foreignKey:ProjectID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"` // Project associated with the bug
+	Number       uint           `json:"number" gorm:"not null;uniqueIndex:idx_project_bug_number"`                                 // Position of the bug in its project, shown as KEY-N
+	ParentID     *uint          `json:"parent_id" gorm:"index"`
+	Parent       *Bug           `json:"-" gorm:"foreignKey:ParentID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"` // Bug this one is a sub-task of
+	MilestoneID  *uint          `json:"milestone_id" gorm:"index"`
+	Milestone    *Milestone     `json:"-" gorm:"foreignKey:MilestoneID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"` // Milestone the bug is planned for
+	CustomFields JSONMap        `json:"custom_fields" gorm:"type:jsonb;not null;default:'{}';index:idx_bug_custom_fields,type:gin"`   // Values of the custom fields of the project, by field key
+
+	DeadlineNotifiedAt *time.Time `json:"-"` // When watchers were told the deadline is approaching, cleared when the deadline changes
+}
+
+type Milestone struct {
+	gorm.Model
+	ProjectID   uint      `json:"project_id" gorm:"not null;index"`
+	Project     Project   `json:"-" gorm:"foreignKey:ProjectID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"` // Project the milestone belongs to
+	Title       string    `json:"title" gorm:"not null;type:varchar(100)"`
+	Description string    `json:"description"`
+	StartDate   time.Time `json:"start_date" gorm:"not null"`
+	EndDate     time.Time `json:"end_date" gorm:"not null"`
+	State       string    `json:"state" gorm:"not null;default:'planned'"` // planned, active, completed
+}
+
+type BugHistory struct {
+	gorm.Model
+	BugID     uint   `json:"bug_id" gorm:"not null;index"`
+	Bug       Bug    `json:"-" gorm:"foreignKey:BugID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"` // Bug the change was made to
+	ChangedBy uint   `json:"changed_by" gorm:"not null"`
+	User      User   `json:"-" gorm:"foreignKey:ChangedBy;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"` // User who made the change
+	Field     string `json:"field" gorm:"not null;type:varchar(50)"`
+	OldValue  string `json:"old_value"`
+	NewValue  string `json:"new_value"`
+}
+
+type Invitation struct {
+	gorm.Model
+	ProjectID   uint       `json:"project_id" gorm:"not null;index"`
+	Project     Project    `json:"-" gorm:"foreignKey:ProjectID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"` // Project the user is invited to
+	Email       string     `json:"email" gorm:"not null;index;type:varchar(100)"`
+	Role        string     `json:"role" gorm:"not null"`
+	InvitedBy   uint       `json:"invited_by" gorm:"not null"`
+	Inviter     User       `json:"-" gorm:"foreignKey:InvitedBy;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"` // User who sent the invitation
+	TokenHash   string     `json:"-" gorm:"unique;not null"`
+	Status      string     `json:"status" gorm:"not null;default:'pending'"` // pending, accepted, declined, revoked
+	ExpiresAt   time.Time  `json:"expires_at" gorm:"not null"`
+	RespondedAt *time.Time `json:"responded_at"`
+}
+
+type Comment struct {
+	gorm.Model
+	BugID    uint       `json:"bug_id" gorm:"not null;index"`
+	Bug      Bug        `json:"-" gorm:"foreignKey:BugID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"` // Bug the comment is posted on
+	AuthorID uint       `json:"author_id" gorm:"not null"`
+	Author   User       `json:"-" gorm:"foreignKey:AuthorID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"` // User who wrote the comment
+	ParentID *uint      `json:"parent_id" gorm:"index"`
+	Parent   *Comment   `json:"-" gorm:"foreignKey:ParentID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"` // Comment this one replies to
+	Body     string     `json:"body" gorm:"not null;type:text"`                                                           // Raw Markdown
+	EditedAt *time.Time `json:"edited_at"`
+}
+
+type CommentRevision struct {
+	gorm.Model
+	CommentID uint    `json:"comment_id" gorm:"not null;index"`
+	Comment   Comment `json:"-" gorm:"foreignKey:CommentID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"` // Comment the revision belongs to
+	Body      string  `json:"body" gorm:"not null;type:text"`                                                            // Body of the comment before the edit
+}
+
+type AttachmentBlob struct {
+	gorm.Model
+	Checksum    string `json:"checksum" gorm:"unique;not null;type:varchar(64)"` // SHA-256 of the content, identical files are only stored once
+	Size        int64  `json:"size" gorm:"not null"`
+	ContentType string `json:"content_type" gorm:"not null"`
+	StorageKey  string `json:"-" gorm:"not null"`
+}
+
+type Attachment struct {
+	gorm.Model
+	BugID      uint           `json:"bug_id" gorm:"not null;index"`
+	Bug        Bug            `json:"-" gorm:"foreignKey:BugID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"` // Bug the file is attached to
+	UploadedBy uint           `json:"uploaded_by" gorm:"not null"`
+	Uploader   User           `json:"-" gorm:"foreignKey:UploadedBy;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"` // User who uploaded the file
+	BlobID     uint           `json:"blob_id" gorm:"not null;index"`
+	Blob       AttachmentBlob `json:"-" gorm:"foreignKey:BlobID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"` // Stored content of the file
+	Filename   string         `json:"filename" gorm:"not null;type:varchar(255)"`
+}
+
+type WorkflowState struct {
+	gorm.Model
+	ProjectID uint    `json:"project_id" gorm:"not null;uniqueIndex:idx_project_workflow_state"`
+	Project   Project `json:"-" gorm:"foreignKey:ProjectID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"` // Project the workflow belongs to
+	Key       string  `json:"key" gorm:"not null;uniqueIndex:idx_project_workflow_state;type:varchar(50)"`               // Value stored in Bug.Status
+	Name      string  `json:"name" gorm:"not null;type:varchar(100)"`
+	Category  string  `json:"category" gorm:"not null"` // open, active, closed
+	Position  int     `json:"position" gorm:"not null"`
+	Initial   bool    `json:"initial" gorm:"not null;default:false"` // State new bugs start in
+}
+
+type WorkflowTransition struct {
+	gorm.Model
+	ProjectID uint           `json:"project_id" gorm:"not null;uniqueIndex:idx_project_workflow_transition"`
+	Project   Project        `json:"-" gorm:"foreignKey:ProjectID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"` // Project the workflow belongs to
+	FromState string         `json:"from_state" gorm:"not null;uniqueIndex:idx_project_workflow_transition;type:varchar(50)"`
+	ToState   string         `json:"to_state" gorm:"not null;uniqueIndex:idx_project_workflow_transition;type:varchar(50)"`
+	Roles     pq.StringArray `json:"roles" gorm:"type:varchar[];not null"` // Team roles allowed to move bugs along the transition
+}
+
+type BugLink struct {
+	gorm.Model
+	SourceID  uint   `json:"source_id" gorm:"not null;uniqueIndex:idx_bug_link"`
+	Source    Bug    `json:"-" gorm:"foreignKey:SourceID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"` // Bug the relation is stated from, such as the duplicate or the blocker
+	TargetID  uint   `json:"target_id" gorm:"not null;uniqueIndex:idx_bug_link;index"`
+	Target    Bug    `json:"-" gorm:"foreignKey:TargetID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"` // Bug the relation points to, such as the original or the blocked bug
+	Type      string `json:"type" gorm:"not null;uniqueIndex:idx_bug_link;type:varchar(20)"`                           // duplicates, blocks, relates_to
+	CreatedBy uint   `json:"created_by" gorm:"not null"`
+	Creator   User   `json:"-" gorm:"foreignKey:CreatedBy;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"` // User who linked the bugs
+}
+
+type CustomField struct {
+	gorm.Model
+	ProjectID uint           `json:"project_id" gorm:"not null;uniqueIndex:idx_project_custom_field"`
+	Project   Project        `json:"-" gorm:"foreignKey:ProjectID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"` // Project the field is defined for
+	Key       string         `json:"key" gorm:"not null;uniqueIndex:idx_project_custom_field;type:varchar(40)"`                 // Key of the value in Bug.CustomFields
+	Name      string         `json:"name" gorm:"not null;type:varchar(100)"`
+	Type      string         `json:"type" gorm:"not null;type:varchar(20)"` // text, number, date, select, multi_select, user
+	Options   pq.StringArray `json:"options" gorm:"type:varchar[]"`         // Values allowed by select and multi_select fields
+	Required  bool           `json:"required" gorm:"not null;default:false"`
+	Position  int            `json:"position" gorm:"not null"`
+}
+
+type Watch struct {
+	gorm.Model
+	UserID    uint    `json:"user_id" gorm:"not null;uniqueIndex:idx_bug_watch,where:bug_id IS NOT NULL;uniqueIndex:idx_project_watch,where:bug_id IS NULL"`
+	User      User    `json:"-" gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"` // User who receives the notifications
+	ProjectID uint    `json:"project_id" gorm:"not null;uniqueIndex:idx_project_watch;index"`
+	Project   Project `json:"-" gorm:"foreignKey:ProjectID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"` // Project watched, or project of the watched bug
+	BugID     *uint   `json:"bug_id" gorm:"uniqueIndex:idx_bug_watch;index"`                                             // Not set when the whole project is watched
+	Bug       *Bug    `json:"-" gorm:"foreignKey:BugID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`     // Bug watched
+	Reason    string  `json:"reason" gorm:"not null;type:varchar(20)"`                                                   // manual, created, assigned, commented
+	Muted     bool    `json:"muted" gorm:"not null;default:false"`                                                       // Stops the notifications of the bug for a user who watches its project
+}
+
+type Notification struct {
+	gorm.Model
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	User      User       `json:"-" gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"` // User the notification is for
+	Event     string     `json:"event" gorm:"not null;type:varchar(50)"`                                                 // Type of the event that raised the notification
+	ProjectID uint       `json:"project_id" gorm:"not null"`
+	Project   Project    `json:"-" gorm:"foreignKey:ProjectID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"` // Project the event happened in
+	BugID     *uint      `json:"bug_id"`
+	Bug       *Bug       `json:"-" gorm:"foreignKey:BugID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"` // Bug the event is about
+	ActorID   *uint      `json:"actor_id"`
+	Actor     *User      `json:"-" gorm:"foreignKey:ActorID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"` // User who caused the event, not set for the events of the server
+	Title     string     `json:"title" gorm:"not null;type:varchar(255)"`
+	Body      string     `json:"body"`
+	ReadAt    *time.Time `json:"read_at" gorm:"index"`
+}
+
+type Webhook struct {
+	gorm.Model
+	ProjectID      uint           `json:"project_id" gorm:"not null;index"`
+	Project        Project        `json:"-" gorm:"foreignKey:ProjectID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"` // Project whose events are sent
+	URL            string         `json:"url" gorm:"not null;type:varchar(2048)"`
+	Secret         string         `json:"-" gorm:"not null"`                     // Key of the HMAC signature of the payloads, kept in clear to sign them
+	Events         pq.StringArray `json:"events" gorm:"type:varchar[];not null"` // Types of event sent to the URL
+	Active         bool           `json:"active" gorm:"not null;default:true"`
+	FailureCount   int            `json:"failure_count" gorm:"not null;default:0"` // Deliveries failed in a row, reset by a successful one
+	DisabledReason string         `json:"disabled_reason"`                         // Set when the webhook was disabled because of its failures
+	CreatedBy      uint           `json:"created_by" gorm:"not null"`
+}
+
+type WebhookDelivery struct {
+	gorm.Model
+	WebhookID      uint             `json:"webhook_id" gorm:"not null;index"`
+	Webhook        Webhook          `json:"-" gorm:"foreignKey:WebhookID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"` // Webhook the payload is sent to
+	EventID        string           `json:"event_id" gorm:"not null;type:varchar(64);index"`                                           // Shared by the deliveries of the same event, redeliveries included
+	Event          string           `json:"event" gorm:"not null;type:varchar(50)"`
+	Payload        string           `json:"payload" gorm:"not null"`                        // Body sent as is, redeliveries included
+	Status         string           `json:"status" gorm:"not null;default:'pending';index"` // pending, succeeded, failed
+	Attempts       int              `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt  *time.Time       `json:"next_attempt_at" gorm:"index"` // Not set once the delivery succeeded or failed for good
+	ResponseStatus *int             `json:"response_status"`
+	ResponseBody   string           `json:"response_body"` // Start of the body of the last response
+	Error          string           `json:"error"`         // Why the last attempt failed
+	DurationMs     int64            `json:"duration_ms"`
+	DeliveredAt    *time.Time       `json:"delivered_at"`
+	RedeliveryOfID *uint            `json:"redelivery_of_id"`
+	RedeliveryOf   *WebhookDelivery `json:"-" gorm:"foreignKey:RedeliveryOfID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"` // Delivery sent again
 }
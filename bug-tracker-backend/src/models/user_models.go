@@ -1,14 +1,95 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
 
 type User struct {
 	gorm.Model
-	Name     string    `json:"name" gorm:"not null;type:varchar(100)"`
-	Username string    `json:"username" gorm:"unique;not null;type:varchar(100)"`
-	Email    string    `json:"email" gorm:"unique;not null;type:varchar(100)"`
-	Password string    `json:"password" gorm:"not null"`
-	Projects []Project `json:"projects" gorm:"foreignKey:CreatedBy;references:ID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"` // Projects created by the user
-	Teams    []Team    `json:"teams" gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`        // Teams the user is part of
-	Bugs     []Bug     `json:"bugs" gorm:"foreignKey:AssignedTo;references:ID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`    // Bugs assigned to the user
+	Name             string     `json:"name" gorm:"not null;type:varchar(100)"`
+	Username         string     `json:"username" gorm:"unique;not null;type:varchar(100)"`
+	Email            string     `json:"email" gorm:"unique;not null;type:varchar(100)"`
+	Password         string     `json:"password" gorm:"not null"`
+	EmailVerifiedAt  *time.Time `json:"email_verified_at"`
+	TOTPSecret       string     `json:"-"` // Set on enrollment, only active once TOTPEnabledAt is set
+	TOTPEnabledAt    *time.Time `json:"totp_enabled_at"`
+	TOTPLastUsedStep int64      `json:"-"`                                                                                                 // Time step of the last accepted code, to prevent replays
+	PasswordResetAt  *time.Time `json:"-"`                                                                                                 // Access tokens issued until then are no longer accepted
+	Projects         []Project  `json:"projects" gorm:"foreignKey:CreatedBy;references:ID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"` // Projects created by the user
+	Teams            []Team     `json:"teams" gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`        // Teams the user is part of
+	Bugs             []Bug      `json:"bugs" gorm:"foreignKey:AssignedTo;references:ID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`    // Bugs assigned to the user
+}
+
+type RefreshToken struct {
+	gorm.Model
+	UserID       uint       `json:"user_id" gorm:"not null;index"`
+	User         User       `json:"-" gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"` // User the token was issued to
+	TokenHash    string     `json:"-" gorm:"unique;not null"`
+	FamilyID     string     `json:"family_id" gorm:"not null;index;type:varchar(64)"` // Shared by every token rotated from the same login
+	ExpiresAt    time.Time  `json:"expires_at" gorm:"not null"`
+	RevokedAt    *time.Time `json:"revoked_at"`
+	ReplacedByID *uint      `json:"replaced_by_id"` // Token issued when this one was rotated
+}
+
+type RevokedToken struct {
+	gorm.Model
+	JTI       string    `json:"jti" gorm:"unique;not null;type:varchar(64)"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null;index"` // The row is no longer needed once the access token has expired
+}
+
+type UserToken struct {
+	gorm.Model
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	User      User       `json:"-" gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"` // User the token was issued to
+	Purpose   string     `json:"purpose" gorm:"not null;type:varchar(50)"`                                               // email_verification, password_reset, account_unlock
+	TokenHash string     `json:"-" gorm:"unique;not null"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at"`
+}
+
+type PersonalAccessToken struct {
+	gorm.Model
+	UserID     uint           `json:"user_id" gorm:"not null;index"`
+	User       User           `json:"-" gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"` // User the token belongs to
+	Name       string         `json:"name" gorm:"not null;type:varchar(100)"`
+	Prefix     string         `json:"prefix" gorm:"not null;type:varchar(16)"` // Start of the token, to help users recognise it
+	TokenHash  string         `json:"-" gorm:"unique;not null"`
+	Scopes     pq.StringArray `json:"scopes" gorm:"type:varchar[];not null"`
+	ExpiresAt  *time.Time     `json:"expires_at"` // Tokens without an expiry never expire
+	LastUsedAt *time.Time     `json:"last_used_at"`
+}
+
+type RecoveryCode struct {
+	gorm.Model
+	UserID   uint       `json:"user_id" gorm:"not null;index"`
+	User     User       `json:"-" gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"` // User the code belongs to
+	CodeHash string     `json:"-" gorm:"not null"`
+	UsedAt   *time.Time `json:"used_at"`
+}
+
+type LoginAttempt struct {
+	gorm.Model
+	Key           string     `json:"key" gorm:"unique;not null;type:varchar(255)"` // account:<email> or ip:<address>
+	Failures      int        `json:"failures" gorm:"not null;default:0"`
+	LastFailureAt time.Time  `json:"last_failure_at"`
+	LockedUntil   *time.Time `json:"locked_until"`
+}
+
+type NotificationPreference struct {
+	gorm.Model
+	UserID uint   `json:"user_id" gorm:"not null;uniqueIndex:idx_user_notification_event"`
+	User   User   `json:"-" gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"` // User the preference belongs to
+	Event  string `json:"event" gorm:"not null;uniqueIndex:idx_user_notification_event;type:varchar(50)"`
+	InApp  bool   `json:"in_app" gorm:"not null"` // Events without a preference are delivered in the app
+}
+
+type EmailNotificationSetting struct {
+	gorm.Model
+	UserID       uint       `json:"user_id" gorm:"not null;unique"`
+	User         User       `json:"-" gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"` // User the setting belongs to
+	Frequency    string     `json:"frequency" gorm:"not null;type:varchar(20)"`                                             // off, immediate, daily or weekly
+	LastDigestAt *time.Time `json:"last_digest_at"`                                                                         // Start of the period covered by the next digest
 }
@@ -0,0 +1,255 @@
+package notifications
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"log"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	texttemplate "text/template"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/conf"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/events"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/mailer"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/models"
+	types "github.com/WNBARookie/BugTracker/bug-tracker-backend/src/types"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/utils"
+)
+
+const (
+	// DefaultEmailFrequency applies to the users who never chose how often they are emailed.
+	DefaultEmailFrequency = types.EmailFrequencyImmediate
+
+	defaultDigestHour = 8  // in hours UTC
+	digestLimit       = 50 // notifications listed in a digest, the others are only counted
+)
+
+//go:embed templates
+var templateFiles embed.FS
+
+var (
+	htmlTemplates = htmltemplate.Must(htmltemplate.ParseFS(templateFiles, "templates/*.html"))
+	textTemplates = texttemplate.Must(texttemplate.ParseFS(templateFiles, "templates/*.txt"))
+)
+
+type emailData struct {
+	Name            string
+	Title           string
+	Body            string
+	Link            string
+	Frequency       types.EmailFrequency
+	Items           []digestItem
+	More            int
+	UnsubscribeLink string
+}
+
+type digestItem struct {
+	Title     string
+	Body      string
+	Link      string
+	CreatedAt string
+}
+
+// Email sends the event to the users who chose immediate emails, if it is one of the types of event emailed as
+// they happen. The emails are sent before the request that raised the event responds, as serverless deployments
+// may freeze the function, and anything left running in the background with it, once the response is sent.
+func Email(event events.Event) {
+	if conf.DB == nil || !slices.Contains(events.EmailTypes, event.Type) {
+		return
+	}
+
+	if err := emailImmediately(conf.DB, event); err != nil {
+		log.Printf("Error while emailing %s event: %v", event.Type, err)
+	}
+}
+
+func emailImmediately(db *gorm.DB, event events.Event) error {
+	recipients, err := Recipients(db, event)
+	if err != nil || len(recipients) == 0 {
+		return err
+	}
+
+	users, err := usersEmailed(db, recipients, types.EmailFrequencyImmediate)
+	if err != nil || len(users) == 0 {
+		return err
+	}
+
+	title, body, err := Describe(db, event)
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		data := emailData{
+			Name:  user.Name,
+			Title: title,
+			Body:  body,
+			Link:  projectLink(event.ProjectID),
+		}
+		if err := sendEmail(user, title, "notification", data); err != nil {
+			log.Printf("Error while emailing %s event to user %d: %v", event.Type, user.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// usersEmailed returns the users among the given ones who are emailed at the frequency. Addresses that have not been
+// verified are never emailed, as nothing proves they belong to the user.
+func usersEmailed(db *gorm.DB, userIDs []uint, frequency types.EmailFrequency) ([]models.User, error) {
+	var users []models.User
+	err := db.Joins("LEFT JOIN email_notification_settings ON email_notification_settings.user_id = users.id AND email_notification_settings.deleted_at IS NULL").
+		Where("users.id IN ? AND users.email_verified_at IS NOT NULL", userIDs).
+		Where("COALESCE(email_notification_settings.frequency, ?) = ?", DefaultEmailFrequency.Value(), frequency.Value()).
+		Find(&users).Error
+	return users, err
+}
+
+// DigestHour returns the hour of the day, in UTC, at which the digests are sent.
+func DigestHour() int {
+	hour, err := strconv.Atoi(os.Getenv("NOTIFICATION_DIGEST_HOUR"))
+	if err != nil || hour < 0 || hour > 23 {
+		hour = defaultDigestHour
+	}
+	return hour
+}
+
+// lastDigestSlot returns the latest time a digest of the frequency was due: today at the digest hour for the daily
+// digests, or the last Monday at that hour for the weekly ones.
+func lastDigestSlot(frequency types.EmailFrequency, now time.Time, hour int) time.Time {
+	now = now.UTC()
+	slot := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, time.UTC)
+	if slot.After(now) {
+		slot = slot.AddDate(0, 0, -1)
+	}
+
+	if frequency == types.EmailFrequencyWeekly {
+		for slot.Weekday() != time.Monday {
+			slot = slot.AddDate(0, 0, -1)
+		}
+	}
+
+	return slot
+}
+
+// SendDigests emails every user whose daily or weekly digest is due the unread notifications they received since
+// their previous digest. The digests are made of the in-app notifications, so the types of event turned off in the
+// app are left out of them too. It is run periodically by the jobs, which serverless deployments trigger from Vercel
+// Cron.
+func SendDigests() error {
+	now := time.Now()
+	hour := DigestHour()
+
+	var settings []models.EmailNotificationSetting
+	if err := conf.DB.Preload("User").
+		Where("frequency IN ?", []string{types.EmailFrequencyDaily.Value(), types.EmailFrequencyWeekly.Value()}).
+		Find(&settings).Error; err != nil {
+		return err
+	}
+
+	for _, setting := range settings {
+		frequency := types.EmailFrequency(setting.Frequency)
+		slot := lastDigestSlot(frequency, now, hour)
+		if setting.LastDigestAt != nil && !setting.LastDigestAt.Before(slot) {
+			continue
+		}
+
+		since := slot.AddDate(0, 0, -1)
+		if frequency == types.EmailFrequencyWeekly {
+			since = slot.AddDate(0, 0, -7)
+		}
+		if setting.LastDigestAt != nil {
+			since = *setting.LastDigestAt
+		}
+
+		// Marking the digest first means a failure cannot send the same notifications twice
+		if err := conf.DB.Model(&setting).UpdateColumn("last_digest_at", now).Error; err != nil {
+			return err
+		}
+
+		if setting.User.EmailVerifiedAt == nil {
+			continue
+		}
+
+		if err := sendDigest(conf.DB, setting.User, frequency, since, now); err != nil {
+			log.Printf("Error while sending %s digest to user %d: %v", frequency, setting.UserID, err)
+		}
+	}
+
+	return nil
+}
+
+func sendDigest(db *gorm.DB, user models.User, frequency types.EmailFrequency, since, until time.Time) error {
+	query := db.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL AND created_at > ? AND created_at <= ?", user.ID, since, until)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil || total == 0 {
+		return err
+	}
+
+	var notifications []models.Notification
+	if err := query.Order("created_at DESC, id DESC").Limit(digestLimit).Find(&notifications).Error; err != nil {
+		return err
+	}
+
+	items := make([]digestItem, 0, len(notifications))
+	for _, notification := range notifications {
+		items = append(items, digestItem{
+			Title:     notification.Title,
+			Body:      notification.Body,
+			Link:      projectLink(notification.ProjectID),
+			CreatedAt: notification.CreatedAt.UTC().Format("Jan 2, 15:04 MST"),
+		})
+	}
+
+	subject := fmt.Sprintf("Your %s Bug Tracker digest: %d unread notification", frequency, total)
+	if total > 1 {
+		subject += "s"
+	}
+
+	return sendEmail(user, subject, "digest", emailData{
+		Name:      user.Name,
+		Frequency: frequency,
+		Items:     items,
+		More:      int(total) - len(items),
+	})
+}
+
+// sendEmail renders the HTML and text versions of the template and emails them to the user, with the links that
+// unsubscribe them from the notification emails in one click.
+func sendEmail(user models.User, subject, template string, data emailData) error {
+	// The link opens a page of the API asking to confirm, mail clients post to it directly
+	data.UnsubscribeLink = utils.APIURL("/api/v1/user/notifications/unsubscribe?token=" + url.QueryEscape(utils.UnsubscribeToken(user.ID)))
+
+	var html, text bytes.Buffer
+	if err := htmlTemplates.ExecuteTemplate(&html, template+".html", data); err != nil {
+		return err
+	}
+	if err := textTemplates.ExecuteTemplate(&text, template+".txt", data); err != nil {
+		return err
+	}
+
+	return mailer.Send(mailer.Message{
+		To:      []string{user.Email},
+		Subject: subject,
+		Text:    text.String(),
+		HTML:    html.String(),
+		Headers: map[string]string{
+			"List-Unsubscribe":      "<" + data.UnsubscribeLink + ">",
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+		},
+	})
+}
+
+// projectLink returns the page of the frontend showing the project, which has no page per bug.
+func projectLink(projectID uint) string {
+	return utils.AppURL(fmt.Sprintf("/projects/%d", projectID))
+}
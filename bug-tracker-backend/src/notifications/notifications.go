@@ -0,0 +1,223 @@
+package notifications
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/conf"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/events"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/models"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/utils"
+)
+
+const (
+	excerptLength         = 200
+	defaultDeadlineWindow = 24 // in hours
+)
+
+// Register subscribes the in-app and email notifications to the events published by the controllers.
+func Register() {
+	events.Subscribe(Notify)
+	events.Subscribe(Email)
+}
+
+// Notify creates the in-app notifications of the event for the users who should hear about it
+// and have not turned off this type of event.
+func Notify(event events.Event) {
+	if conf.DB == nil {
+		return
+	}
+
+	if err := notify(conf.DB, event); err != nil {
+		log.Printf("Error while creating notifications for %s event: %v", event.Type, err)
+	}
+}
+
+func notify(db *gorm.DB, event events.Event) error {
+	recipients, err := Recipients(db, event)
+	if err != nil {
+		return err
+	}
+
+	recipients, err = withInAppEnabled(db, event.Type, recipients)
+	if err != nil || len(recipients) == 0 {
+		return err
+	}
+
+	title, body, err := Describe(db, event)
+	if err != nil {
+		return err
+	}
+
+	notifications := make([]models.Notification, 0, len(recipients))
+	for _, userID := range recipients {
+		notification := models.Notification{
+			UserID:    userID,
+			Event:     event.Type.Value(),
+			ProjectID: event.ProjectID,
+			Title:     title,
+			Body:      body,
+		}
+		if event.BugID != 0 {
+			notification.BugID = &event.BugID
+		}
+		if event.ActorID != 0 {
+			notification.ActorID = &event.ActorID
+		}
+		notifications = append(notifications, notification)
+	}
+
+	return db.Create(&notifications).Error
+}
+
+// Recipients returns the IDs of the users who should hear about the event, whatever the channel. The users the event
+// is directly about are told about it, watchers hear about the changes to the bugs they follow, and nobody is told
+// about what they did themselves.
+func Recipients(db *gorm.DB, event events.Event) ([]uint, error) {
+	var recipients []uint
+
+	switch event.Type {
+	case events.BugAssigned, events.CommentMention:
+		recipients = slices.Clone(event.UserIDs)
+
+	case events.BugStatusChanged, events.CommentCreated, events.BugDeadlineApproaching:
+		var bug models.Bug
+		if err := db.Select("id", "project_id").First(&bug, event.BugID).Error; err != nil {
+			return nil, err
+		}
+
+		subscribers, err := utils.GetBugSubscribers(db, bug)
+		if err != nil {
+			return nil, err
+		}
+
+		if event.Type == events.CommentCreated {
+			// The mentioned users are told with the mention instead
+			recipients = slices.DeleteFunc(subscribers, func(id uint) bool { return slices.Contains(event.UserIDs, id) })
+		} else {
+			recipients = append(subscribers, event.UserIDs...)
+		}
+	}
+
+	slices.Sort(recipients)
+	recipients = slices.Compact(recipients)
+	return slices.DeleteFunc(recipients, func(id uint) bool { return id == event.ActorID }), nil
+}
+
+// withInAppEnabled leaves out the users who turned off the in-app notifications of the type of event.
+func withInAppEnabled(db *gorm.DB, eventType events.Type, userIDs []uint) ([]uint, error) {
+	if len(userIDs) == 0 {
+		return userIDs, nil
+	}
+
+	var disabled []uint
+	if err := db.Model(&models.NotificationPreference{}).
+		Where("event = ? AND user_id IN ? AND NOT in_app", eventType.Value(), userIDs).
+		Pluck("user_id", &disabled).Error; err != nil {
+		return nil, err
+	}
+
+	return slices.DeleteFunc(userIDs, func(id uint) bool { return slices.Contains(disabled, id) }), nil
+}
+
+// Describe returns the title and the body telling a user about the event.
+func Describe(db *gorm.DB, event events.Event) (string, string, error) {
+	var bug models.Bug
+	if err := db.Unscoped().Preload("Project").First(&bug, event.BugID).Error; err != nil {
+		return "", "", err
+	}
+	key := utils.BugKey(bug.Project.Key, bug.Number)
+
+	actor := "Someone"
+	if event.ActorID != 0 {
+		var user models.User
+		if err := db.Unscoped().Select("id", "name").First(&user, event.ActorID).Error; err != nil {
+			return "", "", err
+		}
+		actor = user.Name
+	}
+
+	switch event.Type {
+	case events.BugAssigned:
+		return fmt.Sprintf("%s assigned %s to you", actor, key), bug.Title, nil
+	case events.BugStatusChanged:
+		return fmt.Sprintf("%s moved %s from %v to %v", actor, key, event.Data["old_status"], event.Data["new_status"]), bug.Title, nil
+	case events.CommentCreated:
+		return fmt.Sprintf("%s commented on %s", actor, key), excerpt(event.Data["body"]), nil
+	case events.CommentMention:
+		return fmt.Sprintf("%s mentioned you on %s", actor, key), excerpt(event.Data["body"]), nil
+	case events.BugDeadlineApproaching:
+		return fmt.Sprintf("%s is due %s", key, bug.Deadline.UTC().Format("Jan 2, 15:04 MST")), bug.Title, nil
+	}
+
+	return key, bug.Title, nil
+}
+
+// excerpt shortens the text of a comment for the body of a notification.
+func excerpt(value any) string {
+	text, _ := value.(string)
+	runes := []rune(text)
+	if len(runes) <= excerptLength {
+		return text
+	}
+	return string(runes[:excerptLength]) + "…"
+}
+
+// DeadlineWindow returns how long before its deadline a bug is reported as approaching it.
+func DeadlineWindow() time.Duration {
+	hours, err := strconv.Atoi(os.Getenv("NOTIFICATION_DEADLINE_WINDOW"))
+	if err != nil || hours <= 0 {
+		hours = defaultDeadlineWindow
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// NotifyApproachingDeadlines publishes an event for every open bug whose deadline falls within the window,
+// once per deadline. It is run periodically by the jobs, which serverless deployments trigger from Vercel Cron.
+func NotifyApproachingDeadlines(window time.Duration) error {
+	now := time.Now()
+
+	var bugs []models.Bug
+	if err := conf.DB.Where("deadline > ? AND deadline <= ? AND deadline_notified_at IS NULL", now, now.Add(window)).
+		Order("project_id ASC").
+		Find(&bugs).Error; err != nil {
+		return err
+	}
+
+	workflows := make(map[uint]utils.Workflow)
+	for _, bug := range bugs {
+		workflow, ok := workflows[bug.ProjectID]
+		if !ok {
+			var err error
+			if workflow, err = utils.GetProjectWorkflow(conf.DB, bug.ProjectID); err != nil {
+				return err
+			}
+			workflows[bug.ProjectID] = workflow
+		}
+
+		// Marking the bug first means a failure cannot notify the same deadline twice
+		if err := conf.DB.Model(&bug).UpdateColumn("deadline_notified_at", now).Error; err != nil {
+			return err
+		}
+
+		if workflow.IsClosed(bug.Status) {
+			continue
+		}
+
+		events.Publish(events.Event{
+			Type:      events.BugDeadlineApproaching,
+			ProjectID: bug.ProjectID,
+			BugID:     bug.ID,
+			UserIDs:   []uint{bug.AssignedTo},
+			Data:      map[string]any{"deadline": bug.Deadline},
+		})
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package realtime
+
+import "sync"
+
+// subscriberBuffer is how many messages a subscriber can fall behind before it is dropped.
+const subscriberBuffer = 64
+
+// MemoryBroker delivers the messages to the subscribers of this process only.
+type MemoryBroker struct {
+	mu          sync.Mutex
+	subscribers map[uint]map[chan Message]struct{}
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{subscribers: make(map[uint]map[chan Message]struct{})}
+}
+
+// Publish never blocks. A subscriber whose buffer is full is dropped rather than sent an incomplete stream,
+// so that its client reconnects and reloads.
+func (b *MemoryBroker) Publish(message Message) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers[message.ProjectID] {
+		select {
+		case ch <- message:
+		default:
+			b.remove(message.ProjectID, ch)
+		}
+	}
+	return nil
+}
+
+func (b *MemoryBroker) Subscribe(projectID uint) (<-chan Message, func()) {
+	ch := make(chan Message, subscriberBuffer)
+
+	b.mu.Lock()
+	if b.subscribers[projectID] == nil {
+		b.subscribers[projectID] = make(map[chan Message]struct{})
+	}
+	b.subscribers[projectID][ch] = struct{}{}
+	b.mu.Unlock()
+
+	unsubscribe := func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.remove(projectID, ch)
+	}
+	return ch, unsubscribe
+}
+
+// remove closes the channel of the subscriber, unless it was already removed. The lock must be held.
+func (b *MemoryBroker) remove(projectID uint, ch chan Message) {
+	if _, ok := b.subscribers[projectID][ch]; !ok {
+		return
+	}
+
+	delete(b.subscribers[projectID], ch)
+	close(ch)
+	if len(b.subscribers[projectID]) == 0 {
+		delete(b.subscribers, projectID)
+	}
+}
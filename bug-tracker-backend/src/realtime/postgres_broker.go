@@ -0,0 +1,93 @@
+package realtime
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+const (
+	notifyChannel    = "bug_tracker_realtime"
+	maxNotifyPayload = 7900 // Postgres refuses notification payloads of 8000 bytes or more
+	listenerPing     = 90 * time.Second
+)
+
+// PostgresBroker shares the messages between the instances of the server with LISTEN/NOTIFY. Every instance
+// listens to the channel and hands the messages it receives, its own included, to its local subscribers.
+type PostgresBroker struct {
+	db       *gorm.DB
+	listener *pq.Listener
+	local    *MemoryBroker
+}
+
+func NewPostgresBroker(db *gorm.DB, dsn string) (*PostgresBroker, error) {
+	listener := pq.NewListener(dsn, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Println("Error in realtime listener:", err)
+		}
+	})
+
+	if err := listener.Listen(notifyChannel); err != nil {
+		listener.Close()
+		return nil, err
+	}
+
+	broker := &PostgresBroker{db: db, listener: listener, local: NewMemoryBroker()}
+	go broker.listen()
+	return broker, nil
+}
+
+func (b *PostgresBroker) listen() {
+	for {
+		select {
+		case notification, ok := <-b.listener.Notify:
+			if !ok {
+				return
+			}
+			// A nil notification means the connection was reestablished, the messages sent meanwhile are lost
+			if notification == nil {
+				continue
+			}
+
+			var message Message
+			if err := json.Unmarshal([]byte(notification.Extra), &message); err != nil {
+				log.Println("Error while decoding realtime message:", err)
+				continue
+			}
+			b.local.Publish(message)
+
+		case <-time.After(listenerPing):
+			// Pinging detects a connection that died without the listener noticing
+			go b.listener.Ping()
+		}
+	}
+}
+
+func (b *PostgresBroker) Publish(message Message) error {
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	if len(payload) > maxNotifyPayload {
+		message.Data = nil
+		message.Truncated = true
+		if payload, err = json.Marshal(message); err != nil {
+			return err
+		}
+	}
+
+	return b.db.Exec("SELECT pg_notify(?, ?)", notifyChannel, string(payload)).Error
+}
+
+func (b *PostgresBroker) Subscribe(projectID uint) (<-chan Message, func()) {
+	return b.local.Subscribe(projectID)
+}
+
+// Close stops listening to the database. The subscriptions stay open but receive no more messages.
+func (b *PostgresBroker) Close() error {
+	return b.listener.Close()
+}
@@ -0,0 +1,108 @@
+package realtime
+
+import (
+	"log"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/conf"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/events"
+)
+
+// HeartbeatInterval is how often idle streams send a comment, so that proxies do not close them.
+const HeartbeatInterval = 25 * time.Second
+
+// Message is a change pushed to the clients following a project.
+type Message struct {
+	Type       events.Type    `json:"type"`
+	ProjectID  uint           `json:"project_id"`
+	BugID      uint           `json:"bug_id,omitempty"`
+	ActorID    uint           `json:"actor_id,omitempty"` // Lets clients skip the changes they made themselves
+	UserIDs    []uint         `json:"user_ids,omitempty"`
+	Data       map[string]any `json:"data"`
+	Truncated  bool           `json:"truncated,omitempty"` // Data was left out because it was too large, clients should reload
+	OccurredAt time.Time      `json:"occurred_at"`
+}
+
+// Broker delivers the messages of a project to the clients following it.
+// Implementations must be safe for concurrent use.
+type Broker interface {
+	Publish(message Message) error
+	// Subscribe returns the messages of the project and a function to stop receiving them. The channel is closed
+	// when the subscription ends, including when the subscriber falls too far behind.
+	Subscribe(projectID uint) (<-chan Message, func())
+}
+
+// streamed lists the types of event pushed to the clients, the ones that change what the board of the project shows.
+var streamed = []events.Type{
+	events.BugCreated, events.BugUpdated, events.BugDeleted, events.BugRestored,
+	events.TeamMemberAdded, events.TeamMemberUpdated, events.TeamMemberRemoved,
+}
+
+var (
+	defaultBroker Broker
+	once          sync.Once
+)
+
+// newBrokerFromEnv picks the broker implementation based on the REALTIME_DRIVER environment variable.
+func newBrokerFromEnv() Broker {
+	switch driver := os.Getenv("REALTIME_DRIVER"); driver {
+	case "", "memory":
+		return NewMemoryBroker()
+	case "postgres":
+		if conf.DB == nil {
+			log.Println("Warning: Database is not connected, realtime updates will only reach the clients of this instance")
+			return NewMemoryBroker()
+		}
+		broker, err := NewPostgresBroker(conf.DB, conf.DSN)
+		if err != nil {
+			log.Printf("Warning: Failed to listen to the database, realtime updates will only reach the clients of this instance: %v", err)
+			return NewMemoryBroker()
+		}
+		return broker
+	default:
+		log.Printf("Warning: Unknown realtime driver %q, realtime updates will only reach the clients of this instance", driver)
+		return NewMemoryBroker()
+	}
+}
+
+// SetDefault replaces the broker returned by Default, for example with a MemoryBroker in tests.
+func SetDefault(broker Broker) {
+	once.Do(func() {})
+	defaultBroker = broker
+}
+
+// Default returns the broker of the system, configuring it from the environment on first use.
+func Default() Broker {
+	once.Do(func() {
+		defaultBroker = newBrokerFromEnv()
+	})
+	return defaultBroker
+}
+
+// Register forwards the events published by the controllers to the clients following the project.
+func Register() {
+	events.Subscribe(Forward)
+}
+
+// Forward publishes the event to the clients following its project, if it is one of the streamed types.
+func Forward(event events.Event) {
+	if !slices.Contains(streamed, event.Type) {
+		return
+	}
+
+	err := Default().Publish(Message{
+		Type:       event.Type,
+		ProjectID:  event.ProjectID,
+		BugID:      event.BugID,
+		ActorID:    event.ActorID,
+		UserIDs:    event.UserIDs,
+		Data:       event.Data,
+		OccurredAt: event.OccurredAt,
+	})
+	if err != nil {
+		log.Printf("Error while publishing %s event to the realtime clients: %v", event.Type, err)
+	}
+}
@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/controllers"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/middlewares"
+)
+
+// CronRoutes let the scheduler of serverless deployments run the background jobs, see vercel.json.
+func CronRoutes(router *gin.RouterGroup) {
+	router.GET("cron/jobs/:job", middlewares.RequireCronSecret, controllers.RunJob)
+}
@@ -5,35 +5,137 @@ import (
 
 	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/controllers"
 	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/middlewares"
+	types "github.com/WNBARookie/BugTracker/bug-tracker-backend/src/types"
 )
 
 func ProjectRoutes(router *gin.RouterGroup) {
-	router.Use(middlewares.RequireAuth)
-	router.POST("project", controllers.CreateProject)
-	router.GET("project", controllers.GetAllProjects)
-	router.GET("project/:projectID", middlewares.ProjectCheckMiddleware, controllers.GetProjectByID)
-	router.PATCH("project/:projectID", middlewares.ProjectCheckMiddleware, controllers.UpdateProject)
-	router.DELETE("project/:projectID", middlewares.ProjectCheckMiddleware, controllers.DeleteProject)
+	read := middlewares.RequireScope(types.TokenScopeProjectsRead)
+	write := middlewares.RequireScope(types.TokenScopeProjectsWrite)
+
+	router.POST("project", write, controllers.CreateProject)
+	router.GET("project", read, controllers.GetAllProjects)
+	router.GET("project/:projectID", read, middlewares.ProjectCheckMiddleware, controllers.GetProjectByID)
+	router.PATCH("project/:projectID", write, middlewares.ProjectCheckMiddleware, middlewares.RequirePermission(types.PermissionProjectUpdate), controllers.UpdateProject)
+	router.DELETE("project/:projectID", write, middlewares.ProjectCheckMiddleware, middlewares.RequirePermission(types.PermissionProjectDelete), controllers.DeleteProject)
+	router.GET("project/:projectID/workflow", read, middlewares.ProjectCheckMiddleware, controllers.GetWorkflow)
+	router.PUT("project/:projectID/workflow", write, middlewares.ProjectCheckMiddleware, middlewares.RequirePermission(types.PermissionProjectUpdate), controllers.UpdateWorkflow)
+	router.GET("project/:projectID/custom-fields", read, middlewares.ProjectCheckMiddleware, controllers.GetCustomFields)
+	router.POST("project/:projectID/custom-fields", write, middlewares.ProjectCheckMiddleware, middlewares.RequirePermission(types.PermissionProjectUpdate), controllers.CreateCustomField)
+	router.PATCH("project/:projectID/custom-fields/:fieldID", write, middlewares.ProjectCheckMiddleware, middlewares.RequirePermission(types.PermissionProjectUpdate), middlewares.CustomFieldCheckMiddleware, controllers.UpdateCustomField)
+	router.DELETE("project/:projectID/custom-fields/:fieldID", write, middlewares.ProjectCheckMiddleware, middlewares.RequirePermission(types.PermissionProjectUpdate), middlewares.CustomFieldCheckMiddleware, controllers.DeleteCustomField)
 }
 
 func TeamRoutes(router *gin.RouterGroup) {
-	router.Use(middlewares.RequireAuth)
 	projectGroup := router.Group("project/:projectID/")
 	projectGroup.Use(middlewares.ProjectCheckMiddleware)
+	read := middlewares.RequireScope(types.TokenScopeTeamRead)
+	write := middlewares.RequireScope(types.TokenScopeTeamWrite)
 
-	projectGroup.POST("team/add", controllers.AddToTeam)
-	projectGroup.GET("team", controllers.GetTeamMembers)
-	projectGroup.POST("team/action", controllers.TeamAction)
+	projectGroup.POST("team/add", write, middlewares.RequirePermission(types.PermissionTeamManage), controllers.AddToTeam)
+	projectGroup.GET("team", read, controllers.GetTeamMembers)
+	projectGroup.POST("team/action", write, controllers.TeamAction)
+	projectGroup.POST("team/invite", write, middlewares.RequirePermission(types.PermissionTeamManage), controllers.InviteToTeam)
+	projectGroup.GET("team/invitations", read, middlewares.RequirePermission(types.PermissionTeamManage), controllers.GetProjectInvitations)
+	projectGroup.DELETE("team/invitations/:invitationID", write, middlewares.RequirePermission(types.PermissionTeamManage), controllers.RevokeInvitation)
 }
 
 func BugRoutes(router *gin.RouterGroup) {
-	router.Use(middlewares.RequireAuth)
 	projectGroup := router.Group("project/:projectID/")
 	projectGroup.Use(middlewares.ProjectCheckMiddleware)
+	read := middlewares.RequireScope(types.TokenScopeBugsRead)
+	write := middlewares.RequireScope(types.TokenScopeBugsWrite)
+
+	projectGroup.POST("bug", write, middlewares.RequirePermission(types.PermissionBugCreate), controllers.CreateBug)
+	projectGroup.GET("bug", read, controllers.GetAllBugs)
+	projectGroup.GET("bug/trash", read, controllers.GetTrashedBugs)
+	projectGroup.GET("bug/:bugID", read, middlewares.BugCheckMiddleware, controllers.GetBugByID)
+	projectGroup.PATCH("bug/:bugID", write, middlewares.RequirePermission(types.PermissionBugUpdate), middlewares.BugCheckMiddleware, controllers.UpdateBug)
+	projectGroup.GET("bug/:bugID/history", read, middlewares.BugCheckMiddleware, controllers.GetBugHistory)
+	projectGroup.GET("bug/:bugID/children", read, middlewares.BugCheckMiddleware, controllers.GetBugChildren)
+	projectGroup.POST("bug/:bugID/links", write, middlewares.RequirePermission(types.PermissionBugUpdate), middlewares.BugCheckMiddleware, controllers.CreateBugLink)
+	projectGroup.DELETE("bug/:bugID/links/:linkID", write, middlewares.RequirePermission(types.PermissionBugUpdate), middlewares.BugCheckMiddleware, controllers.DeleteBugLink)
+	projectGroup.DELETE("bug/:bugID", write, middlewares.RequirePermission(types.PermissionBugDelete), middlewares.BugCheckMiddleware, controllers.DeleteBug)
+	projectGroup.POST("bug/:bugID/restore", write, middlewares.RequirePermission(types.PermissionBugRestore), middlewares.TrashedBugCheckMiddleware, controllers.RestoreBug)
+	projectGroup.DELETE("bug/:bugID/purge", write, middlewares.RequirePermission(types.PermissionBugPurge), middlewares.TrashedBugCheckMiddleware, controllers.PurgeBug)
+}
+
+func CommentRoutes(router *gin.RouterGroup) {
+	bugGroup := router.Group("project/:projectID/bug/:bugID/")
+	bugGroup.Use(middlewares.ProjectCheckMiddleware, middlewares.BugCheckMiddleware)
+	read := middlewares.RequireScope(types.TokenScopeBugsRead)
+	write := middlewares.RequireScope(types.TokenScopeBugsWrite)
+
+	bugGroup.POST("comments", write, controllers.CreateComment)
+	bugGroup.GET("comments", read, controllers.GetComments)
+	bugGroup.GET("comments/:commentID", read, middlewares.CommentCheckMiddleware, controllers.GetCommentByID)
+	bugGroup.PATCH("comments/:commentID", write, middlewares.CommentCheckMiddleware, controllers.UpdateComment)
+	bugGroup.DELETE("comments/:commentID", write, middlewares.CommentCheckMiddleware, controllers.DeleteComment)
+	bugGroup.GET("comments/:commentID/history", read, middlewares.CommentCheckMiddleware, controllers.GetCommentHistory)
+}
+
+func AttachmentRoutes(router *gin.RouterGroup) {
+	bugGroup := router.Group("project/:projectID/bug/:bugID/")
+	bugGroup.Use(middlewares.ProjectCheckMiddleware, middlewares.BugCheckMiddleware)
+	read := middlewares.RequireScope(types.TokenScopeBugsRead)
+	write := middlewares.RequireScope(types.TokenScopeBugsWrite)
+
+	bugGroup.POST("attachments", write, controllers.UploadAttachment)
+	bugGroup.GET("attachments", read, controllers.GetAttachments)
+	bugGroup.GET("attachments/:attachmentID/download", read, middlewares.AttachmentCheckMiddleware, controllers.DownloadAttachment)
+	bugGroup.DELETE("attachments/:attachmentID", write, middlewares.AttachmentCheckMiddleware, controllers.DeleteAttachment)
+}
+
+func MilestoneRoutes(router *gin.RouterGroup) {
+	projectGroup := router.Group("project/:projectID/")
+	projectGroup.Use(middlewares.ProjectCheckMiddleware)
+	read := middlewares.RequireScope(types.TokenScopeProjectsRead)
+	write := middlewares.RequireScope(types.TokenScopeProjectsWrite)
+	manage := middlewares.RequirePermission(types.PermissionMilestoneManage)
+
+	projectGroup.POST("milestones", write, manage, controllers.CreateMilestone)
+	projectGroup.GET("milestones", read, controllers.GetMilestones)
+	projectGroup.GET("milestones/:milestoneID", read, middlewares.MilestoneCheckMiddleware, controllers.GetMilestoneByID)
+	projectGroup.GET("milestones/:milestoneID/burndown", read, middlewares.MilestoneCheckMiddleware, controllers.GetMilestoneBurndown)
+	projectGroup.PATCH("milestones/:milestoneID", write, manage, middlewares.MilestoneCheckMiddleware, controllers.UpdateMilestone)
+	projectGroup.DELETE("milestones/:milestoneID", write, manage, middlewares.MilestoneCheckMiddleware, controllers.DeleteMilestone)
+}
+
+func WatchRoutes(router *gin.RouterGroup) {
+	projectGroup := router.Group("project/:projectID/")
+	projectGroup.Use(middlewares.ProjectCheckMiddleware)
+	read := middlewares.RequireScope(types.TokenScopeBugsRead)
+	write := middlewares.RequireScope(types.TokenScopeUserWrite)
+
+	projectGroup.POST("watch", write, controllers.WatchProject)
+	projectGroup.DELETE("watch", write, controllers.UnwatchProject)
+	projectGroup.POST("bug/:bugID/watch", write, middlewares.BugCheckMiddleware, controllers.WatchBug)
+	projectGroup.DELETE("bug/:bugID/watch", write, middlewares.BugCheckMiddleware, controllers.UnwatchBug)
+	projectGroup.GET("bug/:bugID/watchers", read, middlewares.BugCheckMiddleware, controllers.GetBugWatchers)
+}
+
+func WebhookRoutes(router *gin.RouterGroup) {
+	projectGroup := router.Group("project/:projectID/")
+	projectGroup.Use(middlewares.ProjectCheckMiddleware)
+	read := middlewares.RequireScope(types.TokenScopeProjectsRead)
+	write := middlewares.RequireScope(types.TokenScopeProjectsWrite)
+	manage := middlewares.RequirePermission(types.PermissionWebhookManage) // Payloads and secrets are only for admins
+
+	projectGroup.POST("webhooks", write, manage, controllers.CreateWebhook)
+	projectGroup.GET("webhooks", read, manage, controllers.GetWebhooks)
+	projectGroup.GET("webhooks/:webhookID", read, manage, middlewares.WebhookCheckMiddleware, controllers.GetWebhookByID)
+	projectGroup.PATCH("webhooks/:webhookID", write, manage, middlewares.WebhookCheckMiddleware, controllers.UpdateWebhook)
+	projectGroup.DELETE("webhooks/:webhookID", write, manage, middlewares.WebhookCheckMiddleware, controllers.DeleteWebhook)
+	projectGroup.POST("webhooks/:webhookID/ping", write, manage, middlewares.WebhookCheckMiddleware, controllers.PingWebhook)
+	projectGroup.GET("webhooks/:webhookID/deliveries", read, manage, middlewares.WebhookCheckMiddleware, controllers.GetWebhookDeliveries)
+	projectGroup.GET("webhooks/:webhookID/deliveries/:deliveryID", read, manage, middlewares.WebhookCheckMiddleware, controllers.GetWebhookDelivery)
+	projectGroup.POST("webhooks/:webhookID/deliveries/:deliveryID/redeliver", write, manage, middlewares.WebhookCheckMiddleware, controllers.RedeliverWebhookDelivery)
+}
+
+// RealtimeRoutes are not registered on the authenticated routes: the event stream is opened with a ticket in the query,
+// which is requested with the access token.
+func RealtimeRoutes(router *gin.RouterGroup) {
+	read := middlewares.RequireScope(types.TokenScopeBugsRead)
 
-	projectGroup.POST("bug", controllers.CreateBug)
-	projectGroup.GET("bug", controllers.GetAllBugs)
-	projectGroup.GET("bug/:bugID", middlewares.BugCheckMiddleware, controllers.GetBugByID)
-	projectGroup.PATCH("bug/:bugID", middlewares.BugCheckMiddleware, controllers.UpdateBug)
-	projectGroup.DELETE("bug/:bugID", middlewares.BugCheckMiddleware, controllers.DeleteBug)
+	router.POST("project/:projectID/events/ticket", middlewares.RequireAuth, middlewares.ProjectCheckMiddleware, read, controllers.CreateStreamTicket)
+	router.GET("project/:projectID/events", middlewares.RequireStreamTicket, middlewares.ProjectCheckMiddleware, controllers.StreamProjectEvents)
 }
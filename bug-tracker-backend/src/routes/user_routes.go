@@ -5,17 +5,51 @@ import (
 
 	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/controllers"
 	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/middlewares"
+	types "github.com/WNBARookie/BugTracker/bug-tracker-backend/src/types"
 )
 
 func AuthRoutes(router *gin.RouterGroup) {
 	router.POST("user/signup", controllers.SignUp)
 	router.POST("user/login", controllers.Login)
+	router.POST("user/login/2fa", controllers.LoginTwoFactor)
+	router.POST("user/refresh", controllers.RefreshToken)
+	router.POST("user/email/verify", controllers.VerifyEmail)
+	router.POST("user/password/forgot", controllers.ForgotPassword)
+	router.POST("user/password/reset", controllers.ResetPassword)
+	router.POST("user/unlock/request", controllers.RequestAccountUnlock)
+	router.POST("user/unlock", controllers.UnlockAccount)
+	router.GET("user/notifications/unsubscribe", controllers.GetUnsubscribePage)
+	router.POST("user/notifications/unsubscribe", controllers.UnsubscribeFromEmails)
 }
 
 func UserRoutes(router *gin.RouterGroup) {
-	router.Use(middlewares.RequireAuth)
-	router.GET("user", controllers.GetUserProfile)
-	router.PATCH("user", controllers.UpdateUserProfile)
-	router.DELETE("user", controllers.DeleteUserProfile)
-	router.GET("user/bugs", controllers.GetUserBugs)
+	read := middlewares.RequireScope(types.TokenScopeUserRead)
+	write := middlewares.RequireScope(types.TokenScopeUserWrite)
+
+	router.POST("user/logout", controllers.Logout)
+	router.POST("user/email/verification", write, controllers.RequestEmailVerification)
+	router.GET("user", read, controllers.GetUserProfile)
+	router.PATCH("user", write, controllers.UpdateUserProfile)
+	router.DELETE("user", middlewares.RequireSession, controllers.DeleteUserProfile)
+	router.GET("user/export", read, controllers.ExportUserData)
+	router.GET("user/bugs", read, controllers.GetUserBugs)
+	router.GET("user/watching", read, controllers.GetUserWatches)
+	router.GET("user/notifications", read, controllers.GetNotifications)
+	router.GET("user/notifications/unread-count", read, controllers.GetUnreadNotificationCount)
+	router.POST("user/notifications/:notificationID/read", write, controllers.MarkNotificationRead)
+	router.POST("user/notifications/read-all", write, controllers.MarkAllNotificationsRead)
+	router.GET("user/notifications/preferences", read, controllers.GetNotificationPreferences)
+	router.PUT("user/notifications/preferences", write, controllers.UpdateNotificationPreferences)
+	router.GET("user/notifications/email", read, controllers.GetEmailNotificationSettings)
+	router.PUT("user/notifications/email", write, controllers.UpdateEmailNotificationSettings)
+	router.GET("user/invitations", read, controllers.GetUserInvitations)
+	router.POST("user/invitations/accept", write, controllers.AcceptInvitation)
+	router.POST("user/invitations/decline", write, controllers.DeclineInvitation)
+	router.GET("user/tokens", middlewares.RequireSession, controllers.GetPersonalAccessTokens)
+	router.POST("user/tokens", middlewares.RequireSession, controllers.CreatePersonalAccessToken)
+	router.DELETE("user/tokens/:tokenID", middlewares.RequireSession, controllers.RevokePersonalAccessToken)
+	router.POST("user/2fa/enroll", middlewares.RequireSession, controllers.EnrollTwoFactor)
+	router.POST("user/2fa/confirm", middlewares.RequireSession, controllers.ConfirmTwoFactor)
+	router.POST("user/2fa/disable", middlewares.RequireSession, controllers.DisableTwoFactor)
+	router.POST("user/2fa/recovery-codes", middlewares.RequireSession, controllers.RegenerateRecoveryCodes)
 }
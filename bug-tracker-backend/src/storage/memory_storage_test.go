@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestMemoryStoragePutOpenDelete(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStorage()
+
+	if _, err := s.Open(ctx, "attachments/a.txt"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("open of a missing object = %v, want ErrNotFound", err)
+	}
+
+	if err := s.Put(ctx, "attachments/a.txt", strings.NewReader("hello"), 5, "text/plain"); err != nil {
+		t.Fatal(err)
+	}
+
+	body, err := s.Open(ctx, "attachments/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(body)
+	body.Close()
+	if string(data) != "hello" {
+		t.Errorf("object = %q, want %q", data, "hello")
+	}
+
+	if err := s.Delete(ctx, "attachments/a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Open(ctx, "attachments/a.txt"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("open of a deleted object = %v, want ErrNotFound", err)
+	}
+
+	// Deleting a missing object is not an error, like on the other storages
+	if err := s.Delete(ctx, "attachments/a.txt"); err != nil {
+		t.Errorf("delete of a missing object = %v", err)
+	}
+}
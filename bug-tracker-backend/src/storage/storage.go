@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"sync"
+)
+
+// ErrNotFound is returned by Open when no object is stored under the key.
+var ErrNotFound = errors.New("object not found")
+
+// Storage keeps binary objects, such as bug attachments, under slash separated keys.
+// Implementations must be safe for concurrent use.
+type Storage interface {
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+var (
+	defaultStorage Storage
+	once           sync.Once
+)
+
+// newStorageFromEnv picks the storage implementation based on the STORAGE_DRIVER environment variable.
+func newStorageFromEnv() Storage {
+	switch driver := os.Getenv("STORAGE_DRIVER"); driver {
+	case "", "local":
+		return NewLocalStorage(os.Getenv("STORAGE_DIR"))
+	case "s3":
+		return NewS3StorageFromEnv()
+	case "memory":
+		return NewMemoryStorage()
+	default:
+		log.Printf("Warning: Unknown storage driver %q, files will be stored on the local filesystem", driver)
+		return NewLocalStorage(os.Getenv("STORAGE_DIR"))
+	}
+}
+
+// SetDefault replaces the storage returned by Default, for example with a MemoryStorage in tests.
+func SetDefault(storage Storage) {
+	once.Do(func() {})
+	defaultStorage = storage
+}
+
+// Default returns the storage of the system, configuring it from the environment on first use.
+func Default() Storage {
+	once.Do(func() {
+		defaultStorage = newStorageFromEnv()
+	})
+	return defaultStorage
+}
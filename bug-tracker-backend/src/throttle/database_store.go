@@ -0,0 +1,91 @@
+package throttle
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/conf"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/models"
+)
+
+// DatabaseStore keeps the attempts in the login_attempts table, so that they are shared by every instance.
+type DatabaseStore struct {
+	resetAfter time.Duration
+}
+
+func NewDatabaseStore(resetAfter time.Duration) *DatabaseStore {
+	return &DatabaseStore{resetAfter: resetAfter}
+}
+
+func toAttempt(loginAttempt models.LoginAttempt) Attempt {
+	attempt := Attempt{
+		Failures:      loginAttempt.Failures,
+		LastFailureAt: loginAttempt.LastFailureAt,
+	}
+	if loginAttempt.LockedUntil != nil {
+		attempt.LockedUntil = *loginAttempt.LockedUntil
+	}
+	return attempt
+}
+
+func (s *DatabaseStore) Get(key string) (Attempt, error) {
+	var loginAttempt models.LoginAttempt
+	if err := conf.DB.Where("key = ?", key).First(&loginAttempt).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return Attempt{}, nil
+		}
+		return Attempt{}, err
+	}
+	return toAttempt(loginAttempt), nil
+}
+
+func (s *DatabaseStore) RecordFailure(key string, now time.Time, lockout func(failures int) time.Duration) (Attempt, error) {
+	var loginAttempt models.LoginAttempt
+
+	err := conf.DB.Transaction(func(tx *gorm.DB) error {
+		// Make sure the row exists, then lock it so that concurrent failures are all counted
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.LoginAttempt{Key: key, LastFailureAt: now}).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("key = ?", key).First(&loginAttempt).Error; err != nil {
+			return err
+		}
+
+		if now.Sub(loginAttempt.LastFailureAt) > s.resetAfter {
+			loginAttempt.Failures = 0
+			loginAttempt.LockedUntil = nil
+		}
+
+		loginAttempt.Failures++
+		loginAttempt.LastFailureAt = now
+		if duration := lockout(loginAttempt.Failures); duration > 0 {
+			lockedUntil := now.Add(duration)
+			loginAttempt.LockedUntil = &lockedUntil
+		}
+
+		return tx.Model(&loginAttempt).Updates(map[string]any{
+			"failures":        loginAttempt.Failures,
+			"last_failure_at": loginAttempt.LastFailureAt,
+			"locked_until":    loginAttempt.LockedUntil,
+		}).Error
+	})
+	if err != nil {
+		return Attempt{}, err
+	}
+
+	return toAttempt(loginAttempt), nil
+}
+
+func (s *DatabaseStore) Reset(key string) error {
+	return conf.DB.Unscoped().Where("key = ?", key).Delete(&models.LoginAttempt{}).Error
+}
+
+// PurgeStale removes the attempts that are old enough to have been forgotten.
+func (s *DatabaseStore) PurgeStale() error {
+	return conf.DB.Unscoped().
+		Where("last_failure_at < ? AND (locked_until IS NULL OR locked_until < ?)", time.Now().Add(-s.resetAfter), time.Now()).
+		Delete(&models.LoginAttempt{}).Error
+}
@@ -0,0 +1,51 @@
+package throttle
+
+import (
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps the attempts in process memory. It is only suitable for a single instance and for tests.
+type MemoryStore struct {
+	mu         sync.Mutex
+	attempts   map[string]Attempt
+	resetAfter time.Duration
+}
+
+func NewMemoryStore(resetAfter time.Duration) *MemoryStore {
+	return &MemoryStore{attempts: make(map[string]Attempt), resetAfter: resetAfter}
+}
+
+func (s *MemoryStore) Get(key string) (Attempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.attempts[key], nil
+}
+
+func (s *MemoryStore) RecordFailure(key string, now time.Time, lockout func(failures int) time.Duration) (Attempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attempt := s.attempts[key]
+	if now.Sub(attempt.LastFailureAt) > s.resetAfter {
+		attempt = Attempt{}
+	}
+
+	attempt.Failures++
+	attempt.LastFailureAt = now
+	if duration := lockout(attempt.Failures); duration > 0 {
+		attempt.LockedUntil = now.Add(duration)
+	}
+
+	s.attempts[key] = attempt
+	return attempt, nil
+}
+
+func (s *MemoryStore) Reset(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.attempts, key)
+	return nil
+}
@@ -0,0 +1,81 @@
+package throttle
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreLocksAfterTheFreeAttemptsWithBackoff(t *testing.T) {
+	store := NewMemoryStore(AccountPolicy.ResetAfter)
+	now := time.Now()
+
+	for i := 1; i <= AccountPolicy.FreeAttempts; i++ {
+		attempt, err := store.RecordFailure("account:a@example.com", now, AccountPolicy.Lockout)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !attempt.LockedUntil.IsZero() {
+			t.Fatalf("key was locked after %d failures", i)
+		}
+	}
+
+	for _, want := range []time.Duration{30 * time.Second, time.Minute, 2 * time.Minute, 4 * time.Minute} {
+		attempt, err := store.RecordFailure("account:a@example.com", now, AccountPolicy.Lockout)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := attempt.LockedUntil.Sub(now); got != want {
+			t.Errorf("after %d failures the key is locked for %s, want %s", attempt.Failures, got, want)
+		}
+	}
+
+	if attempt, _ := store.Get("account:b@example.com"); attempt.Failures != 0 {
+		t.Errorf("other key has %d failures", attempt.Failures)
+	}
+}
+
+func TestMemoryStoreForgetsOldFailures(t *testing.T) {
+	store := NewMemoryStore(time.Hour)
+	now := time.Now()
+
+	for i := 0; i < 3; i++ {
+		store.RecordFailure("ip:127.0.0.1", now, IPPolicy.Lockout)
+	}
+
+	attempt, _ := store.RecordFailure("ip:127.0.0.1", now.Add(2*time.Hour), IPPolicy.Lockout)
+	if attempt.Failures != 1 {
+		t.Errorf("failures after the reset period = %d, want 1", attempt.Failures)
+	}
+}
+
+func TestPolicyLockoutIsCapped(t *testing.T) {
+	if got := AccountPolicy.Lockout(AccountPolicy.FreeAttempts + 100); got != AccountPolicy.MaxLockout {
+		t.Errorf("lockout = %s, want %s", got, AccountPolicy.MaxLockout)
+	}
+}
+
+func TestLimiterRetryAfterAndReset(t *testing.T) {
+	limiter := &Limiter{Store: NewMemoryStore(AccountPolicy.ResetAfter)}
+	key := AccountKey(" A@Example.com ")
+
+	for i := 0; i <= AccountPolicy.FreeAttempts; i++ {
+		if err := limiter.RecordFailure(key, AccountPolicy); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	retryAfter, err := limiter.RetryAfter(IPKey("127.0.0.1"), AccountKey("a@example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if retryAfter <= 0 || retryAfter > AccountPolicy.BaseLockout {
+		t.Errorf("retry after = %s, want up to %s", retryAfter, AccountPolicy.BaseLockout)
+	}
+
+	if err := limiter.Reset(key); err != nil {
+		t.Fatal(err)
+	}
+	if retryAfter, _ := limiter.RetryAfter(key); retryAfter != 0 {
+		t.Errorf("retry after a reset = %s, want 0", retryAfter)
+	}
+}
@@ -0,0 +1,124 @@
+package throttle
+
+import (
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Attempt is the failed login state of a key, either an account or an IP address.
+type Attempt struct {
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   time.Time
+}
+
+// Store keeps track of failed attempts. Implementations must be safe for concurrent use.
+type Store interface {
+	Get(key string) (Attempt, error)
+	// RecordFailure increments the failures of the key and locks it for the duration returned by lockout.
+	RecordFailure(key string, now time.Time, lockout func(failures int) time.Duration) (Attempt, error)
+	Reset(key string) error
+}
+
+// Policy defines how many attempts a key gets before it is locked and how the lockout grows.
+type Policy struct {
+	FreeAttempts int           // Failures allowed before the key is locked
+	BaseLockout  time.Duration // Lockout after the first failure over the free attempts, doubled for every further failure
+	MaxLockout   time.Duration
+	ResetAfter   time.Duration // Failures are forgotten after this long without a new one
+}
+
+var (
+	AccountPolicy = Policy{FreeAttempts: 5, BaseLockout: 30 * time.Second, MaxLockout: time.Hour, ResetAfter: 24 * time.Hour}
+	IPPolicy      = Policy{FreeAttempts: 20, BaseLockout: 30 * time.Second, MaxLockout: time.Hour, ResetAfter: 24 * time.Hour}
+)
+
+// Lockout returns how long a key is locked for after the given number of failures.
+func (p Policy) Lockout(failures int) time.Duration {
+	over := failures - p.FreeAttempts
+	if over <= 0 {
+		return 0
+	}
+
+	lockout := p.BaseLockout
+	for i := 1; i < over && lockout < p.MaxLockout; i++ {
+		lockout *= 2
+	}
+	if lockout > p.MaxLockout {
+		lockout = p.MaxLockout
+	}
+	return lockout
+}
+
+func AccountKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func IPKey(ip string) string {
+	return "ip:" + ip
+}
+
+// Limiter applies a policy to the attempts kept in a store.
+type Limiter struct {
+	Store Store
+}
+
+// RetryAfter returns how long the caller has to wait before the keys can be used again, zero if none of them is locked.
+func (l *Limiter) RetryAfter(keys ...string) (time.Duration, error) {
+	now := time.Now()
+	var retryAfter time.Duration
+
+	for _, key := range keys {
+		attempt, err := l.Store.Get(key)
+		if err != nil {
+			return 0, err
+		}
+		if wait := attempt.LockedUntil.Sub(now); wait > retryAfter {
+			retryAfter = wait
+		}
+	}
+
+	return retryAfter, nil
+}
+
+func (l *Limiter) RecordFailure(key string, policy Policy) error {
+	now := time.Now()
+	_, err := l.Store.RecordFailure(key, now, func(failures int) time.Duration {
+		return policy.Lockout(failures)
+	})
+	return err
+}
+
+func (l *Limiter) Reset(key string) error {
+	return l.Store.Reset(key)
+}
+
+var (
+	defaultLimiter *Limiter
+	once           sync.Once
+)
+
+// Default returns the limiter used for logins, with the store picked by the LOGIN_ATTEMPT_STORE environment variable.
+func Default() *Limiter {
+	once.Do(func() {
+		switch store := os.Getenv("LOGIN_ATTEMPT_STORE"); store {
+		case "memory":
+			defaultLimiter = &Limiter{Store: NewMemoryStore(AccountPolicy.ResetAfter)}
+		case "", "database":
+			defaultLimiter = &Limiter{Store: NewDatabaseStore(AccountPolicy.ResetAfter)}
+		default:
+			log.Printf("Warning: Unknown login attempt store %q, using the database", store)
+			defaultLimiter = &Limiter{Store: NewDatabaseStore(AccountPolicy.ResetAfter)}
+		}
+	})
+	return defaultLimiter
+}
+
+// SetDefault replaces the limiter returned by Default, for example with an in-memory store in tests.
+func SetDefault(limiter *Limiter) {
+	once.Do(func() {})
+	defaultLimiter = limiter
+}
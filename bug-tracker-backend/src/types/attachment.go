@@ -0,0 +1,18 @@
+package types
+
+import "time"
+
+type AttachmentURI struct {
+	AttachmentID uint `uri:"attachmentID" binding:"required"`
+}
+
+type AttachmentResponse struct {
+	ID          uint       `json:"id"`
+	BugID       uint       `json:"bug_id"`
+	Filename    string     `json:"filename"`
+	ContentType string     `json:"content_type"`
+	Size        int64      `json:"size"`
+	Checksum    string     `json:"checksum"` // SHA-256 of the content
+	UploadedBy  AssignedTo `json:"uploaded_by"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
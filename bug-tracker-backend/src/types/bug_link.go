@@ -0,0 +1,47 @@
+package types
+
+import "time"
+
+type BugLinkType string
+
+const (
+	BugLinkTypeDuplicates BugLinkType = "duplicates" // The bug reports the same problem as the linked bug, and is closed
+	BugLinkTypeBlocks     BugLinkType = "blocks"     // The linked bug cannot be fixed before the bug
+	BugLinkTypeRelatesTo  BugLinkType = "relates_to"
+)
+
+func (t BugLinkType) Value() string {
+	return string(t)
+}
+
+// BugLinkDirection tells how a link reads from the bug it is listed on.
+type BugLinkDirection string
+
+const (
+	BugLinkDirectionOutward BugLinkDirection = "outward" // The bug is the source, such as the duplicate or the blocker
+	BugLinkDirectionInward  BugLinkDirection = "inward"  // The bug is the target, such as the original or the blocked bug
+)
+
+type CreateBugLink struct {
+	Type   BugLinkType `json:"type" binding:"required,oneof=duplicates blocks relates_to"`
+	Target string      `json:"target" binding:"required"` // ID or key of a bug of the same project
+}
+
+type BugLinkURI struct {
+	LinkID uint `uri:"linkID" binding:"required"`
+}
+
+type LinkedBug struct {
+	ID     uint      `json:"id"`
+	Key    string    `json:"key"`
+	Title  string    `json:"title"`
+	Status BugStatus `json:"status"`
+}
+
+type BugLinkResponse struct {
+	ID        uint             `json:"id"`
+	Type      BugLinkType      `json:"type"`
+	Direction BugLinkDirection `json:"direction"`
+	Bug       LinkedBug        `json:"bug"` // The other bug of the link
+	CreatedAt time.Time        `json:"created_at"`
+}
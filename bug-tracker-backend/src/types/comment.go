@@ -0,0 +1,37 @@
+package types
+
+import "time"
+
+type CreateComment struct {
+	Body     string `json:"body" binding:"required,max=10000"` // Markdown
+	ParentID *uint  `json:"parent_id" binding:"omitempty"`     // Comment being replied to
+}
+
+type UpdateComment struct {
+	Body string `json:"body" binding:"required,max=10000"`
+}
+
+type CommentURI struct {
+	CommentID uint `uri:"commentID" binding:"required"`
+}
+
+type CommentResponse struct {
+	ID        uint              `json:"id"`
+	BugID     uint              `json:"bug_id"`
+	ParentID  *uint             `json:"parent_id"`
+	Author    AssignedTo        `json:"author"`
+	Body      string            `json:"body"`
+	BodyHTML  string            `json:"body_html"`
+	Deleted   bool              `json:"deleted"` // Deleted comments are only listed to keep their replies in the thread, without their body
+	EditedAt  *time.Time        `json:"edited_at"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+	Replies   []CommentResponse `json:"replies,omitempty"`
+}
+
+type CommentRevisionResponse struct {
+	ID        uint      `json:"id"`
+	Body      string    `json:"body"`
+	BodyHTML  string    `json:"body_html"`
+	CreatedAt time.Time `json:"created_at"` // When the body was replaced
+}
@@ -0,0 +1,61 @@
+package types
+
+import "time"
+
+type CustomFieldType string
+
+const (
+	CustomFieldTypeText        CustomFieldType = "text"
+	CustomFieldTypeNumber      CustomFieldType = "number"
+	CustomFieldTypeDate        CustomFieldType = "date" // YYYY-MM-DD
+	CustomFieldTypeSelect      CustomFieldType = "select"
+	CustomFieldTypeMultiSelect CustomFieldType = "multi_select"
+	CustomFieldTypeUser        CustomFieldType = "user" // ID of a member of the project
+)
+
+func (t CustomFieldType) Value() string {
+	return string(t)
+}
+
+// HasOptions reports whether values of the type are picked from the options of the field.
+func (t CustomFieldType) HasOptions() bool {
+	return t == CustomFieldTypeSelect || t == CustomFieldTypeMultiSelect
+}
+
+type CreateCustomField struct {
+	Key      string          `json:"key" binding:"required,max=40"` // Lowercase letters, digits and underscores, starting with a letter
+	Name     string          `json:"name" binding:"required,max=100"`
+	Type     CustomFieldType `json:"type" binding:"required,oneof=text number date select multi_select user"`
+	Options  []string        `json:"options" binding:"omitempty,dive,required,max=100"` // Required by select and multi_select fields
+	Required bool            `json:"required"`
+	Position int             `json:"position"`
+}
+
+// UpdateCustomField changes how a field is displayed and validated. The key and the type cannot change,
+// since the values already stored on bugs depend on them.
+type UpdateCustomField struct {
+	Name     *string   `json:"name" binding:"omitempty,max=100"`
+	Options  *[]string `json:"options" binding:"omitempty,dive,required,max=100"`
+	Required *bool     `json:"required" binding:"omitempty"`
+	Position *int      `json:"position" binding:"omitempty"`
+}
+
+type CustomFieldURI struct {
+	FieldID uint `uri:"fieldID" binding:"required"`
+}
+
+type CustomFieldResponse struct {
+	ID        uint            `json:"id"`
+	Key       string          `json:"key"`
+	Name      string          `json:"name"`
+	Type      CustomFieldType `json:"type"`
+	Options   []string        `json:"options"`
+	Required  bool            `json:"required"`
+	Position  int             `json:"position"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+type CustomFieldOptionsInUseResponse struct {
+	Options []string `json:"options"` // Options removed from the field that bugs still have
+}
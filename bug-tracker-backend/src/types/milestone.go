@@ -0,0 +1,78 @@
+package types
+
+import "time"
+
+type MilestoneState string
+
+const (
+	MilestoneStatePlanned   MilestoneState = "planned"
+	MilestoneStateActive    MilestoneState = "active"
+	MilestoneStateCompleted MilestoneState = "completed"
+)
+
+func (m MilestoneState) Value() string {
+	return string(m)
+}
+
+type CreateMilestone struct {
+	Title       string         `json:"title" binding:"required,max=100"`
+	Description string         `json:"description" binding:"omitempty"`
+	StartDate   time.Time      `json:"start_date" binding:"required"`
+	EndDate     time.Time      `json:"end_date" binding:"required"`
+	State       MilestoneState `json:"state" binding:"omitempty,oneof=planned active completed"` // planned if omitted
+}
+
+type UpdateMilestone struct {
+	Title       *string         `json:"title" binding:"omitempty,max=100"`
+	Description *string         `json:"description" binding:"omitempty"`
+	StartDate   *time.Time      `json:"start_date" binding:"omitempty"`
+	EndDate     *time.Time      `json:"end_date" binding:"omitempty"`
+	State       *MilestoneState `json:"state" binding:"omitempty,oneof=planned active completed"`
+}
+
+type MilestoneURI struct {
+	MilestoneID uint `uri:"milestoneID" binding:"required"`
+}
+
+type MilestoneListQueryParams struct {
+	*PaginationQueryParams
+	State *string `form:"state" binding:"omitempty,oneof=planned active completed"`
+}
+
+type MilestoneResponse struct {
+	ID          uint           `json:"id"`
+	ProjectID   uint           `json:"project_id"`
+	Title       string         `json:"title"`
+	Description string         `json:"description"`
+	StartDate   time.Time      `json:"start_date"`
+	EndDate     time.Time      `json:"end_date"`
+	State       MilestoneState `json:"state"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+type MilestoneBugCounts struct {
+	Total  int64 `json:"total"`
+	Open   int64 `json:"open"`   // Bugs that are not in a closed state of the workflow
+	Closed int64 `json:"closed"` // Bugs in a closed state of the workflow
+}
+
+type MilestoneDetailResponse struct {
+	MilestoneResponse
+	Bugs MilestoneBugCounts `json:"bugs"`
+}
+
+// BurndownPoint is the state of the bugs of a milestone at the end of a day.
+type BurndownPoint struct {
+	Date      string  `json:"date"` // YYYY-MM-DD, in UTC
+	Remaining int64   `json:"remaining"`
+	Closed    int64   `json:"closed"`
+	Ideal     float64 `json:"ideal"` // Remaining bugs if they were closed at a steady pace from the start to the end of the milestone
+}
+
+type MilestoneBurndownResponse struct {
+	MilestoneID uint            `json:"milestone_id"`
+	StartDate   string          `json:"start_date"`
+	EndDate     string          `json:"end_date"`
+	Points      []BurndownPoint `json:"points"` // One point per day from the start of the milestone to today or its end
+}
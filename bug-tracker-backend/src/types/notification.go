@@ -0,0 +1,76 @@
+package types
+
+import (
+	"time"
+
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/events"
+)
+
+type NotificationListQueryParams struct {
+	*PaginationQueryParams
+	Unread *bool `form:"unread"` // Only the notifications that have not been read
+}
+
+type NotificationURI struct {
+	NotificationID uint `uri:"notificationID" binding:"required"`
+}
+
+type NotificationResponse struct {
+	ID        uint        `json:"id"`
+	Event     events.Type `json:"event"`
+	Title     string      `json:"title"`
+	Body      string      `json:"body"`
+	ProjectID uint        `json:"project_id"`
+	BugID     *uint       `json:"bug_id"`
+	BugKey    string      `json:"bug_key,omitempty"`
+	Actor     *AssignedTo `json:"actor"` // Not set for the events raised by the server, such as deadlines
+	Read      bool        `json:"read"`
+	ReadAt    *time.Time  `json:"read_at"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+type UnreadNotificationsResponse struct {
+	Unread int64 `json:"unread"`
+}
+
+type NotificationPreferenceRequest struct {
+	Event events.Type `json:"event" binding:"required,oneof=bug.assigned bug.status_changed bug.deadline_approaching comment.created comment.mention"`
+	InApp *bool       `json:"in_app" binding:"required"`
+}
+
+type UpdateNotificationPreferences struct {
+	Preferences []NotificationPreferenceRequest `json:"preferences" binding:"required,min=1,dive"`
+}
+
+type NotificationPreferenceResponse struct {
+	Event events.Type `json:"event"`
+	InApp bool        `json:"in_app"`
+}
+
+type EmailFrequency string
+
+const (
+	EmailFrequencyOff       EmailFrequency = "off"
+	EmailFrequencyImmediate EmailFrequency = "immediate" // Assignments, mentions and deadlines are emailed as they happen
+	EmailFrequencyDaily     EmailFrequency = "daily"     // A digest of the unread notifications every day
+	EmailFrequencyWeekly    EmailFrequency = "weekly"    // A digest of the unread notifications every Monday
+)
+
+func (f EmailFrequency) Value() string {
+	return string(f)
+}
+
+type UpdateEmailNotificationSettings struct {
+	Frequency EmailFrequency `json:"frequency" binding:"required,oneof=off immediate daily weekly"`
+}
+
+type EmailNotificationSettingsResponse struct {
+	Frequency       EmailFrequency `json:"frequency"`
+	ImmediateEvents []events.Type  `json:"immediate_events"` // Types of event emailed as they happen
+	EmailVerified   bool           `json:"email_verified"`   // Emails are only sent to verified addresses
+	LastDigestAt    *time.Time     `json:"last_digest_at"`
+}
+
+type UnsubscribeQueryParams struct {
+	Token string `form:"token" binding:"required"`
+}
@@ -0,0 +1,74 @@
+package types
+
+type Permission string
+
+const (
+	PermissionProjectUpdate    Permission = "project.update"
+	PermissionProjectDelete    Permission = "project.delete"
+	PermissionBugCreate        Permission = "bug.create"
+	PermissionBugUpdate        Permission = "bug.update"
+	PermissionBugAssign        Permission = "bug.assign" // Assigning a bug to someone other than oneself
+	PermissionBugClose         Permission = "bug.close"  // Moving a bug into or out of a closed workflow state
+	PermissionBugDelete        Permission = "bug.delete"
+	PermissionBugRestore       Permission = "bug.restore"
+	PermissionBugPurge         Permission = "bug.purge"
+	PermissionTeamManage       Permission = "team.manage"
+	PermissionMilestoneManage  Permission = "milestone.manage"
+	PermissionWebhookManage    Permission = "webhook.manage"
+	PermissionCommentDelete    Permission = "comment.delete"    // Deleting comments written by someone else
+	PermissionAttachmentDelete Permission = "attachment.delete" // Deleting files uploaded by someone else
+)
+
+func (p Permission) Value() string {
+	return string(p)
+}
+
+// RolePermissions maps every team role to the actions it is allowed to perform on a project.
+var RolePermissions = map[TeamRole][]Permission{
+	TeamRoleAdmin: {
+		PermissionProjectUpdate,
+		PermissionProjectDelete,
+		PermissionBugCreate,
+		PermissionBugUpdate,
+		PermissionBugAssign,
+		PermissionBugClose,
+		PermissionBugDelete,
+		PermissionBugRestore,
+		PermissionBugPurge,
+		PermissionTeamManage,
+		PermissionMilestoneManage,
+		PermissionWebhookManage,
+		PermissionCommentDelete,
+		PermissionAttachmentDelete,
+	},
+	TeamRoleDeveloper: {
+		PermissionBugCreate,
+		PermissionBugUpdate,
+		PermissionBugAssign,
+		PermissionBugClose,
+		PermissionBugDelete,
+		PermissionBugRestore,
+	},
+	TeamRoleTester: {
+		PermissionBugCreate,
+		PermissionBugUpdate,
+		PermissionBugClose,
+	},
+}
+
+// HasPermission reports whether the role is allowed to perform the action.
+func (t TeamRole) HasPermission(permission Permission) bool {
+	for _, p := range RolePermissions[t] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+const PermissionDeniedMessage = "You do not have permission to perform this action"
+
+type PermissionDeniedResponse struct {
+	Permission Permission `json:"permission"`
+	Role       TeamRole   `json:"role"`
+}
@@ -1,27 +1,29 @@
 package types
 
-import (
-	"time"
-
-	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/utils"
-)
+import "time"
 
 type CreateProject struct {
 	Title       string `json:"title" binding:"required"`
+	Key         string `json:"key" binding:"omitempty,alphanum,min=2,max=10"` // Generated from the title if not set
 	Description string `json:"description" binding:"omitempty"`
+
+	AllowCloseWithOpenChildren bool `json:"allow_close_with_open_children"`
 }
 
 type ProjectResponse struct {
 	ID          int       `json:"id"`
 	Title       string    `json:"title"`
+	Key         string    `json:"key"`
 	Description string    `json:"description"`
 	CreatedBy   int       `json:"created_by"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+
+	AllowCloseWithOpenChildren bool `json:"allow_close_with_open_children"`
 }
 
 type ProjectListQueryParams struct {
-	*utils.PaginationQueryParams
+	*PaginationQueryParams
 	Search *string `form:"search"`
 }
 
@@ -33,10 +35,27 @@ const (
 	TeamRoleTester    TeamRole = "tester"
 )
 
+// TeamRoles lists every team role, from the most senior.
+var TeamRoles = []TeamRole{TeamRoleAdmin, TeamRoleDeveloper, TeamRoleTester}
+
 func (t TeamRole) Value() string {
 	return string(t)
 }
 
+// Rank returns the seniority of the role, the higher the rank, the more senior the role.
+func (t TeamRole) Rank() int {
+	switch t {
+	case TeamRoleAdmin:
+		return 3
+	case TeamRoleDeveloper:
+		return 2
+	case TeamRoleTester:
+		return 1
+	default:
+		return 0
+	}
+}
+
 type Priority uint
 
 const (
@@ -49,6 +68,7 @@ func (p Priority) Value() uint {
 	return uint(p)
 }
 
+// BugStatus is the key of a state of the workflow of the project. The constants are the states of the default workflow.
 type BugStatus string
 
 const (
@@ -70,17 +90,18 @@ type CreateBug struct {
 	Description string    `json:"description" binding:"omitempty"`
 	Tags        []string  `json:"tags" binding:"omitempty"`
 	Deadline    time.Time `json:"deadline" binding:"required"`
-	Status      BugStatus `json:"status" binding:"omitempty,oneof=todo in_progress done"`
+	Status      BugStatus `json:"status" binding:"omitempty,max=50"`        // Initial state of the workflow if omitted
 	Priority    Priority  `json:"priority" binding:"omitempty,oneof=1 2 3"` // 1: High, 2: Medium, 3: Low
 	AssignedTo  uint      `json:"assigned_to" binding:"required"`
+	Parent      string    `json:"parent" binding:"omitempty"` // ID or key of the bug this one is a sub-task of
+	MilestoneID *uint     `json:"milestone_id" binding:"omitempty"`
+
+	CustomFields map[string]any `json:"custom_fields" binding:"omitempty"` // Values by field key
 }
 
 // SetDefaults sets default values for CreateBug fields if they are omitted.
+// The default status depends on the workflow of the project, so it is set by the controller.
 func (c *CreateBug) SetDefaults() {
-	if c.Status == "" {
-		c.Status = BugStatusTodo
-	}
-
 	if c.Priority == 0 {
 		c.Priority = PriorityHigh
 	}
@@ -94,6 +115,8 @@ type AssignedTo struct {
 
 type BugResponse struct {
 	ID          uint       `json:"id"`
+	Key         string     `json:"key"` // Such as PROJ-42
+	Number      uint       `json:"number"`
 	Title       string     `json:"title"`
 	Description string     `json:"description"`
 	Tags        []string   `json:"tags"`
@@ -102,25 +125,84 @@ type BugResponse struct {
 	Priority    Priority   `json:"priority"`
 	AssignedTo  AssignedTo `json:"assigned_to"`
 	ProjectID   uint       `json:"project_id"`
+	ParentID    *uint      `json:"parent_id"`
+	MilestoneID *uint      `json:"milestone_id"`
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
+
+	CustomFields map[string]any `json:"custom_fields"` // Values by field key, fields without a value are omitted
+
+	Progress *BugProgress      `json:"progress,omitempty"` // Only set on bugs that have sub-tasks
+	Links    []BugLinkResponse `json:"links,omitempty"`    // Only set on the response of a single bug
+}
+
+// BugProgress rolls up the statuses of the sub-tasks of a bug.
+type BugProgress struct {
+	Total   int64 `json:"total"`
+	Closed  int64 `json:"closed"`
+	Percent int   `json:"percent"`
 }
 
 type BugURI struct {
-	BugID uint `uri:"bugID" binding:"required"`
+	BugID string `uri:"bugID" binding:"required"` // Either the ID or the key of the bug
+}
+
+type UpdateBug struct {
+	Title       *string    `json:"title" binding:"omitempty"`
+	Description *string    `json:"description" binding:"omitempty"`
+	Tags        *[]string  `json:"tags" binding:"omitempty"`
+	Deadline    *time.Time `json:"deadline" binding:"omitempty"`
+	Status      *BugStatus `json:"status" binding:"omitempty,max=50"`
+	Priority    *Priority  `json:"priority" binding:"omitempty,oneof=1 2 3"` // 1: High, 2: Medium, 3: Low
+	AssignedTo  *uint      `json:"assigned_to" binding:"omitempty"`
+	Parent      *string    `json:"parent" binding:"omitempty"`       // ID or key of the parent bug, an empty string makes the bug top level
+	MilestoneID *uint      `json:"milestone_id" binding:"omitempty"` // 0 removes the bug from its milestone
+
+	CustomFields map[string]any `json:"custom_fields" binding:"omitempty"` // Only the listed fields change, null removes the value of a field
+}
+
+type TrashedBugResponse struct {
+	BugResponse
+	DeletedAt time.Time `json:"deleted_at"`
+	PurgeAt   time.Time `json:"purge_at"`
+}
+
+type BugHistoryResponse struct {
+	ID        uint       `json:"id"`
+	Field     string     `json:"field"`
+	OldValue  string     `json:"old_value"`
+	NewValue  string     `json:"new_value"`
+	ChangedBy AssignedTo `json:"changed_by"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// BugFieldChange is a change listed in the payload of the bug.updated webhook event.
+type BugFieldChange struct {
+	Field    string `json:"field"`
+	OldValue string `json:"old_value"`
+	NewValue string `json:"new_value"`
 }
 
 type UpdateProject struct {
 	Title       *string `json:"title" binding:"omitempty"`
+	Key         *string `json:"key" binding:"omitempty,alphanum,min=2,max=10"`
 	Description *string `json:"description" binding:"omitempty"`
+
+	AllowCloseWithOpenChildren *bool `json:"allow_close_with_open_children" binding:"omitempty"`
 }
 
+// BugListQueryParams filters the bugs of a project. Custom fields are filtered with cf.<key> parameters,
+// which cannot be bound to the struct since the keys depend on the project.
 type BugListQueryParams struct {
-	*utils.PaginationQueryParams
+	*PaginationQueryParams
 	Search     *string `form:"search"`
 	Tags       *string `form:"tags"`
 	Deadline   *string `form:"deadline"`
-	Status     *string `form:"status" binding:"omitempty,oneof=todo in_progress done"`
+	Status     *string `form:"status" binding:"omitempty,max=50"`
+	Category   *string `form:"category" binding:"omitempty,oneof=open active closed"` // Category of the workflow state of the bugs
 	Priority   *int    `form:"priority" binding:"omitempty,oneof=1 2 3"`
 	AssignedTo *uint   `form:"assigned_to"`
+	Parent     *string `form:"parent"`    // ID or key of the bug whose sub-tasks are listed
+	TopLevel   *bool   `form:"top_level"` // Only bugs that are not sub-tasks
+	Milestone  *uint   `form:"milestone"` // ID of the milestone of the bugs, 0 for bugs without a milestone
 }
@@ -0,0 +1,8 @@
+package types
+
+import "time"
+
+type StreamTicketResponse struct {
+	Ticket    string    `json:"ticket"` // Passed as the ticket query parameter of the event stream
+	ExpiresAt time.Time `json:"expires_at"`
+}
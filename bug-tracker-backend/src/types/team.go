@@ -0,0 +1,84 @@
+package types
+
+import "time"
+
+type AddTeamMember struct {
+	Email    *string  `json:"email" binding:"required_without=Username,omitempty,email"`
+	Username *string  `json:"username" binding:"required_without=Email,omitempty"`
+	Role     TeamRole `json:"role" binding:"required,oneof=admin dev tester"`
+}
+
+type TeamMemberResponse struct {
+	ID        uint      `json:"id"`
+	UserID    uint      `json:"user_id"`
+	Name      string    `json:"name"`
+	Username  string    `json:"username"`
+	Email     string    `json:"email"`
+	Role      TeamRole  `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type TeamListQueryParams struct {
+	*PaginationQueryParams
+	Search *string `form:"search"`
+	Role   *string `form:"role" binding:"omitempty,oneof=admin dev tester"`
+}
+
+type TeamActionType string
+
+const (
+	TeamActionPromote TeamActionType = "promote"
+	TeamActionDemote  TeamActionType = "demote"
+	TeamActionRemove  TeamActionType = "remove"
+	TeamActionLeave   TeamActionType = "leave"
+)
+
+func (a TeamActionType) Value() string {
+	return string(a)
+}
+
+type TeamAction struct {
+	Action TeamActionType `json:"action" binding:"required,oneof=promote demote remove leave"`
+	UserID uint           `json:"user_id" binding:"required_unless=Action leave"`
+	Role   *TeamRole      `json:"role" binding:"omitempty,oneof=admin dev tester"` // Role to promote or demote to, defaults to the next role in seniority
+}
+
+type InvitationStatus string
+
+const (
+	InvitationStatusPending  InvitationStatus = "pending"
+	InvitationStatusAccepted InvitationStatus = "accepted"
+	InvitationStatusDeclined InvitationStatus = "declined"
+	InvitationStatusRevoked  InvitationStatus = "revoked"
+)
+
+func (s InvitationStatus) Value() string {
+	return string(s)
+}
+
+type InviteTeamMember struct {
+	Email string   `json:"email" binding:"required,email"`
+	Role  TeamRole `json:"role" binding:"required,oneof=admin dev tester"`
+}
+
+type InvitationToken struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type InvitationURI struct {
+	InvitationID uint `uri:"invitationID" binding:"required"`
+}
+
+type InvitationResponse struct {
+	ID           uint             `json:"id"`
+	ProjectID    uint             `json:"project_id"`
+	ProjectTitle string           `json:"project_title"`
+	Email        string           `json:"email"`
+	Role         TeamRole         `json:"role"`
+	Status       InvitationStatus `json:"status"`
+	InvitedBy    AssignedTo       `json:"invited_by"`
+	Token        string           `json:"token,omitempty"` // Only returned when the invitation is created
+	ExpiresAt    time.Time        `json:"expires_at"`
+	CreatedAt    time.Time        `json:"created_at"`
+}
@@ -0,0 +1,47 @@
+package types
+
+import "time"
+
+type TokenScope string
+
+const (
+	TokenScopeProjectsRead  TokenScope = "projects:read"
+	TokenScopeProjectsWrite TokenScope = "projects:write"
+	TokenScopeTeamRead      TokenScope = "team:read"
+	TokenScopeTeamWrite     TokenScope = "team:write"
+	TokenScopeBugsRead      TokenScope = "bugs:read"
+	TokenScopeBugsWrite     TokenScope = "bugs:write"
+	TokenScopeUserRead      TokenScope = "user:read"
+	TokenScopeUserWrite     TokenScope = "user:write"
+)
+
+func (s TokenScope) Value() string {
+	return string(s)
+}
+
+type CreatePersonalAccessToken struct {
+	Name          string       `json:"name" binding:"required,max=100"`
+	Scopes        []TokenScope `json:"scopes" binding:"required,min=1,dive,oneof=projects:read projects:write team:read team:write bugs:read bugs:write user:read user:write"`
+	ExpiresInDays *int         `json:"expires_in_days" binding:"omitempty,min=1,max=365"` // The token never expires if omitted
+}
+
+type PersonalAccessTokenURI struct {
+	TokenID uint `uri:"tokenID" binding:"required"`
+}
+
+type PersonalAccessTokenResponse struct {
+	ID         uint         `json:"id"`
+	Name       string       `json:"name"`
+	Prefix     string       `json:"prefix"`
+	Scopes     []TokenScope `json:"scopes"`
+	Token      string       `json:"token,omitempty"` // Only returned when the token is created
+	ExpiresAt  *time.Time   `json:"expires_at"`
+	LastUsedAt *time.Time   `json:"last_used_at"`
+	CreatedAt  time.Time    `json:"created_at"`
+}
+
+const ScopeDeniedMessage = "Access token does not have the required scope"
+
+type ScopeDeniedResponse struct {
+	Scope TokenScope `json:"scope"`
+}
@@ -7,6 +7,8 @@ type SignUpUser struct {
 	Email    string `json:"email" binding:"required,email"`
 	Username string `json:"username,omitempty" binding:"omitempty,alphanum,min=3,max=20"`
 	Password string `json:"password" binding:"required,min=8"`
+
+	InvitationToken *string `json:"invitation_token,omitempty" binding:"omitempty"` // Joins the project of the invitation on sign up
 }
 
 type LoginUser struct {
@@ -14,13 +16,43 @@ type LoginUser struct {
 	Password string `json:"password" binding:"required"`
 }
 
+type TokenResponse struct {
+	Type             string    `json:"type"`
+	Token            string    `json:"token"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+type LoginChallengeResponse struct {
+	Type           string    `json:"type"`
+	ChallengeToken string    `json:"challenge_token"`
+	ExpiresAt      time.Time `json:"expires_at"`
+}
+
+type LoginTwoFactor struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required_without=RecoveryCode,omitempty,len=6,numeric"`
+	RecoveryCode   string `json:"recovery_code" binding:"required_without=Code"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type LogoutUser struct {
+	RefreshToken *string `json:"refresh_token" binding:"omitempty"`
+	AllSessions  bool    `json:"all_sessions"` // Revokes the refresh tokens of every session of the user
+}
+
 type UserResponse struct {
-	ID        uint      `json:"id"`
-	Name      string    `json:"name"`
-	Username  string    `json:"username"`
-	Email     string    `json:"email"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID              uint       `json:"id"`
+	Name            string     `json:"name"`
+	Username        string     `json:"username"`
+	Email           string     `json:"email"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
 }
 
 type UserBugsProject struct {
@@ -30,6 +62,7 @@ type UserBugsProject struct {
 
 type UserBugsResponse struct {
 	ID        uint            `json:"id"`
+	Key       string          `json:"key"`
 	Title     string          `json:"title"`
 	Status    BugStatus       `json:"status"`
 	Priority  Priority        `json:"priority"`
@@ -43,3 +76,106 @@ type UpdateUser struct {
 	Username *string `json:"username,omitempty" binding:"omitempty,alphanum,min=3,max=20"`
 	Password *string `json:"password" binding:"omitempty,min=8"`
 }
+
+type VerifyEmail struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type ForgotPassword struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPassword struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=8"`
+}
+
+type TwoFactorEnrollResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type TwoFactorCode struct {
+	Code string `json:"code" binding:"required,len=6,numeric"`
+}
+
+type DisableTwoFactor struct {
+	Password     string `json:"password" binding:"required"`
+	Code         string `json:"code" binding:"required_without=RecoveryCode,omitempty,len=6,numeric"`
+	RecoveryCode string `json:"recovery_code" binding:"required_without=Code"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type RequestAccountUnlock struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type UnlockAccount struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type ProjectTransfer struct {
+	ProjectID uint `json:"project_id" binding:"required"`
+	UserID    uint `json:"user_id" binding:"required"` // Team member who becomes admin and takes over the bugs assigned to the deleted user
+}
+
+type DeleteUser struct {
+	Password     string            `json:"password" binding:"required"`
+	Code         string            `json:"code" binding:"omitempty,len=6,numeric"` // Required, along with RecoveryCode as an alternative, if two-factor authentication is enabled
+	RecoveryCode string            `json:"recovery_code" binding:"omitempty"`
+	Transfers    []ProjectTransfer `json:"transfers" binding:"omitempty,dive"`
+}
+
+type BlockingProject struct {
+	ID    uint   `json:"id"`
+	Title string `json:"title"`
+}
+
+type ExportedProfile struct {
+	ID              uint       `json:"id"`
+	Name            string     `json:"name"`
+	Username        string     `json:"username"`
+	Email           string     `json:"email"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	TOTPEnabledAt   *time.Time `json:"totp_enabled_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+type ExportedTeamMembership struct {
+	ProjectID    uint      `json:"project_id"`
+	ProjectTitle string    `json:"project_title"`
+	Role         TeamRole  `json:"role"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type ExportedBug struct {
+	BugResponse
+	DeletedAt *time.Time `json:"deleted_at"` // Set for bugs that are in the trash
+}
+
+type ExportedComment struct {
+	ID        uint                      `json:"id"`
+	BugID     uint                      `json:"bug_id"`
+	ParentID  *uint                     `json:"parent_id"`
+	Body      string                    `json:"body"`
+	EditedAt  *time.Time                `json:"edited_at"`
+	CreatedAt time.Time                 `json:"created_at"`
+	UpdatedAt time.Time                 `json:"updated_at"`
+	DeletedAt *time.Time                `json:"deleted_at"` // Set for deleted comments, which are kept while they have replies
+	Revisions []ExportedCommentRevision `json:"revisions"`  // Bodies the comment had before its edits, oldest first
+}
+
+type ExportedCommentRevision struct {
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"` // When the body was replaced
+}
+
+type ExportedNotificationSettings struct {
+	Preferences []NotificationPreferenceResponse  `json:"preferences"`
+	Email       EmailNotificationSettingsResponse `json:"email"`
+}
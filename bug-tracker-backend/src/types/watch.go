@@ -0,0 +1,47 @@
+package types
+
+import "time"
+
+type WatchReason string
+
+const (
+	WatchReasonManual    WatchReason = "manual"
+	WatchReasonCreated   WatchReason = "created"   // Created the bug
+	WatchReasonAssigned  WatchReason = "assigned"  // Was assigned the bug
+	WatchReasonCommented WatchReason = "commented" // Commented on the bug
+)
+
+// WatchTarget tells whether a user watches a single bug or every bug of a project.
+type WatchTarget string
+
+const (
+	WatchTargetBug     WatchTarget = "bug"
+	WatchTargetProject WatchTarget = "project"
+)
+
+type WatchListQueryParams struct {
+	*PaginationQueryParams
+	Target *string `form:"target" binding:"omitempty,oneof=bug project"`
+}
+
+type WatchedProject struct {
+	ID    uint   `json:"id"`
+	Key   string `json:"key"`
+	Title string `json:"title"`
+}
+
+type WatchResponse struct {
+	ID        uint           `json:"id"`
+	Target    WatchTarget    `json:"target"`
+	Project   WatchedProject `json:"project"`
+	Bug       *LinkedBug     `json:"bug"` // Not set when the whole project is watched
+	Reason    WatchReason    `json:"reason"`
+	Muted     bool           `json:"muted"` // The bug is excluded from the notifications of the watched project
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+type WatcherResponse struct {
+	User   AssignedTo  `json:"user"`
+	Via    WatchTarget `json:"via"` // Whether the user watches the bug itself or its project
+	Reason WatchReason `json:"reason"`
+}
@@ -0,0 +1,103 @@
+package types
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/events"
+)
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryStatusPending   WebhookDeliveryStatus = "pending" // Waiting for its first attempt or for a retry
+	WebhookDeliveryStatusSucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryStatusFailed    WebhookDeliveryStatus = "failed" // Every attempt failed
+)
+
+func (s WebhookDeliveryStatus) Value() string {
+	return string(s)
+}
+
+type CreateWebhook struct {
+	URL    string        `json:"url" binding:"required,url,max=2048"`
+	Events []events.Type `json:"events" binding:"required,min=1,dive,oneof=bug.created bug.updated bug.deleted bug.restored bug.assigned bug.status_changed bug.deadline_approaching comment.created team.member_added team.member_updated team.member_removed"`
+}
+
+type UpdateWebhook struct {
+	URL          *string        `json:"url" binding:"omitempty,url,max=2048"`
+	Events       *[]events.Type `json:"events" binding:"omitempty,min=1,dive,oneof=bug.created bug.updated bug.deleted bug.restored bug.assigned bug.status_changed bug.deadline_approaching comment.created team.member_added team.member_updated team.member_removed"`
+	Active       *bool          `json:"active" binding:"omitempty"` // Enabling the webhook again resets its count of failures
+	RotateSecret bool           `json:"rotate_secret"`              // Replaces the secret, which is returned once in the response
+}
+
+type WebhookURI struct {
+	WebhookID uint `uri:"webhookID" binding:"required"`
+}
+
+type WebhookDeliveryURI struct {
+	DeliveryID uint `uri:"deliveryID" binding:"required"`
+}
+
+type WebhookDeliveryListQueryParams struct {
+	*PaginationQueryParams
+	Status *string `form:"status" binding:"omitempty,oneof=pending succeeded failed"`
+	Event  *string `form:"event" binding:"omitempty,max=50"`
+}
+
+type WebhookResponse struct {
+	ID             uint          `json:"id"`
+	ProjectID      uint          `json:"project_id"`
+	URL            string        `json:"url"`
+	Events         []events.Type `json:"events"`
+	Active         bool          `json:"active"`
+	FailureCount   int           `json:"failure_count"`
+	DisabledReason string        `json:"disabled_reason,omitempty"`
+	CreatedBy      uint          `json:"created_by"`
+	CreatedAt      time.Time     `json:"created_at"`
+	UpdatedAt      time.Time     `json:"updated_at"`
+}
+
+// WebhookSecretResponse is returned when the secret is created or rotated, the only times it can be read.
+type WebhookSecretResponse struct {
+	WebhookResponse
+	Secret string `json:"secret"`
+}
+
+type WebhookDeliveryResponse struct {
+	ID             uint                  `json:"id"`
+	WebhookID      uint                  `json:"webhook_id"`
+	EventID        string                `json:"event_id"`
+	Event          events.Type           `json:"event"`
+	Status         WebhookDeliveryStatus `json:"status"`
+	Attempts       int                   `json:"attempts"`
+	NextAttemptAt  *time.Time            `json:"next_attempt_at"`
+	ResponseStatus *int                  `json:"response_status"`
+	Error          string                `json:"error,omitempty"`
+	DurationMs     int64                 `json:"duration_ms"`
+	DeliveredAt    *time.Time            `json:"delivered_at"`
+	RedeliveryOfID *uint                 `json:"redelivery_of_id"`
+	CreatedAt      time.Time             `json:"created_at"`
+}
+
+type WebhookDeliveryDetailResponse struct {
+	WebhookDeliveryResponse
+	Payload      json.RawMessage `json:"payload"`
+	ResponseBody string          `json:"response_body"`
+}
+
+// WebhookPayload is the body posted to the URL of a webhook.
+type WebhookPayload struct {
+	ID         string                `json:"id"` // Identifies the event, redeliveries keep it so that receivers can ignore the ones they already handled
+	Event      events.Type           `json:"event"`
+	OccurredAt time.Time             `json:"occurred_at"`
+	Project    WebhookPayloadProject `json:"project"`
+	Actor      *AssignedTo           `json:"actor"` // Not set for the events raised by the server, such as deadlines
+	Data       map[string]any        `json:"data"`
+}
+
+type WebhookPayloadProject struct {
+	ID    uint   `json:"id"`
+	Key   string `json:"key"`
+	Title string `json:"title"`
+}
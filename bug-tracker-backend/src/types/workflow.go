@@ -0,0 +1,56 @@
+package types
+
+type WorkflowCategory string
+
+const (
+	WorkflowCategoryOpen   WorkflowCategory = "open"
+	WorkflowCategoryActive WorkflowCategory = "active"
+	WorkflowCategoryClosed WorkflowCategory = "closed"
+)
+
+func (w WorkflowCategory) Value() string {
+	return string(w)
+}
+
+type WorkflowStateRequest struct {
+	Key      BugStatus        `json:"key" binding:"required,max=50"` // Lowercase letters, digits and underscores, starting with a letter
+	Name     string           `json:"name" binding:"required,max=100"`
+	Category WorkflowCategory `json:"category" binding:"required,oneof=open active closed"`
+	Initial  bool             `json:"initial"` // Exactly one state must be the initial one
+}
+
+type WorkflowTransitionRequest struct {
+	From  BugStatus  `json:"from" binding:"required"`
+	To    BugStatus  `json:"to" binding:"required"`
+	Roles []TeamRole `json:"roles" binding:"required,min=1,dive,oneof=admin dev tester"`
+}
+
+// UpdateWorkflow replaces the whole workflow of a project, states are listed in the order they should be displayed in.
+type UpdateWorkflow struct {
+	States      []WorkflowStateRequest      `json:"states" binding:"required,min=1,dive"`
+	Transitions []WorkflowTransitionRequest `json:"transitions" binding:"omitempty,dive"`
+}
+
+type WorkflowStateResponse struct {
+	Key      BugStatus        `json:"key"`
+	Name     string           `json:"name"`
+	Category WorkflowCategory `json:"category"`
+	Position int              `json:"position"`
+	Initial  bool             `json:"initial"`
+}
+
+type WorkflowTransitionResponse struct {
+	From  BugStatus  `json:"from"`
+	To    BugStatus  `json:"to"`
+	Roles []TeamRole `json:"roles"`
+}
+
+type WorkflowResponse struct {
+	States      []WorkflowStateResponse      `json:"states"`
+	Transitions []WorkflowTransitionResponse `json:"transitions"`
+	IsDefault   bool                         `json:"is_default"` // The project uses the default workflow
+}
+
+type WorkflowStatesInUseResponse struct {
+	States []BugStatus `json:"states"` // States removed from the workflow that bugs are still in
+}
@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"context"
+	"log"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/conf"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/models"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/storage"
+)
+
+const defaultAttachmentMaxSizeMB = 10
+
+var defaultAttachmentAllowedTypes = []string{
+	"image/*",
+	"text/plain",
+	"application/pdf",
+	"application/zip",
+	"application/x-gzip",
+}
+
+// attachmentBlobGracePeriod keeps unreferenced blobs around for a while, so that an upload reusing a blob
+// that has just lost its last attachment does not race with its removal.
+const attachmentBlobGracePeriod = time.Hour
+
+// AttachmentMaxSize returns the maximum size of an uploaded file, in bytes.
+func AttachmentMaxSize() int64 {
+	size, err := strconv.Atoi(os.Getenv("ATTACHMENT_MAX_SIZE_MB"))
+	if err != nil || size <= 0 {
+		size = defaultAttachmentMaxSizeMB
+	}
+	return int64(size) << 20
+}
+
+// IsAttachmentTypeAllowed reports whether files of the MIME type can be attached to bugs.
+// The allowed types are read from ATTACHMENT_ALLOWED_TYPES, where entries such as image/* match a whole family.
+func IsAttachmentTypeAllowed(contentType string) bool {
+	allowed := defaultAttachmentAllowedTypes
+	if value := os.Getenv("ATTACHMENT_ALLOWED_TYPES"); value != "" {
+		allowed = strings.Split(value, ",")
+	}
+
+	for _, pattern := range allowed {
+		if matched, _ := path.Match(strings.TrimSpace(pattern), contentType); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// AttachmentStorageKey returns the key under which the content with the checksum is stored.
+func AttachmentStorageKey(checksum string) string {
+	return "attachments/" + checksum[:2] + "/" + checksum
+}
+
+// PurgeOrphanedAttachmentBlobs removes the stored files that are no longer attached to any bug,
+// for example after their attachments or bugs were deleted.
+func PurgeOrphanedAttachmentBlobs() error {
+	var blobs []models.AttachmentBlob
+	if err := conf.DB.
+		Where("updated_at < ?", time.Now().Add(-attachmentBlobGracePeriod)).
+		Where("NOT EXISTS (SELECT 1 FROM attachments WHERE attachments.blob_id = attachment_blobs.id)").
+		Find(&blobs).Error; err != nil {
+		return err
+	}
+
+	for _, blob := range blobs {
+		if err := storage.Default().Delete(context.Background(), blob.StorageKey); err != nil {
+			return err
+		}
+		if err := conf.DB.Unscoped().Delete(&blob).Error; err != nil {
+			return err
+		}
+	}
+
+	if len(blobs) > 0 {
+		log.Printf("Purged %d orphaned attachment files", len(blobs))
+	}
+	return nil
+}
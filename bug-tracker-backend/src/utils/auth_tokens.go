@@ -0,0 +1,166 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/conf"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/models"
+)
+
+const defaultRefreshTokenExpiresIn = 30 // in days
+
+// Types of the JWTs issued by the system, stored in the "typ" claim.
+const (
+	TokenTypeAccess             = "access"
+	TokenTypeTwoFactorChallenge = "2fa_challenge"
+)
+
+// TwoFactorChallengeExpiresIn is how long the user has to enter their code after logging in with their password.
+const TwoFactorChallengeExpiresIn = 5 * time.Minute
+
+var ErrInvalidChallengeToken = errors.New("invalid two-factor challenge token")
+
+// RandomID returns a random hex encoded identifier, used for token IDs and token families.
+func RandomID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func AccessTokenExpiresIn() time.Duration {
+	minutes, _ := strconv.Atoi(os.Getenv("JWT_EXPIRES_IN"))
+	return time.Duration(minutes) * time.Minute
+}
+
+func RefreshTokenExpiresIn() time.Duration {
+	days, err := strconv.Atoi(os.Getenv("JWT_REFRESH_EXPIRES_IN"))
+	if err != nil || days <= 0 {
+		days = defaultRefreshTokenExpiresIn
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+func signJWT(userID uint, tokenType string, expiresIn time.Duration) (string, time.Time, error) {
+	jti, err := RandomID()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	expiresAt := time.Now().Add(expiresIn)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": userID,
+		"jti": jti,
+		"typ": tokenType,
+		"iat": time.Now().Unix(),
+		"exp": expiresAt.Unix(),
+	})
+
+	tokenString, err := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return tokenString, expiresAt, nil
+}
+
+// GenerateAccessToken signs a JWT for the user with a unique ID, so that it can be revoked before it expires.
+func GenerateAccessToken(userID uint) (string, time.Time, error) {
+	return signJWT(userID, TokenTypeAccess, AccessTokenExpiresIn())
+}
+
+// GenerateChallengeToken signs a short-lived JWT proving that the user has entered the right password,
+// which is exchanged for an access token once the second factor is verified.
+func GenerateChallengeToken(userID uint) (string, time.Time, error) {
+	return signJWT(userID, TokenTypeTwoFactorChallenge, TwoFactorChallengeExpiresIn)
+}
+
+// ParseChallengeToken validates a token created by GenerateChallengeToken and returns the user ID, the token ID and its expiry.
+func ParseChallengeToken(tokenString string) (uint, string, time.Time, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (any, error) {
+		return []byte(os.Getenv("JWT_SECRET")), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return 0, "", time.Time{}, ErrInvalidChallengeToken
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["typ"] != TokenTypeTwoFactorChallenge {
+		return 0, "", time.Time{}, ErrInvalidChallengeToken
+	}
+
+	sub, subOK := claims["sub"].(float64)
+	jti, jtiOK := claims["jti"].(string)
+	exp, expOK := claims["exp"].(float64)
+	if !subOK || !jtiOK || !expOK {
+		return 0, "", time.Time{}, ErrInvalidChallengeToken
+	}
+
+	// A challenge can only be completed once
+	if revoked, err := IsAccessTokenRevoked(jti); err != nil || revoked {
+		return 0, "", time.Time{}, ErrInvalidChallengeToken
+	}
+
+	return uint(sub), jti, time.Unix(int64(exp), 0), nil
+}
+
+// CreateRefreshToken stores a new refresh token of the family for the user and returns it.
+func CreateRefreshToken(tx *gorm.DB, userID uint, familyID string) (string, *models.RefreshToken, error) {
+	token, tokenHash, err := GenerateSignedToken()
+	if err != nil {
+		return "", nil, err
+	}
+
+	refreshToken := models.RefreshToken{
+		UserID:    userID,
+		TokenHash: tokenHash,
+		FamilyID:  familyID,
+		ExpiresAt: time.Now().Add(RefreshTokenExpiresIn()),
+	}
+	if err := tx.Create(&refreshToken).Error; err != nil {
+		return "", nil, err
+	}
+
+	return token, &refreshToken, nil
+}
+
+// RevokeAccessToken adds the ID of an access token to the revocation list until the token expires.
+func RevokeAccessToken(jti string, expiresAt time.Time) error {
+	return conf.DB.Where(models.RevokedToken{JTI: jti}).
+		Attrs(models.RevokedToken{ExpiresAt: expiresAt}).
+		FirstOrCreate(&models.RevokedToken{}).Error
+}
+
+func IsAccessTokenRevoked(jti string) (bool, error) {
+	var count int64
+	if err := conf.DB.Model(&models.RevokedToken{}).Where("jti = ?", jti).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// RevokeRefreshTokens revokes every active refresh token that matches the query.
+func RevokeRefreshTokens(tx *gorm.DB, query string, args ...any) error {
+	return tx.Model(&models.RefreshToken{}).
+		Where("revoked_at IS NULL").
+		Where(query, args...).
+		Update("revoked_at", time.Now()).Error
+}
+
+// PurgeExpiredTokens removes the revocation list entries and refresh tokens that have expired.
+func PurgeExpiredTokens() error {
+	now := time.Now()
+	if err := conf.DB.Unscoped().Where("expires_at < ?", now).Delete(&models.RevokedToken{}).Error; err != nil {
+		return err
+	}
+	return conf.DB.Unscoped().Where("expires_at < ?", now).Delete(&models.RefreshToken{}).Error
+}
@@ -0,0 +1,125 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/models"
+)
+
+const maxProjectKeyLength = 10
+
+var (
+	projectKeyPattern = regexp.MustCompile(`^[A-Z][A-Z0-9]{1,9}$`)
+	bugKeyPattern     = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9]{1,9})-([1-9][0-9]*)$`)
+)
+
+// IsValidProjectKey reports whether the key is 2 to 10 uppercase letters and digits, starting with a letter.
+func IsValidProjectKey(key string) bool {
+	return projectKeyPattern.MatchString(key)
+}
+
+// BugKey returns the human-readable key of a bug, such as PROJ-42.
+func BugKey(projectKey string, number uint) string {
+	return fmt.Sprintf("%s-%d", projectKey, number)
+}
+
+// ParseBugKey splits a bug key into the key of its project, uppercased, and the number of the bug in the project.
+func ParseBugKey(bugKey string) (string, uint, bool) {
+	match := bugKeyPattern.FindStringSubmatch(bugKey)
+	if match == nil {
+		return "", 0, false
+	}
+
+	number, err := strconv.ParseUint(match[2], 10, 32)
+	if err != nil {
+		return "", 0, false
+	}
+	return strings.ToUpper(match[1]), uint(number), true
+}
+
+// GenerateProjectKey derives an unused key from the title of a project, from the initials of its words
+// or the start of its only word, adding a counter if the key is already taken.
+func GenerateProjectKey(tx *gorm.DB, title string) (string, error) {
+	words := strings.FieldsFunc(strings.ToUpper(title), func(r rune) bool {
+		return r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r))
+	})
+
+	var base string
+	if len(words) > 1 {
+		for _, word := range words {
+			base += word[:1]
+		}
+	} else if len(words) == 1 {
+		base = words[0]
+	}
+
+	base = strings.TrimLeft(base, "0123456789")
+	if len(base) > 4 {
+		base = base[:4]
+	}
+	if len(base) < 2 {
+		base = "PR"
+	}
+
+	for i := 1; ; i++ {
+		key := base
+		if i > 1 {
+			suffix := strconv.Itoa(i)
+			key = base[:min(len(base), maxProjectKeyLength-len(suffix))] + suffix
+		}
+
+		taken, err := IsProjectKeyTaken(tx, key, 0)
+		if err != nil {
+			return "", err
+		} else if !taken {
+			return key, nil
+		}
+	}
+}
+
+// IsProjectKeyTaken reports whether another project, including deleted ones, already uses the key.
+func IsProjectKeyTaken(tx *gorm.DB, key string, exceptProjectID uint) (bool, error) {
+	var count int64
+	if err := tx.Unscoped().Model(&models.Project{}).Where("key = ? AND id <> ?", key, exceptProjectID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// NextBugNumber atomically increments the bug sequence of the project and returns the new value,
+// so that concurrent bug creations never get the same number.
+func NextBugNumber(tx *gorm.DB, projectID uint) (uint, error) {
+	var project models.Project
+	result := tx.Model(&project).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "bug_sequence"}}}).
+		Where("id = ?", projectID).
+		UpdateColumn("bug_sequence", gorm.Expr("bug_sequence + 1"))
+	if result.Error != nil {
+		return 0, result.Error
+	} else if result.RowsAffected == 0 {
+		return 0, errors.New("project not found")
+	}
+	return project.BugSequence, nil
+}
+
+// WhereBugReference restricts the query to the bug of the project referenced either by its ID or by its key.
+// It returns false if the reference is neither, or is the key of a bug of another project.
+func WhereBugReference(db *gorm.DB, project models.Project, reference string) (*gorm.DB, bool) {
+	if id, err := strconv.ParseUint(reference, 10, 32); err == nil {
+		return db.Where("project_id = ? AND id = ?", project.ID, id), true
+	}
+
+	key, number, ok := ParseBugKey(reference)
+	if !ok || key != project.Key {
+		return db, false
+	}
+	return db.Where("project_id = ? AND number = ?", project.ID, number), true
+}
@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"time"
+
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/models"
+)
+
+// BurndownDay is the number of bugs that were still open and already closed at the end of a day.
+type BurndownDay struct {
+	Date      time.Time
+	Remaining int64
+	Closed    int64
+}
+
+// TruncateToDay returns midnight UTC of the day of the time.
+func TruncateToDay(t time.Time) time.Time {
+	year, month, day := t.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// Burndown replays the status history of the bugs to count, for every day from the start to the end, how many of
+// them were in a closed state of the workflow at the end of the day. The history must only hold status changes,
+// ordered from the oldest. Bugs are only counted from the day they were created.
+func Burndown(bugs []models.Bug, history []models.BugHistory, workflow Workflow, start, end time.Time) []BurndownDay {
+	changes := make(map[uint][]models.BugHistory)
+	for _, entry := range history {
+		changes[entry.BugID] = append(changes[entry.BugID], entry)
+	}
+
+	days := make([]BurndownDay, 0)
+	for day := TruncateToDay(start); !day.After(end); day = day.AddDate(0, 0, 1) {
+		endOfDay := day.AddDate(0, 0, 1)
+		point := BurndownDay{Date: day}
+
+		for _, bug := range bugs {
+			if !bug.CreatedAt.Before(endOfDay) {
+				continue
+			}
+
+			if workflow.IsClosed(statusAt(bug, changes[bug.ID], endOfDay)) {
+				point.Closed++
+			} else {
+				point.Remaining++
+			}
+		}
+
+		days = append(days, point)
+	}
+
+	return days
+}
+
+// statusAt returns the status the bug was in just before the time, given its status changes ordered from the oldest.
+func statusAt(bug models.Bug, changes []models.BugHistory, t time.Time) string {
+	if len(changes) == 0 {
+		return bug.Status
+	}
+
+	status := changes[0].OldValue
+	for _, change := range changes {
+		if !change.CreatedAt.Before(t) {
+			break
+		}
+		status = change.NewValue
+	}
+	return status
+}
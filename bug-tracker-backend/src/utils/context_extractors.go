@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"time"
+
 	"github.com/gin-gonic/gin"
 
 	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/models"
@@ -26,3 +28,68 @@ func ExtractBugFromContext(c *gin.Context) models.Bug {
 
 	return bug
 }
+
+func ExtractCommentFromContext(c *gin.Context) models.Comment {
+	contextComment, _ := c.Get("comment")
+	comment, _ := contextComment.(models.Comment)
+
+	return comment
+}
+
+func ExtractAttachmentFromContext(c *gin.Context) models.Attachment {
+	contextAttachment, _ := c.Get("attachment")
+	attachment, _ := contextAttachment.(models.Attachment)
+
+	return attachment
+}
+
+func ExtractMilestoneFromContext(c *gin.Context) models.Milestone {
+	contextMilestone, _ := c.Get("milestone")
+	milestone, _ := contextMilestone.(models.Milestone)
+
+	return milestone
+}
+
+func ExtractCustomFieldFromContext(c *gin.Context) models.CustomField {
+	contextField, _ := c.Get("customField")
+	field, _ := contextField.(models.CustomField)
+
+	return field
+}
+
+func ExtractWebhookFromContext(c *gin.Context) models.Webhook {
+	contextWebhook, _ := c.Get("webhook")
+	webhook, _ := contextWebhook.(models.Webhook)
+
+	return webhook
+}
+
+func ExtractUserRoleFromContext(c *gin.Context) string {
+	contextUserRole, _ := c.Get("userRole")
+	role, _ := contextUserRole.(string)
+
+	return role
+}
+
+// ExtractAccessTokenFromContext returns the ID and the expiry of the access token used to authenticate the request.
+func ExtractAccessTokenFromContext(c *gin.Context) (string, time.Time) {
+	contextTokenID, _ := c.Get("tokenID")
+	tokenID, _ := contextTokenID.(string)
+
+	contextExpiresAt, _ := c.Get("tokenExpiresAt")
+	expiresAt, _ := contextExpiresAt.(time.Time)
+
+	return tokenID, expiresAt
+}
+
+// ExtractTokenScopesFromContext returns the scopes of the personal access token used to authenticate the request.
+// The second value is false if the request was not authenticated with a personal access token.
+func ExtractTokenScopesFromContext(c *gin.Context) ([]string, bool) {
+	contextScopes, exists := c.Get("tokenScopes")
+	if !exists {
+		return nil, false
+	}
+	scopes, _ := contextScopes.([]string)
+
+	return scopes, true
+}
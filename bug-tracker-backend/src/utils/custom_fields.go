@@ -0,0 +1,207 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/models"
+	types "github.com/WNBARookie/BugTracker/bug-tracker-backend/src/types"
+)
+
+// CustomFieldFilterPrefix prefixes the query parameters filtering bugs on custom fields, such as cf.browser=firefox.
+const CustomFieldFilterPrefix = "cf."
+
+const customFieldTextMaxLength = 1000
+
+// CustomFieldError describes a value that does not fit the custom fields of the project.
+type CustomFieldError struct {
+	Message string
+}
+
+func (e *CustomFieldError) Error() string {
+	return e.Message
+}
+
+func customFieldError(format string, args ...any) error {
+	return &CustomFieldError{Message: fmt.Sprintf(format, args...)}
+}
+
+// GetProjectCustomFields returns the custom fields of the project in the order they are displayed in.
+func GetProjectCustomFields(db *gorm.DB, projectID uint) ([]models.CustomField, error) {
+	var fields []models.CustomField
+	err := db.Where("project_id = ?", projectID).Order("position ASC, id ASC").Find(&fields).Error
+	return fields, err
+}
+
+// ApplyCustomFieldValues validates the values set on a bug and merges them into its current ones, a null value
+// removing the value of the field. Required fields must have a value once the changes are applied.
+// Validation problems are returned as a *CustomFieldError.
+func ApplyCustomFieldValues(fields []models.CustomField, projectID uint, current models.JSONMap, changes map[string]any) (models.JSONMap, error) {
+	values := make(models.JSONMap, len(current)+len(changes))
+	for key, value := range current {
+		values[key] = value
+	}
+
+	for key, value := range changes {
+		i := slices.IndexFunc(fields, func(field models.CustomField) bool { return field.Key == key })
+		if i < 0 {
+			return nil, customFieldError("Custom field %q does not exist in this project", key)
+		}
+
+		if value == nil {
+			delete(values, key)
+			continue
+		}
+
+		normalized, err := normalizeCustomFieldValue(fields[i], projectID, value)
+		if err != nil {
+			return nil, err
+		}
+		values[key] = normalized
+	}
+
+	for _, field := range fields {
+		if _, ok := values[field.Key]; field.Required && !ok {
+			return nil, customFieldError("Custom field %q is required", field.Key)
+		}
+	}
+
+	return values, nil
+}
+
+// normalizeCustomFieldValue checks that the value decoded from JSON fits the type of the field,
+// returning it in the form it is stored in.
+func normalizeCustomFieldValue(field models.CustomField, projectID uint, value any) (any, error) {
+	switch types.CustomFieldType(field.Type) {
+	case types.CustomFieldTypeText:
+		text, ok := value.(string)
+		if !ok {
+			return nil, customFieldError("Custom field %q must be a string", field.Key)
+		} else if len(text) > customFieldTextMaxLength {
+			return nil, customFieldError("Custom field %q cannot be longer than %d characters", field.Key, customFieldTextMaxLength)
+		}
+		return text, nil
+
+	case types.CustomFieldTypeNumber:
+		number, ok := value.(float64)
+		if !ok {
+			return nil, customFieldError("Custom field %q must be a number", field.Key)
+		}
+		return number, nil
+
+	case types.CustomFieldTypeDate:
+		text, ok := value.(string)
+		if !ok {
+			return nil, customFieldError("Custom field %q must be a date (YYYY-MM-DD)", field.Key)
+		}
+		if _, err := time.Parse("2006-01-02", text); err != nil {
+			return nil, customFieldError("Custom field %q must be a date (YYYY-MM-DD)", field.Key)
+		}
+		return text, nil
+
+	case types.CustomFieldTypeSelect:
+		option, ok := value.(string)
+		if !ok || !slices.Contains(field.Options, option) {
+			return nil, customFieldError("Custom field %q must be one of: %s", field.Key, strings.Join(field.Options, ", "))
+		}
+		return option, nil
+
+	case types.CustomFieldTypeMultiSelect:
+		items, ok := value.([]any)
+		if !ok {
+			return nil, customFieldError("Custom field %q must be a list of options", field.Key)
+		}
+
+		options := make([]string, 0, len(items))
+		for _, item := range items {
+			option, ok := item.(string)
+			if !ok || !slices.Contains(field.Options, option) {
+				return nil, customFieldError("Custom field %q only accepts: %s", field.Key, strings.Join(field.Options, ", "))
+			}
+			if !slices.Contains(options, option) {
+				options = append(options, option)
+			}
+		}
+		return options, nil
+
+	case types.CustomFieldTypeUser:
+		number, ok := value.(float64)
+		if !ok || number <= 0 || number != math.Trunc(number) {
+			return nil, customFieldError("Custom field %q must be the ID of a project member", field.Key)
+		}
+
+		role, err := CheckIfUserIsProjectMember(uint(number), projectID)
+		if err != nil {
+			return nil, err
+		} else if role == "" {
+			return nil, customFieldError("Custom field %q must be the ID of a project member", field.Key)
+		}
+		return uint(number), nil
+	}
+
+	return nil, fmt.Errorf("unknown custom field type %q", field.Type)
+}
+
+// likeEscaper escapes the wildcards of LIKE patterns, and the escape character itself.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// escapeLike makes the text match itself in a LIKE pattern using \ as the escape character.
+func escapeLike(text string) string {
+	return likeEscaper.Replace(text)
+}
+
+// WhereCustomField filters the bugs on the value of a custom field given as a query parameter. Text fields match
+// values containing the parameter, multi select fields match bugs that have the option among others, and the other
+// types match the exact value.
+func WhereCustomField(db *gorm.DB, field models.CustomField, raw string) (*gorm.DB, error) {
+	var value any = raw
+
+	switch types.CustomFieldType(field.Type) {
+	case types.CustomFieldTypeText:
+		return db.Where(`bugs.custom_fields ->> ? ILIKE ? ESCAPE '\'`, field.Key, "%"+escapeLike(raw)+"%"), nil
+
+	case types.CustomFieldTypeNumber:
+		number, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, customFieldError("Filter on custom field %q must be a number", field.Key)
+		}
+		value = number
+
+	case types.CustomFieldTypeDate:
+		if _, err := time.Parse("2006-01-02", raw); err != nil {
+			return nil, customFieldError("Filter on custom field %q must be a date (YYYY-MM-DD)", field.Key)
+		}
+
+	case types.CustomFieldTypeMultiSelect:
+		value = []string{raw}
+
+	case types.CustomFieldTypeUser:
+		id, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return nil, customFieldError("Filter on custom field %q must be a user ID", field.Key)
+		}
+		value = id
+	}
+
+	// Containment matches both exact values and options of lists, and can use the GIN index on the column
+	filter, err := json.Marshal(map[string]any{field.Key: value})
+	if err != nil {
+		return nil, err
+	}
+	return db.Where("bugs.custom_fields @> ?::jsonb", string(filter)), nil
+}
+
+// IsCustomFieldError reports whether the error is a validation problem to report to the client.
+func IsCustomFieldError(err error) (*CustomFieldError, bool) {
+	var fieldErr *CustomFieldError
+	ok := errors.As(err, &fieldErr)
+	return fieldErr, ok
+}
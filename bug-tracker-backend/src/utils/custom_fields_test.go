@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/models"
+	types "github.com/WNBARookie/BugTracker/bug-tracker-backend/src/types"
+)
+
+func TestEscapeLike(t *testing.T) {
+	cases := map[string]string{
+		"chrome":   "chrome",
+		"%":        `\%`,
+		"a_b":      `a\_b`,
+		`C:\temp`:  `C:\\temp`,
+		`100%_\\x`: `100\%\_\\\\x`,
+	}
+	for text, want := range cases {
+		if got := escapeLike(text); got != want {
+			t.Errorf("escapeLike(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
+
+func TestWhereCustomFieldEscapesTextFilters(t *testing.T) {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	field := models.CustomField{Key: "browser", Type: types.CustomFieldTypeText.Value()}
+	query, err := WhereCustomField(db.Model(&models.Bug{}), field, "50%_off")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statement := query.Find(&[]models.Bug{}).Statement
+	if sql := statement.SQL.String(); !strings.Contains(sql, `ILIKE $2 ESCAPE '\'`) {
+		t.Errorf("SQL = %s", sql)
+	}
+	if pattern := statement.Vars[1]; pattern != `%50\%\_off%` {
+		t.Errorf("pattern = %v, want %q", pattern, `%50\%\_off%`)
+	}
+}
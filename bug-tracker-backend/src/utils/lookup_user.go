@@ -23,3 +23,19 @@ func CheckIfUserIsProjectMember(userID, projectID uint) (string, error) {
 	}
 	return team.Role, nil // User is a member of the project
 }
+
+func LookupUserUsingEmail(email string) (*models.User, error) {
+	var user models.User
+	if err := conf.DB.Where("email = ?", email).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func LookupUserUsingUsername(username string) (*models.User, error) {
+	var user models.User
+	if err := conf.DB.Where("username = ?", username).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
@@ -0,0 +1,21 @@
+package utils
+
+import (
+	"bytes"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// Raw HTML in the source is dropped and links with dangerous schemes such as javascript: are blanked,
+// which is what goldmark does unless html.WithUnsafe is set, so the output is safe to embed in a page.
+var markdown = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+// RenderMarkdown converts Markdown to sanitized HTML.
+func RenderMarkdown(source string) string {
+	var buf bytes.Buffer
+	if err := markdown.Convert([]byte(source), &buf); err != nil {
+		return ""
+	}
+	return buf.String()
+}
@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"regexp"
+
+	"gorm.io/gorm"
+
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/models"
+)
+
+// Usernames are alphanumeric, a mention must not be preceded by a word character so that emails are not taken for one
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@.])@([A-Za-z0-9]{3,20})\b`)
+
+// ExtractMentions returns the usernames mentioned with @username in the text, once each.
+func ExtractMentions(text string) []string {
+	usernames := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, match := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			usernames = append(usernames, match[1])
+		}
+	}
+	return usernames
+}
+
+// FindMentionedMembers returns the IDs of the members of the project mentioned in the text.
+func FindMentionedMembers(db *gorm.DB, projectID uint, text string) ([]uint, error) {
+	usernames := ExtractMentions(text)
+	if len(usernames) == 0 {
+		return nil, nil
+	}
+
+	var userIDs []uint
+	err := db.Model(&models.User{}).
+		Joins("INNER JOIN teams ON teams.user_id = users.id AND teams.deleted_at IS NULL").
+		Where("teams.project_id = ? AND users.username IN ?", projectID, usernames).
+		Pluck("users.id", &userIDs).Error
+	return userIDs, err
+}
@@ -14,11 +14,6 @@ type PaginatedResponse[T any] struct {
 	PrevPage   *int   `json:"prev_page"`
 }
 
-type PaginationQueryParams struct {
-	Page  int `form:"page,default=1" binding:"min=1"`
-	Limit int `form:"limit,default=10" binding:"min=1"`
-}
-
 func Paginate[T any](data []T, page, limit, totalCount int) PaginatedResponse[T] {
 	var paginatedResponse PaginatedResponse[T]
 
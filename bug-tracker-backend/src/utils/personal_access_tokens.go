@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"time"
+
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/conf"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/models"
+)
+
+// PersonalAccessTokenPrefix tells personal access tokens apart from JWTs.
+const PersonalAccessTokenPrefix = "btp_"
+
+const lastUsedUpdateInterval = time.Minute
+
+// GeneratePersonalAccessToken creates a new random personal access token and returns it along with its hash and its display prefix.
+func GeneratePersonalAccessToken() (string, string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", "", err
+	}
+
+	token := PersonalAccessTokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return token, HashToken(token), token[:len(PersonalAccessTokenPrefix)+6], nil
+}
+
+// LookupPersonalAccessToken returns the stored token if it exists, has not been revoked and has not expired.
+func LookupPersonalAccessToken(token string) (*models.PersonalAccessToken, error) {
+	var accessToken models.PersonalAccessToken
+	if err := conf.DB.
+		Where("token_hash = ? AND (expires_at IS NULL OR expires_at > ?)", HashToken(token), time.Now()).
+		First(&accessToken).Error; err != nil {
+		return nil, err
+	}
+	return &accessToken, nil
+}
+
+// TouchPersonalAccessToken records the use of the token. The write is skipped if the token was used very recently.
+func TouchPersonalAccessToken(accessToken *models.PersonalAccessToken) error {
+	now := time.Now()
+	if accessToken.LastUsedAt != nil && now.Sub(*accessToken.LastUsedAt) < lastUsedUpdateInterval {
+		return nil
+	}
+	return conf.DB.Model(accessToken).UpdateColumn("last_used_at", now).Error
+}
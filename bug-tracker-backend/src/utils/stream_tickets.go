@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// StreamTicketLifetime is how long a ticket can be used to open an event stream.
+const StreamTicketLifetime = time.Minute
+
+// StreamTicket lets clients that cannot set the Authorization header, such as EventSource, open the event stream of a
+// project. It is passed in the query string, where it can end up in access logs, so it only lasts a minute and only
+// opens the stream of one project.
+type StreamTicket struct {
+	UserID           uint   `json:"uid"`
+	ProjectID        uint   `json:"pid"`
+	TokenID          string `json:"jti,omitempty"` // Access token the ticket was requested with, not set for personal access tokens
+	SessionExpiresAt int64  `json:"sxp,omitempty"` // Expiry of that access token, which ends the stream
+	ExpiresAt        int64  `json:"exp"`
+}
+
+// CreateStreamTicket signs the ticket with the JWT secret.
+func CreateStreamTicket(ticket StreamTicket) (string, error) {
+	data, err := json.Marshal(ticket)
+	if err != nil {
+		return "", err
+	}
+
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return payload + "." + signTokenPayload("stream:"+payload), nil
+}
+
+// VerifyStreamTicket checks the signature and the expiry of a ticket created by CreateStreamTicket.
+func VerifyStreamTicket(token string) (*StreamTicket, bool) {
+	payload, signature, found := strings.Cut(token, ".")
+	if !found || payload == "" {
+		return nil, false
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(signTokenPayload("stream:"+payload))) {
+		return nil, false
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, false
+	}
+
+	var ticket StreamTicket
+	if err := json.Unmarshal(data, &ticket); err != nil || time.Now().Unix() > ticket.ExpiresAt {
+		return nil, false
+	}
+
+	return &ticket, true
+}
@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/models"
+	types "github.com/WNBARookie/BugTracker/bug-tracker-backend/src/types"
+)
+
+// ChildCount is the number of sub-tasks of a bug, and how many of them are in a closed state.
+type ChildCount struct {
+	ParentID uint
+	Total    int64
+	Closed   int64
+}
+
+// CountChildren returns the sub-task counts of the bugs that have sub-tasks, by parent ID.
+// Sub-tasks in the trash are not counted.
+func CountChildren(db *gorm.DB, workflow Workflow, parentIDs []uint) (map[uint]ChildCount, error) {
+	counts := make(map[uint]ChildCount)
+	if len(parentIDs) == 0 {
+		return counts, nil
+	}
+
+	closedStates := workflow.StatesInCategory(types.WorkflowCategoryClosed)
+
+	var rows []ChildCount
+	if err := db.Model(&models.Bug{}).
+		Select("parent_id, COUNT(*) AS total, COUNT(*) FILTER (WHERE status IN ?) AS closed", closedStates).
+		Where("parent_id IN ?", parentIDs).
+		Group("parent_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		counts[row.ParentID] = row
+	}
+	return counts, nil
+}
+
+// CountOpenChildren returns the number of sub-tasks of the bug that are not in a closed state.
+func CountOpenChildren(db *gorm.DB, workflow Workflow, bugID uint) (int64, error) {
+	var count int64
+	err := db.Model(&models.Bug{}).
+		Where("parent_id = ? AND status NOT IN ?", bugID, workflow.StatesInCategory(types.WorkflowCategoryClosed)).
+		Count(&count).Error
+	return count, err
+}
+
+// IsDescendant reports whether the bug is a sub-task of the ancestor, directly or not.
+// Bugs in the trash are followed too, since they can be restored.
+func IsDescendant(db *gorm.DB, bugID, ancestorID uint) (bool, error) {
+	visited := make(map[uint]bool)
+	for current := bugID; !visited[current]; {
+		visited[current] = true
+
+		var bug models.Bug
+		if err := db.Unscoped().Select("id", "parent_id").First(&bug, current).Error; err != nil {
+			return false, err
+		}
+
+		if bug.ParentID == nil {
+			return false, nil
+		} else if *bug.ParentID == ancestorID {
+			return true, nil
+		}
+		current = *bug.ParentID
+	}
+
+	return false, nil
+}
@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"strconv"
+	"strings"
+)
+
+func signTokenPayload(payload string) string {
+	mac := hmac.New(sha256.New, []byte(os.Getenv("JWT_SECRET")))
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// HashToken returns the hash under which a token is stored, so that leaked rows cannot be used as tokens.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// GenerateSignedToken creates a random token signed with the JWT secret and returns it along with its hash.
+func GenerateSignedToken() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+
+	payload := base64.RawURLEncoding.EncodeToString(b)
+	token := payload + "." + signTokenPayload(payload)
+
+	return token, HashToken(token), nil
+}
+
+// VerifySignedToken checks the signature of a token created by GenerateSignedToken and returns its hash.
+func VerifySignedToken(token string) (string, bool) {
+	payload, signature, found := strings.Cut(token, ".")
+	if !found || payload == "" {
+		return "", false
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(signTokenPayload(payload))) {
+		return "", false
+	}
+
+	return HashToken(token), true
+}
+
+// UnsubscribeToken creates the token of the unsubscribe links of the notification emails of a user.
+// It does not expire, so that the links of old emails keep working.
+func UnsubscribeToken(userID uint) string {
+	payload := strconv.FormatUint(uint64(userID), 10)
+	return payload + "." + signTokenPayload("unsubscribe:"+payload)
+}
+
+// VerifyUnsubscribeToken checks a token created by UnsubscribeToken and returns the ID of its user.
+func VerifyUnsubscribeToken(token string) (uint, bool) {
+	payload, signature, found := strings.Cut(token, ".")
+	if !found {
+		return 0, false
+	}
+
+	userID, err := strconv.ParseUint(payload, 10, 64)
+	if err != nil || userID == 0 {
+		return 0, false
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(signTokenPayload("unsubscribe:"+payload))) {
+		return 0, false
+	}
+
+	return uint(userID), true
+}
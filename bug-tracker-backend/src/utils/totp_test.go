@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA1 seed of the test vectors of RFC 6238, appendix B.
+var rfc6238Secret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+// The vectors of the RFC have 8 digits, the codes of the system are their last 6.
+var rfc6238Vectors = []struct {
+	unix int64
+	code string
+}{
+	{59, "287082"},
+	{1111111109, "081804"},
+	{1111111111, "050471"},
+	{1234567890, "005924"},
+	{2000000000, "279037"},
+	{20000000000, "353130"},
+}
+
+func TestValidateTOTPMatchesRFC6238Vectors(t *testing.T) {
+	for _, vector := range rfc6238Vectors {
+		at := time.Unix(vector.unix, 0)
+
+		step, ok := ValidateTOTP(rfc6238Secret, vector.code, at)
+		if !ok {
+			t.Errorf("code %s at %d was rejected", vector.code, vector.unix)
+			continue
+		}
+		if want := vector.unix / totpPeriod; step != want {
+			t.Errorf("code %s at %d matched step %d, want %d", vector.code, vector.unix, step, want)
+		}
+	}
+}
+
+func TestValidateTOTPAcceptsOnePeriodOfSkew(t *testing.T) {
+	vector := rfc6238Vectors[3]
+	at := time.Unix(vector.unix, 0)
+
+	for _, offset := range []time.Duration{-totpPeriod * time.Second, totpPeriod * time.Second} {
+		if _, ok := ValidateTOTP(rfc6238Secret, vector.code, at.Add(offset)); !ok {
+			t.Errorf("code was rejected %s from its period", offset)
+		}
+	}
+
+	for _, offset := range []time.Duration{-2 * totpPeriod * time.Second, 2 * totpPeriod * time.Second} {
+		if _, ok := ValidateTOTP(rfc6238Secret, vector.code, at.Add(offset)); ok {
+			t.Errorf("code was accepted %s from its period", offset)
+		}
+	}
+}
+
+func TestValidateTOTPRejectsMalformedInput(t *testing.T) {
+	at := time.Unix(rfc6238Vectors[0].unix, 0)
+
+	cases := map[string]struct{ secret, code string }{
+		"wrong code":     {rfc6238Secret, "287083"},
+		"short code":     {rfc6238Secret, "28708"},
+		"8 digit code":   {rfc6238Secret, "94287082"},
+		"invalid secret": {"not base32!", "287082"},
+	}
+	for name, c := range cases {
+		if _, ok := ValidateTOTP(c.secret, c.code, at); ok {
+			t.Errorf("%s: code was accepted", name)
+		}
+	}
+}
+
+func TestGenerateTOTPSecretRoundTrips(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil {
+		t.Fatalf("secret %q is not base32: %v", secret, err)
+	}
+
+	now := time.Now()
+	if _, ok := ValidateTOTP(secret, totpCode(key, now.Unix()/totpPeriod), now); !ok {
+		t.Error("code of a generated secret was rejected")
+	}
+}
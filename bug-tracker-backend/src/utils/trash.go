@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/conf"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/models"
+)
+
+const defaultBugTrashRetentionDays = 30
+
+// BugTrashRetention returns how long a deleted bug is kept in the trash before it is purged.
+func BugTrashRetention() time.Duration {
+	days, err := strconv.Atoi(os.Getenv("BUG_TRASH_RETENTION_DAYS"))
+	if err != nil || days <= 0 {
+		days = defaultBugTrashRetentionDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// PurgeExpiredBugs permanently deletes the bugs that have been in the trash longer than the retention period.
+// If projectID is 0, the trash of every project is purged.
+func PurgeExpiredBugs(projectID uint) (int64, error) {
+	query := conf.DB.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", time.Now().Add(-BugTrashRetention()))
+	if projectID != 0 {
+		query = query.Where("project_id = ?", projectID)
+	}
+
+	result := query.Delete(&models.Bug{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	if result.RowsAffected > 0 {
+		log.Printf("Purged %d expired bugs from the trash", result.RowsAffected)
+	}
+	return result.RowsAffected, nil
+}
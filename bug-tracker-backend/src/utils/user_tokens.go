@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/conf"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/models"
+)
+
+const (
+	UserTokenEmailVerification = "email_verification"
+	UserTokenPasswordReset     = "password_reset"
+	UserTokenAccountUnlock     = "account_unlock"
+)
+
+var ErrInvalidUserToken = errors.New("token is invalid, expired or has already been used")
+
+// CreateUserToken issues a single-use token for the user, invalidating the unused tokens issued earlier for the same purpose.
+func CreateUserToken(userID uint, purpose string, expiresIn time.Duration) (string, error) {
+	token, tokenHash, err := GenerateSignedToken()
+	if err != nil {
+		return "", err
+	}
+
+	err = conf.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.UserToken{}).
+			Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+			Update("expires_at", time.Now()).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&models.UserToken{
+			UserID:    userID,
+			Purpose:   purpose,
+			TokenHash: tokenHash,
+			ExpiresAt: time.Now().Add(expiresIn),
+		}).Error
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// ConsumeUserToken marks the token as used and returns it, as long as it is valid for the purpose.
+func ConsumeUserToken(tx *gorm.DB, token, purpose string) (*models.UserToken, error) {
+	tokenHash, ok := VerifySignedToken(token)
+	if !ok {
+		return nil, ErrInvalidUserToken
+	}
+
+	var userToken models.UserToken
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("token_hash = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?", tokenHash, purpose, time.Now()).
+		First(&userToken).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidUserToken
+		}
+		return nil, err
+	}
+
+	if err := tx.Model(&userToken).Update("used_at", time.Now()).Error; err != nil {
+		return nil, err
+	}
+
+	return &userToken, nil
+}
+
+// AppURL builds a link to a page of the frontend application.
+func AppURL(path string) string {
+	baseURL := strings.TrimSuffix(os.Getenv("APP_URL"), "/")
+	if baseURL == "" {
+		baseURL = "http://localhost:5000"
+	}
+	return baseURL + path
+}
+
+// APIURL builds a link to an endpoint of this API, for the links that must work without the frontend.
+func APIURL(path string) string {
+	baseURL := strings.TrimSuffix(os.Getenv("API_URL"), "/")
+	if baseURL == "" {
+		baseURL = "http://localhost:8080"
+	}
+	return baseURL + path
+}
@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/models"
+)
+
+// Reasons a user started watching, automatic watches never undo a choice the user made.
+const (
+	WatchReasonManual    = "manual"
+	WatchReasonCreated   = "created"
+	WatchReasonAssigned  = "assigned"
+	WatchReasonCommented = "commented"
+)
+
+// WatchBug subscribes the user to the changes of the bug. Watching it again keeps the existing subscription,
+// so a bug the user muted stays muted.
+func WatchBug(db *gorm.DB, userID uint, bug models.Bug, reason string) error {
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.Watch{
+		UserID:    userID,
+		ProjectID: bug.ProjectID,
+		BugID:     &bug.ID,
+		Reason:    reason,
+	}).Error
+}
+
+// WatchProject subscribes the user to the changes of every bug of the project.
+func WatchProject(db *gorm.DB, userID, projectID uint, reason string) error {
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.Watch{
+		UserID:    userID,
+		ProjectID: projectID,
+		Reason:    reason,
+	}).Error
+}
+
+// IsWatchingProject reports whether the user watches the whole project.
+func IsWatchingProject(db *gorm.DB, userID, projectID uint) (bool, error) {
+	var count int64
+	err := db.Model(&models.Watch{}).Where("user_id = ? AND project_id = ? AND bug_id IS NULL", userID, projectID).Count(&count).Error
+	return count > 0, err
+}
+
+// GetBugSubscribers returns the IDs of the users to notify about a change to the bug: the members of its project
+// who watch the bug, or watch the project and have not muted the bug.
+func GetBugSubscribers(db *gorm.DB, bug models.Bug) ([]uint, error) {
+	var userIDs []uint
+	err := db.Model(&models.Watch{}).
+		Joins("INNER JOIN teams ON teams.user_id = watches.user_id AND teams.project_id = watches.project_id AND teams.deleted_at IS NULL").
+		Where("watches.project_id = ?", bug.ProjectID).
+		Where(`(watches.bug_id = ? AND NOT watches.muted) OR (watches.bug_id IS NULL AND NOT EXISTS (
+			SELECT 1 FROM watches AS muted_watches
+			WHERE muted_watches.user_id = watches.user_id AND muted_watches.bug_id = ? AND muted_watches.muted
+		))`, bug.ID, bug.ID).
+		Distinct().
+		Pluck("watches.user_id", &userIDs).Error
+	return userIDs, err
+}
+
+// GetProjectSubscribers returns the IDs of the members who watch the whole project, for changes that are not about a bug.
+func GetProjectSubscribers(db *gorm.DB, projectID uint) ([]uint, error) {
+	var userIDs []uint
+	err := db.Model(&models.Watch{}).
+		Joins("INNER JOIN teams ON teams.user_id = watches.user_id AND teams.project_id = watches.project_id AND teams.deleted_at IS NULL").
+		Where("watches.project_id = ? AND watches.bug_id IS NULL", projectID).
+		Pluck("watches.user_id", &userIDs).Error
+	return userIDs, err
+}
@@ -0,0 +1,119 @@
+package utils
+
+import (
+	"slices"
+
+	"gorm.io/gorm"
+
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/models"
+	types "github.com/WNBARookie/BugTracker/bug-tracker-backend/src/types"
+)
+
+// Workflow is the set of states a bug of a project can be in, and the transitions allowed between them.
+type Workflow struct {
+	States      []models.WorkflowState
+	Transitions []models.WorkflowTransition
+	IsDefault   bool // The project has not configured a workflow of its own
+}
+
+// allRoles returns the value of every team role, to open a transition to all of them.
+func allRoles() []string {
+	roles := make([]string, 0, len(types.TeamRoles))
+	for _, role := range types.TeamRoles {
+		roles = append(roles, role.Value())
+	}
+	return roles
+}
+
+// DefaultWorkflow returns the workflow of projects that have not configured one, which is the original
+// todo, in_progress and done statuses with every transition open to every role.
+func DefaultWorkflow() Workflow {
+	states := []models.WorkflowState{
+		{Key: "todo", Name: "To Do", Category: types.WorkflowCategoryOpen.Value(), Position: 0, Initial: true},
+		{Key: "in_progress", Name: "In Progress", Category: types.WorkflowCategoryActive.Value(), Position: 1},
+		{Key: "done", Name: "Done", Category: types.WorkflowCategoryClosed.Value(), Position: 2},
+	}
+
+	var transitions []models.WorkflowTransition
+	for _, from := range states {
+		for _, to := range states {
+			if from.Key != to.Key {
+				transitions = append(transitions, models.WorkflowTransition{FromState: from.Key, ToState: to.Key, Roles: allRoles()})
+			}
+		}
+	}
+
+	return Workflow{States: states, Transitions: transitions, IsDefault: true}
+}
+
+// GetProjectWorkflow returns the workflow of the project, or the default workflow if it has not configured one.
+func GetProjectWorkflow(db *gorm.DB, projectID uint) (Workflow, error) {
+	var states []models.WorkflowState
+	if err := db.Where("project_id = ?", projectID).Order("position ASC").Find(&states).Error; err != nil {
+		return Workflow{}, err
+	}
+
+	if len(states) == 0 {
+		return DefaultWorkflow(), nil
+	}
+
+	var transitions []models.WorkflowTransition
+	if err := db.Where("project_id = ?", projectID).Order("id ASC").Find(&transitions).Error; err != nil {
+		return Workflow{}, err
+	}
+
+	return Workflow{States: states, Transitions: transitions}, nil
+}
+
+// State returns the state with the key, or nil if the workflow has no such state.
+func (w Workflow) State(key string) *models.WorkflowState {
+	for i := range w.States {
+		if w.States[i].Key == key {
+			return &w.States[i]
+		}
+	}
+	return nil
+}
+
+// InitialState returns the state new bugs start in.
+func (w Workflow) InitialState() models.WorkflowState {
+	for _, state := range w.States {
+		if state.Initial {
+			return state
+		}
+	}
+	return w.States[0]
+}
+
+// StatesInCategory returns the keys of the states of the category, for example to filter the closed bugs.
+func (w Workflow) StatesInCategory(category types.WorkflowCategory) []string {
+	keys := make([]string, 0)
+	for _, state := range w.States {
+		if state.Category == category.Value() {
+			keys = append(keys, state.Key)
+		}
+	}
+	return keys
+}
+
+// IsClosed reports whether the state is in the closed category.
+func (w Workflow) IsClosed(key string) bool {
+	state := w.State(key)
+	return state != nil && state.Category == types.WorkflowCategoryClosed.Value()
+}
+
+// Transition returns the transition between the states, or nil if bugs cannot move directly from one to the other.
+func (w Workflow) Transition(from, to string) *models.WorkflowTransition {
+	for i := range w.Transitions {
+		if w.Transitions[i].FromState == from && w.Transitions[i].ToState == to {
+			return &w.Transitions[i]
+		}
+	}
+	return nil
+}
+
+// CanTransition reports whether a member with the role may move a bug from one state to the other.
+func (w Workflow) CanTransition(from, to, role string) bool {
+	transition := w.Transition(from, to)
+	return transition != nil && slices.Contains(transition.Roles, role)
+}
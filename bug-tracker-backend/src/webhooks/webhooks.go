@@ -0,0 +1,503 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/conf"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/events"
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/models"
+	types "github.com/WNBARookie/BugTracker/bug-tracker-backend/src/types"
+)
+
+// Headers sent along with every payload. The signature is the HMAC-SHA256 of the timestamp, a dot and the body,
+// keyed with the secret of the webhook, so that receivers can check both where the payload comes from and how old it is.
+const (
+	EventHeader     = "X-BugTracker-Event"
+	DeliveryHeader  = "X-BugTracker-Delivery"
+	TimestampHeader = "X-BugTracker-Timestamp"
+	SignatureHeader = "X-BugTracker-Signature"
+)
+
+const (
+	defaultMaxAttempts  = 6  // Retried after 1, 2, 4, 8 and 16 minutes
+	defaultDisableAfter = 10 // Deliveries failed in a row
+	defaultTimeout      = 10 // in seconds
+	defaultRetention    = 30 // in days
+	retryBaseDelay      = time.Minute
+	maxRetryDelay       = 6 * time.Hour
+	attemptLease        = 5 * time.Minute // A claimed delivery is attempted again after this, in case the server stopped while sending it
+	responseBodyLength  = 1024
+	dueBatchSize        = 100
+)
+
+// ErrForbiddenURL is returned for the URLs webhooks cannot be sent to, so that they cannot be used to reach the
+// services of the internal network of the server.
+var ErrForbiddenURL = errors.New("webhook URL is not an http or https URL of a public host")
+
+// Address ranges that are not covered by the methods of net.IP but are not reachable on the public internet either.
+var reservedNetworks = []*net.IPNet{
+	mustParseCIDR("0.0.0.0/8"),     // This network
+	mustParseCIDR("100.64.0.0/10"), // Shared address space of carrier-grade NAT, also used by some cloud metadata services
+	mustParseCIDR("192.0.0.0/24"),  // IETF protocol assignments
+	mustParseCIDR("198.18.0.0/15"), // Benchmarking
+	mustParseCIDR("240.0.0.0/4"),   // Reserved, including the broadcast address
+}
+
+// dialer only connects to public addresses. The address is checked once resolved, so that a host cannot resolve to
+// an internal address after its URL was accepted.
+var dialer = &net.Dialer{
+	Timeout:   30 * time.Second,
+	KeepAlive: 30 * time.Second,
+	Control: func(network, address string, _ syscall.RawConn) error {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return err
+		}
+		if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+			return ErrForbiddenURL
+		}
+		return nil
+	},
+}
+
+// allowedDialer connects to the hosts of WEBHOOK_ALLOWED_HOSTS, whatever their addresses.
+var allowedDialer = &net.Dialer{
+	Timeout:   30 * time.Second,
+	KeepAlive: 30 * time.Second,
+}
+
+var client = &http.Client{
+	Transport: &http.Transport{
+		// A proxy would connect to the receiver itself, out of reach of the check of the dialer
+		Proxy: nil,
+		DialContext: func(ctx context.Context, network, address string) (net.Conn, error) {
+			if host, _, err := net.SplitHostPort(address); err == nil && isAllowedHost(host) {
+				return allowedDialer.DialContext(ctx, network, address)
+			}
+			return dialer.DialContext(ctx, network, address)
+		},
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	},
+	// A redirect is reported as a failure instead of posting the payload to another URL
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+func mustParseCIDR(cidr string) *net.IPNet {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		panic(err)
+	}
+	return network
+}
+
+// isPublicIP reports whether the address can be reached on the public internet, leaving out the loopback, private,
+// link-local, unspecified, multicast and reserved addresses.
+func isPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsUnspecified() || ip.IsMulticast() {
+		return false
+	}
+
+	for _, network := range reservedNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// isAllowedHost reports whether the host, a name or an address, is listed in WEBHOOK_ALLOWED_HOSTS. Payloads can be
+// sent to these hosts even if they are internal, such as a local receiver in development and tests.
+func isAllowedHost(host string) bool {
+	for _, allowed := range strings.Split(os.Getenv("WEBHOOK_ALLOWED_HOSTS"), ",") {
+		if allowed = strings.TrimSpace(allowed); allowed != "" && strings.EqualFold(allowed, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateURL checks that payloads can be sent to the URL: it must use http or https, and its host must not be an
+// internal address or resolve to one, unless it is allowed by WEBHOOK_ALLOWED_HOSTS. The addresses are checked
+// again on every delivery, as DNS records can change.
+func ValidateURL(ctx context.Context, rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+		return ErrForbiddenURL
+	}
+
+	if isAllowedHost(parsed.Hostname()) {
+		return nil
+	}
+
+	if ip := net.ParseIP(parsed.Hostname()); ip != nil {
+		if !isPublicIP(ip) {
+			return ErrForbiddenURL
+		}
+		return nil
+	}
+
+	// A host that cannot be resolved yet is accepted, its deliveries fail until it can
+	addresses, err := net.DefaultResolver.LookupIPAddr(ctx, parsed.Hostname())
+	if err != nil {
+		return nil
+	}
+	for _, address := range addresses {
+		if !isPublicIP(address.IP) {
+			return ErrForbiddenURL
+		}
+	}
+	return nil
+}
+
+// Register subscribes the webhooks to the events published by the controllers.
+func Register() {
+	events.Subscribe(Enqueue)
+}
+
+// Enqueue records a delivery of the event for every active webhook of the project subscribed to its type, then sends
+// them in the background. Deliveries that cannot be sent right away are retried by the jobs.
+func Enqueue(event events.Event) {
+	if conf.DB == nil {
+		return
+	}
+
+	deliveries, err := enqueue(conf.DB, event)
+	if err != nil {
+		log.Printf("Error while queuing webhook deliveries for %s event: %v", event.Type, err)
+		return
+	}
+
+	if len(deliveries) == 0 {
+		return
+	}
+
+	go func() {
+		for _, delivery := range deliveries {
+			if _, err := Deliver(conf.DB, delivery.ID); err != nil {
+				log.Println("Error while delivering webhook:", err)
+			}
+		}
+	}()
+}
+
+func enqueue(db *gorm.DB, event events.Event) ([]models.WebhookDelivery, error) {
+	var webhooks []models.Webhook
+	if err := db.Where("project_id = ? AND active AND ? = ANY(events)", event.ProjectID, event.Type.Value()).Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+
+	if len(webhooks) == 0 {
+		return nil, nil
+	}
+
+	payload, err := BuildPayload(db, event)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := make([]models.WebhookDelivery, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		delivery, err := NewDelivery(webhook, payload)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err := db.Create(&deliveries).Error; err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// BuildPayload returns the body posted to the webhooks for the event.
+func BuildPayload(db *gorm.DB, event events.Event) (types.WebhookPayload, error) {
+	var project models.Project
+	if err := db.Unscoped().First(&project, event.ProjectID).Error; err != nil {
+		return types.WebhookPayload{}, err
+	}
+
+	eventID, err := NewEventID()
+	if err != nil {
+		return types.WebhookPayload{}, err
+	}
+
+	data := make(map[string]any, len(event.Data)+1)
+	for key, value := range event.Data {
+		data[key] = value
+	}
+	if event.BugID != 0 {
+		data["bug_id"] = event.BugID
+	}
+
+	payload := types.WebhookPayload{
+		ID:         eventID,
+		Event:      event.Type,
+		OccurredAt: event.OccurredAt,
+		Project: types.WebhookPayloadProject{
+			ID:    project.ID,
+			Key:   project.Key,
+			Title: project.Title,
+		},
+		Data: data,
+	}
+
+	if event.ActorID != 0 {
+		var actor models.User
+		if err := db.Unscoped().First(&actor, event.ActorID).Error; err != nil {
+			return types.WebhookPayload{}, err
+		}
+		payload.Actor = &types.AssignedTo{
+			ID:    actor.ID,
+			Name:  actor.Name,
+			Email: actor.Email,
+		}
+	}
+
+	return payload, nil
+}
+
+// NewDelivery returns the pending delivery of the payload to the webhook, due right away.
+func NewDelivery(webhook models.Webhook, payload types.WebhookPayload) (models.WebhookDelivery, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+
+	now := time.Now()
+	return models.WebhookDelivery{
+		WebhookID:     webhook.ID,
+		EventID:       payload.ID,
+		Event:         payload.Event.Value(),
+		Payload:       string(body),
+		Status:        types.WebhookDeliveryStatusPending.Value(),
+		NextAttemptAt: &now,
+	}, nil
+}
+
+// Deliver makes one attempt at sending the delivery, unless it is not due, already being sent or its webhook is
+// disabled, and returns the delivery as it stands afterwards.
+func Deliver(db *gorm.DB, deliveryID uint) (models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+
+	// Claiming the delivery keeps the background sending and the jobs from sending it twice
+	now := time.Now()
+	claim := db.Model(&models.WebhookDelivery{}).
+		Where("id = ? AND status = ? AND next_attempt_at <= ?", deliveryID, types.WebhookDeliveryStatusPending.Value(), now).
+		Where("webhook_id IN (?)", db.Model(&models.Webhook{}).Select("id").Where("active")).
+		Updates(map[string]any{
+			"attempts":        gorm.Expr("attempts + 1"),
+			"next_attempt_at": now.Add(attemptLease),
+		})
+	if claim.Error != nil {
+		return delivery, claim.Error
+	}
+
+	if err := db.Preload("Webhook").First(&delivery, deliveryID).Error; err != nil {
+		return delivery, err
+	}
+
+	if claim.RowsAffected == 0 {
+		return delivery, nil
+	}
+
+	responseStatus, responseBody, duration, sendErr := send(delivery.Webhook, delivery)
+
+	status := types.WebhookDeliveryStatusPending
+	updates := map[string]any{
+		"response_status": responseStatus,
+		"response_body":   responseBody,
+		"duration_ms":     duration.Milliseconds(),
+		"error":           "",
+	}
+
+	if sendErr == nil {
+		status = types.WebhookDeliveryStatusSucceeded
+		updates["next_attempt_at"] = nil
+		updates["delivered_at"] = time.Now()
+	} else if delivery.Attempts >= maxAttempts() {
+		status = types.WebhookDeliveryStatusFailed
+		updates["next_attempt_at"] = nil
+		updates["error"] = sendErr.Error()
+	} else {
+		updates["next_attempt_at"] = time.Now().Add(retryDelay(delivery.Attempts))
+		updates["error"] = sendErr.Error()
+	}
+	updates["status"] = status.Value()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&delivery).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		switch status {
+		case types.WebhookDeliveryStatusSucceeded:
+			return tx.Model(&models.Webhook{}).Where("id = ?", delivery.WebhookID).UpdateColumn("failure_count", 0).Error
+		case types.WebhookDeliveryStatusFailed:
+			return recordFailure(tx, delivery.WebhookID)
+		}
+		return nil
+	})
+
+	return delivery, err
+}
+
+// recordFailure counts a delivery that failed for good against the webhook, disabling it once too many failed in a row.
+func recordFailure(tx *gorm.DB, webhookID uint) error {
+	if err := tx.Model(&models.Webhook{}).Where("id = ?", webhookID).
+		UpdateColumn("failure_count", gorm.Expr("failure_count + 1")).Error; err != nil {
+		return err
+	}
+
+	disableAfter := envInt("WEBHOOK_DISABLE_AFTER", defaultDisableAfter)
+	return tx.Model(&models.Webhook{}).
+		Where("id = ? AND active AND failure_count >= ?", webhookID, disableAfter).
+		Updates(map[string]any{
+			"active":          false,
+			"disabled_reason": fmt.Sprintf("Disabled after %d deliveries failed in a row", disableAfter),
+		}).Error
+}
+
+// send posts the payload of the delivery to the URL of the webhook. Only 2xx responses count as delivered.
+func send(webhook models.Webhook, delivery models.WebhookDelivery) (*int, string, time.Duration, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(envInt("WEBHOOK_TIMEOUT", defaultTimeout))*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewBufferString(delivery.Payload))
+	if err != nil {
+		return nil, "", 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "BugTracker-Webhooks/1.0")
+	req.Header.Set(EventHeader, delivery.Event)
+	req.Header.Set(DeliveryHeader, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, "sha256="+Sign(webhook.Secret, timestamp, delivery.Payload))
+
+	start := time.Now()
+	res, err := client.Do(req)
+	duration := time.Since(start)
+	if err != nil {
+		return nil, "", duration, err
+	}
+	defer res.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(res.Body, responseBodyLength))
+	if !utf8.Valid(body) {
+		body = []byte("(binary response body)")
+	}
+
+	status := res.StatusCode
+	if status < 200 || status >= 300 {
+		return &status, string(body), duration, errors.New("receiver responded with " + res.Status)
+	}
+
+	return &status, string(body), duration, nil
+}
+
+// Sign returns the hex encoded signature of a payload sent at the given Unix timestamp.
+func Sign(secret, timestamp, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// GenerateSecret returns a new random secret for signing the payloads of a webhook.
+func GenerateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// NewEventID returns a random identifier for the payload of an event.
+func NewEventID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// DeliverDue attempts the pending deliveries whose retry is due. It is run periodically by the jobs, which serverless
+// deployments trigger from Vercel Cron.
+func DeliverDue() error {
+	var ids []uint
+	if err := conf.DB.Model(&models.WebhookDelivery{}).
+		Where("status = ? AND next_attempt_at <= ?", types.WebhookDeliveryStatusPending.Value(), time.Now()).
+		Where("webhook_id IN (?)", conf.DB.Model(&models.Webhook{}).Select("id").Where("active")).
+		Order("next_attempt_at ASC").
+		Limit(dueBatchSize).
+		Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		if _, err := Deliver(conf.DB, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PurgeOldDeliveries permanently deletes the deliveries older than the retention period. It is run periodically by the jobs.
+func PurgeOldDeliveries() error {
+	retention := time.Duration(envInt("WEBHOOK_DELIVERY_RETENTION", defaultRetention)) * 24 * time.Hour
+	return conf.DB.Unscoped().
+		Where("created_at < ? AND status <> ?", time.Now().Add(-retention), types.WebhookDeliveryStatusPending.Value()).
+		Delete(&models.WebhookDelivery{}).Error
+}
+
+// retryDelay doubles the wait after every failed attempt.
+func retryDelay(attempts int) time.Duration {
+	delay := retryBaseDelay
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxRetryDelay)
+}
+
+func maxAttempts() int {
+	return envInt("WEBHOOK_MAX_ATTEMPTS", defaultMaxAttempts)
+}
+
+func envInt(name string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(name))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
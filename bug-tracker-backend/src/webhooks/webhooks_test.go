@@ -0,0 +1,281 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/WNBARookie/BugTracker/bug-tracker-backend/src/models"
+	types "github.com/WNBARookie/BugTracker/bug-tracker-backend/src/types"
+)
+
+// receivedRequest is a payload posted to the local receiver.
+type receivedRequest struct {
+	header http.Header
+	body   string
+}
+
+// startReceiver starts a local HTTP receiver answering with the status and allows webhooks to be sent to it.
+func startReceiver(t *testing.T, status int) (*httptest.Server, func() []receivedRequest) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var received []receivedRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		received = append(received, receivedRequest{header: r.Header.Clone(), body: string(body)})
+		mu.Unlock()
+		w.WriteHeader(status)
+		io.WriteString(w, "ok")
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("WEBHOOK_ALLOWED_HOSTS", "example.com, 127.0.0.1")
+
+	return server, func() []receivedRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]receivedRequest(nil), received...)
+	}
+}
+
+// setupTestDB returns an in-memory SQLite database with the tables of the webhooks.
+func setupTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger:                                   logger.Default.LogMode(logger.Silent),
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := db.Migrator().CreateTable(&models.Webhook{}, &models.WebhookDelivery{}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func createDelivery(t *testing.T, db *gorm.DB, webhook models.Webhook) models.WebhookDelivery {
+	t.Helper()
+
+	delivery, err := NewDelivery(webhook, types.WebhookPayload{ID: "event", Event: "bug.created"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&delivery).Error; err != nil {
+		t.Fatal(err)
+	}
+	return delivery
+}
+
+func TestValidateURLRefusesInternalHostsUnlessAllowed(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("WEBHOOK_ALLOWED_HOSTS", "")
+
+	for _, rawURL := range []string{"http://127.0.0.1:8080/hook", "http://[::1]/hook", "http://10.0.0.1/hook", "http://169.254.169.254/latest", "ftp://example.com", "/relative"} {
+		if err := ValidateURL(ctx, rawURL); !errors.Is(err, ErrForbiddenURL) {
+			t.Errorf("%s: error %v, want %v", rawURL, err, ErrForbiddenURL)
+		}
+	}
+	if err := ValidateURL(ctx, "https://93.184.216.34/hook"); err != nil {
+		t.Errorf("public address: %v", err)
+	}
+
+	t.Setenv("WEBHOOK_ALLOWED_HOSTS", "localhost, 127.0.0.1")
+	for _, rawURL := range []string{"http://127.0.0.1:8080/hook", "http://LOCALHOST:3000/hook"} {
+		if err := ValidateURL(ctx, rawURL); err != nil {
+			t.Errorf("%s: %v", rawURL, err)
+		}
+	}
+	if err := ValidateURL(ctx, "http://10.0.0.1/hook"); !errors.Is(err, ErrForbiddenURL) {
+		t.Errorf("address that is not allowed: error %v", err)
+	}
+}
+
+func TestSendRefusesLocalReceiverUnlessAllowed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("payload was sent to a host that is not allowed")
+	}))
+	defer server.Close()
+	t.Setenv("WEBHOOK_ALLOWED_HOSTS", "")
+
+	_, _, _, err := send(models.Webhook{URL: server.URL}, models.WebhookDelivery{Payload: "{}"})
+	if !errors.Is(err, ErrForbiddenURL) {
+		t.Errorf("error %v, want %v", err, ErrForbiddenURL)
+	}
+}
+
+func TestSendSignsThePayload(t *testing.T) {
+	server, received := startReceiver(t, http.StatusOK)
+
+	webhook := models.Webhook{URL: server.URL + "/hook", Secret: "whsec_test"}
+	delivery := models.WebhookDelivery{Event: "bug.created", Payload: `{"id":"event"}`}
+	delivery.ID = 42
+
+	status, body, _, err := send(webhook, delivery)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status == nil || *status != http.StatusOK || body != "ok" {
+		t.Errorf("response = %v %q", status, body)
+	}
+
+	requests := received()
+	if len(requests) != 1 {
+		t.Fatalf("receiver got %d requests", len(requests))
+	}
+	request := requests[0]
+
+	timestamp := request.header.Get(TimestampHeader)
+	if sent, err := strconv.ParseInt(timestamp, 10, 64); err != nil || time.Since(time.Unix(sent, 0)) > time.Minute {
+		t.Errorf("%s = %q", TimestampHeader, timestamp)
+	}
+	if got, want := request.header.Get(SignatureHeader), "sha256="+Sign("whsec_test", timestamp, request.body); got != want {
+		t.Errorf("%s = %q, want %q", SignatureHeader, got, want)
+	}
+	if request.body != delivery.Payload || request.header.Get(EventHeader) != "bug.created" || request.header.Get(DeliveryHeader) != "42" {
+		t.Errorf("request = %+v", request)
+	}
+}
+
+func TestSignMatchesKnownSignature(t *testing.T) {
+	// echo -n '1700000000.{"id":"event"}' | openssl dgst -sha256 -hmac whsec_test
+	want := "6757a645571fb3acc9b792594ae1bf951209ffe8050cf5f94879b22ffe60a66f"
+	if got := Sign("whsec_test", "1700000000", `{"id":"event"}`); got != want {
+		t.Errorf("Sign = %s, want %s", got, want)
+	}
+}
+
+func TestRetryDelayBacksOffExponentially(t *testing.T) {
+	want := map[int]time.Duration{
+		1:  time.Minute,
+		2:  2 * time.Minute,
+		3:  4 * time.Minute,
+		5:  16 * time.Minute,
+		9:  256 * time.Minute,
+		10: maxRetryDelay,
+		50: maxRetryDelay,
+	}
+	for attempts, delay := range want {
+		if got := retryDelay(attempts); got != delay {
+			t.Errorf("retryDelay(%d) = %s, want %s", attempts, got, delay)
+		}
+	}
+}
+
+func TestDeliverSchedulesRetries(t *testing.T) {
+	db := setupTestDB(t)
+	server, _ := startReceiver(t, http.StatusInternalServerError)
+	t.Setenv("WEBHOOK_MAX_ATTEMPTS", "2")
+
+	webhook := models.Webhook{URL: server.URL, Secret: "whsec_test", Events: pq.StringArray{"bug.created"}, Active: true}
+	if err := db.Create(&webhook).Error; err != nil {
+		t.Fatal(err)
+	}
+	delivery := createDelivery(t, db, webhook)
+
+	before := time.Now()
+	if _, err := Deliver(db, delivery.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	db.First(&delivery, delivery.ID)
+	if delivery.Status != types.WebhookDeliveryStatusPending.Value() || delivery.Attempts != 1 || *delivery.ResponseStatus != http.StatusInternalServerError {
+		t.Fatalf("delivery after the first failure = %+v", delivery)
+	}
+	if retryAt := delivery.NextAttemptAt.Sub(before); retryAt < retryBaseDelay || retryAt > retryBaseDelay+time.Minute {
+		t.Errorf("retried in %s, want %s", retryAt, retryBaseDelay)
+	}
+
+	// A delivery is not sent again before its retry is due
+	if _, err := Deliver(db, delivery.ID); err != nil {
+		t.Fatal(err)
+	}
+	if db.First(&delivery, delivery.ID); delivery.Attempts != 1 {
+		t.Fatalf("delivery was attempted %d times before its retry was due", delivery.Attempts)
+	}
+
+	db.Model(&delivery).Update("next_attempt_at", time.Now())
+	if _, err := Deliver(db, delivery.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reloaded into a new value, First leaves the fields that became NULL as they were
+	deliveryID := delivery.ID
+	delivery = models.WebhookDelivery{}
+	db.First(&delivery, deliveryID)
+	if delivery.Status != types.WebhookDeliveryStatusFailed.Value() || delivery.NextAttemptAt != nil {
+		t.Errorf("delivery after the last attempt = %+v", delivery)
+	}
+}
+
+func TestWebhookIsDisabledAfterFailedDeliveries(t *testing.T) {
+	db := setupTestDB(t)
+	server, received := startReceiver(t, http.StatusBadGateway)
+	t.Setenv("WEBHOOK_MAX_ATTEMPTS", "1")
+	t.Setenv("WEBHOOK_DISABLE_AFTER", "3")
+
+	webhook := models.Webhook{URL: server.URL, Secret: "whsec_test", Events: pq.StringArray{"bug.created"}, Active: true}
+	if err := db.Create(&webhook).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 1; i <= 3; i++ {
+		if _, err := Deliver(db, createDelivery(t, db, webhook).ID); err != nil {
+			t.Fatal(err)
+		}
+
+		db.First(&webhook, webhook.ID)
+		if webhook.FailureCount != i || webhook.Active != (i < 3) {
+			t.Fatalf("after %d failed deliveries the webhook is %+v", i, webhook)
+		}
+	}
+	if webhook.DisabledReason == "" {
+		t.Error("disabled webhook has no reason")
+	}
+
+	// Deliveries of a disabled webhook are not sent
+	if _, err := Deliver(db, createDelivery(t, db, webhook).ID); err != nil {
+		t.Fatal(err)
+	}
+	if requests := received(); len(requests) != 3 {
+		t.Errorf("receiver got %d requests, want 3", len(requests))
+	}
+}
+
+func TestSuccessfulDeliveryResetsTheFailures(t *testing.T) {
+	db := setupTestDB(t)
+	server, _ := startReceiver(t, http.StatusNoContent)
+
+	webhook := models.Webhook{URL: server.URL, Secret: "whsec_test", Events: pq.StringArray{"bug.created"}, Active: true, FailureCount: 2}
+	if err := db.Create(&webhook).Error; err != nil {
+		t.Fatal(err)
+	}
+	delivery := createDelivery(t, db, webhook)
+
+	if _, err := Deliver(db, delivery.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	db.First(&delivery, delivery.ID)
+	db.First(&webhook, webhook.ID)
+	if delivery.Status != types.WebhookDeliveryStatusSucceeded.Value() || delivery.DeliveredAt == nil || webhook.FailureCount != 0 {
+		t.Errorf("delivery = %+v, webhook = %+v", delivery, webhook)
+	}
+}